@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"github.com/JoshPugli/grindhouse-api/internal/api"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"context"
-	"io"
 	"syscall"
 	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/api"
+	"github.com/JoshPugli/grindhouse-api/internal/config"
+	"github.com/JoshPugli/grindhouse-api/internal/database"
+	"github.com/JoshPugli/grindhouse-api/internal/migrations"
 )
 
+// @BasePath /api/v1
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
@@ -20,19 +26,66 @@ func run(ctx context.Context, w io.Writer, args []string) error {
 	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	srv := api.NewServer()
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	logger := slog.New(slog.NewTextHandler(w, nil))
+
+	db, err := database.NewConnection(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrations.NewMigrator(db, cfg.Database.Driver, logger)
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 1 && args[1] == "migrate" {
+		return runMigrate(ctx, w, migrator, args[2:])
+	}
+
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		fmt.Fprintf(w, "Applied migration %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	srv, err := api.NewServer(cfg, db, api.Deps{Logger: logger})
+	if err != nil {
+		return fmt.Errorf("failed to create server: %w", err)
+	}
+
+	// Workers outlive the signal so they stop only after the HTTP server
+	// has drained, and the deferred db.Close waits for them.
+	workerCtx, stopWorkers := context.WithCancel(context.WithoutCancel(ctx))
+	workersDone := make(chan struct{})
+	go func() {
+		srv.RunWorkers(workerCtx)
+		close(workersDone)
+	}()
+	defer func() {
+		stopWorkers()
+		<-workersDone
+	}()
+
 	server := &http.Server{
-		Addr:         ":8000",
+		Addr:         cfg.Server.Addr,
 		Handler:      srv,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	serverErrors := make(chan error, 1)
 
 	go func() {
-		fmt.Fprintf(w, "Server listening on port :8000\n")
+		fmt.Fprintf(w, "Server listening on %s\n", cfg.Server.Addr)
 		serverErrors <- server.ListenAndServe()
 	}()
 
@@ -41,26 +94,82 @@ func run(ctx context.Context, w io.Writer, args []string) error {
 		return fmt.Errorf("server error: %w", err)
 	case <-ctx.Done():
 		fmt.Fprintf(w, "\nShutdown signal received...\n")
-		
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 		defer shutdownCancel()
-		
+
 		if err := server.Shutdown(shutdownCtx); err != nil {
 			// Force shutdown
 			server.Close()
 			return fmt.Errorf("could not gracefully shut down server: %w", err)
 		}
-		
+
 		fmt.Fprintf(w, "Server gracefully shut down\n")
 	}
 
 	return nil
 }
 
+// runMigrate implements "migrate up|down|status|redo". The server applies
+// pending migrations when it starts, so this is for inspecting the schema
+// and for stepping back and forth while writing a migration.
+func runMigrate(ctx context.Context, w io.Writer, migrator *migrations.Migrator, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: migrate up|down|status|redo")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(w, "Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintf(w, "Schema is up to date\n")
+		}
+	case "down", "redo":
+		action, verb := migrator.Down, "Reverted"
+		if args[0] == "redo" {
+			action, verb = migrator.Redo, "Redid"
+		}
+		m, err := action(ctx)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			fmt.Fprintf(w, "No migrations are applied\n")
+			return nil
+		}
+		fmt.Fprintf(w, "%s %04d_%s\n", verb, m.Version, m.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			name, state := s.Name, "pending"
+			if s.Unknown {
+				name = "(unknown to this build)"
+			}
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%04d_%-30s %s\n", s.Version, name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, want up, down, status or redo", args[0])
+	}
+
+	return nil
+}
+
 func main() {
 	ctx := context.Background()
 	if err := run(ctx, os.Stdout, os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
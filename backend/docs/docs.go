@@ -15,7 +15,47 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/auth/login": {
+        "/achievements": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List every achievement with whether the authenticated user has unlocked it and their progress towards it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "achievements"
+                ],
+                "summary": "List achievements",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/achievements.Achievement"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
             "post": {
                 "description": "Authenticate user with email and password",
                 "consumes": [
@@ -47,34 +87,34 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid JSON",
+                        "description": "Invalid JSON or missing credentials",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "401": {
                         "description": "Invalid credentials",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "500": {
                         "description": "Failed to generate token",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/auth/me": {
+        "/auth/me": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get the currently authenticated user's information",
+                "description": "Get the currently authenticated user's information, including their experience summary. Servers that store data in SQLite keep no experience and leave it out.",
                 "produces": [
                     "application/json"
                 ],
@@ -93,19 +133,25 @@ const docTemplate = `{
                     "401": {
                         "description": "User not found in context",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "404": {
                         "description": "User not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/auth/register": {
+        "/auth/register": {
             "post": {
                 "description": "Register a new user account",
                 "consumes": [
@@ -137,61 +183,105 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid JSON or missing required fields",
+                        "description": "Invalid JSON or validation error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "409": {
                         "description": "User already exists",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "500": {
                         "description": "Failed to generate token",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/calendar.ics": {
+            "get": {
+                "description": "Subscribe to active goals as all-day events recurring daily. Days on which a goal was completed are marked with a check mark. The token in the URL is the credential; rotate it to revoke access.",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "iCalendar feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Calendar token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "iCalendar document",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "404": {
+                        "description": "Calendar not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
                     }
                 }
             }
         },
-        "/api/goals": {
+        "/calendar/subscription": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all active goals for the authenticated user",
+                "description": "Get the URL of the authenticated user's iCalendar feed",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "goals"
+                    "calendar"
                 ],
-                "summary": "Get user's goals",
+                "summary": "Get calendar subscription",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/goals.Goal"
-                            }
+                            "$ref": "#/definitions/calendar.Subscription"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Calendar subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
@@ -202,205 +292,258 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new goal for the authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Issue a new iCalendar feed URL for the authenticated user. Any previous URL stops working.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "goals"
-                ],
-                "summary": "Create a new goal",
-                "parameters": [
-                    {
-                        "description": "Goal data",
-                        "name": "goal",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/goals.CreateGoalRequest"
-                        }
-                    }
+                    "calendar"
                 ],
+                "summary": "Create or rotate calendar subscription",
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/goals.Goal"
+                            "$ref": "#/definitions/calendar.Subscription"
                         }
                     },
-                    "400": {
-                        "description": "Invalid JSON or validation error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
                         }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke the authenticated user's iCalendar feed URL",
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Delete calendar subscription",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Calendar subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/goals/today": {
+        "/export": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all active goals for the authenticated user with today's daily instances",
+                "description": "Export the authenticated user's profile, settings, goals and daily instances as a ZIP of JSON and CSV files. Small accounts get the archive streamed directly; large accounts, or requests with async=true, get a background job to poll instead.",
                 "produces": [
+                    "application/zip",
                     "application/json"
                 ],
                 "tags": [
-                    "goals"
+                    "export"
+                ],
+                "summary": "Export all data",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Always export in the background",
+                        "name": "async",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get user's goals with today's instances",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "ZIP archive",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/goals.GoalWithTodayInstance"
-                            }
+                            "type": "file"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/export.Job"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/goals/{goalId}/daily": {
-            "put": {
+        "/export/download/{token}": {
+            "get": {
+                "description": "Download a completed export archive. The link itself is the credential and stops working once it expires.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Download an export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Download token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ZIP archive",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found or expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/export/jobs/{jobId}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a daily goal instance for a specific date",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the status of a background export. Completed jobs include a download link that expires at expires_at.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "goals"
+                    "export"
                 ],
-                "summary": "Update daily goal instance",
+                "summary": "Get export job",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Goal ID",
-                        "name": "goalId",
+                        "description": "Export job ID",
+                        "name": "jobId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Date (YYYY-MM-DD format, defaults to today)",
-                        "name": "date",
-                        "in": "query"
-                    },
-                    {
-                        "description": "Daily instance data",
-                        "name": "instance",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/goals.UpdateDailyInstanceRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/goals.DailyGoalInstance"
+                            "$ref": "#/definitions/export.Job"
                         }
                     },
                     "400": {
-                        "description": "Invalid JSON or date format",
+                        "description": "Invalid job ID",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "404": {
-                        "description": "Goal not found",
+                        "description": "Export job not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/goals/{goalId}/history": {
+        "/feed": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get daily instances for a goal within a date range",
+                "description": "Get activity events from the authenticated user and the users they follow, newest first by default",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "goals"
+                    "feed"
                 ],
-                "summary": "Get goal history",
+                "summary": "Get activity feed",
                 "parameters": [
                     {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
                         "type": "string",
-                        "description": "Goal ID",
-                        "name": "goalId",
-                        "in": "path",
-                        "required": true
+                        "description": "Sort order (defaults to desc)",
+                        "name": "order",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Start date (YYYY-MM-DD format, defaults to 30 days ago)",
-                        "name": "startDate",
+                        "type": "integer",
+                        "description": "Page size (defaults to 50, at most 200)",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "End date (YYYY-MM-DD format, defaults to today)",
-                        "name": "endDate",
+                        "description": "Cursor from the previous page",
+                        "name": "cursor",
                         "in": "query"
                     }
                 ],
@@ -408,174 +551,152 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/goals.DailyGoalInstance"
+                            "$ref": "#/definitions/feed.FeedResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Link to the next page, if there is one"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, if there is one"
                             }
                         }
                     },
                     "400": {
-                        "description": "Invalid date format",
+                        "description": "Invalid page parameters",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "404": {
-                        "description": "Goal not found",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/goals/{id}": {
+        "/follows": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a specific goal by ID for the authenticated user",
+                "description": "List the users the authenticated user follows",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "goals"
-                ],
-                "summary": "Get a specific goal",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Goal ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "feed"
                 ],
+                "summary": "List followed users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/goals.Goal"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/feed.Follow"
+                            }
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Goal not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a specific goal by ID for the authenticated user",
+                "description": "Follow another user so their non-private activity appears in your feed",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "goals"
+                    "feed"
                 ],
-                "summary": "Update a goal",
+                "summary": "Follow a user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Goal ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated goal data",
-                        "name": "goal",
+                        "description": "User to follow",
+                        "name": "follow",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/goals.UpdateGoalRequest"
+                            "$ref": "#/definitions/feed.FollowRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/goals.Goal"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": "Invalid JSON",
+                        "description": "Invalid JSON or user ID",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "404": {
-                        "description": "Goal not found",
+                        "description": "User not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/follows/{userId}": {
             "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Soft delete a specific goal by ID for the authenticated user",
+                "description": "Stop following a user. Events already in your feed are kept.",
                 "tags": [
-                    "goals"
+                    "feed"
                 ],
-                "summary": "Delete a goal",
+                "summary": "Unfollow a user",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Goal ID",
-                        "name": "id",
+                        "description": "User ID",
+                        "name": "userId",
                         "in": "path",
                         "required": true
                     }
@@ -584,88 +705,2321 @@ const docTemplate = `{
                     "204": {
                         "description": "No Content"
                     },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "404": {
-                        "description": "Goal not found",
+                        "description": "Follow not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/health": {
+        "/goals": {
             "get": {
-                "description": "Check if the API is running",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of the authenticated user's goals, active ones by default. Follow the Link header with rel=\"next\" for the next page.",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "goals"
+                ],
+                "summary": "Get user's goals",
+                "parameters": [
+                    {
+                        "enum": [
+                            "boolean",
+                            "numeric",
+                            "duration"
+                        ],
+                        "type": "string",
+                        "description": "Only goals of this type",
+                        "name": "goal_type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "archived",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "Goals to include (defaults to active)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only goals created at or after this time (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only goals created before this time (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "updated_at",
+                            "title"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (defaults to created_at)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (defaults to desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (defaults to 50, at most 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/goals.Goal"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Entity tag of the response"
+                            },
+                            "Link": {
+                                "type": "string",
+                                "description": "Link to the next page, if there is one"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, if there is one"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Invalid filter or page parameters",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new goal for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "goals"
+                ],
+                "summary": "Create a new goal",
+                "parameters": [
+                    {
+                        "description": "Goal data",
+                        "name": "goal",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/goals.CreateGoalRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/goals.Goal"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or validation error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/protected": {
+        "/goals/today": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Example protected endpoint that requires authentication",
+                "description": "Get all active goals for the authenticated user with today's daily instances",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "protected"
+                    "goals"
+                ],
+                "summary": "Get user's goals with today's instances",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Protected endpoint",
                 "responses": {
                     "200": {
-                        "description": "Protected route accessed",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/goals.GoalWithTodayInstance"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Entity tag of the response"
+                            }
                         }
                     },
+                    "304": {
+                        "description": "Not Modified"
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "goals.CreateGoalRequest": {
+        },
+        "/goals/{goalId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a specific goal by ID for the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "goals"
+                ],
+                "summary": "Get a specific goal",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Goal ID",
+                        "name": "goalId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/goals.Goal"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Goal version"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Invalid goal ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Goal not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a specific goal by ID for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "goals"
+                ],
+                "summary": "Update a goal",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Goal ID",
+                        "name": "goalId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated goal data",
+                        "name": "goal",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/goals.UpdateGoalRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being modified",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/goals.Goal"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New goal version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid goal ID, JSON or validation error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Goal not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Modified concurrently without If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match the current version",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Soft delete a specific goal by ID for the authenticated user",
+                "tags": [
+                    "goals"
+                ],
+                "summary": "Delete a goal",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Goal ID",
+                        "name": "goalId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being modified",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid goal ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Goal not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Modified concurrently without If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match the current version",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/goals/{goalId}/daily": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a daily goal instance for a specific date",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "goals"
+                ],
+                "summary": "Update daily goal instance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Goal ID",
+                        "name": "goalId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date (YYYY-MM-DD format, defaults to today)",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Daily instance data",
+                        "name": "instance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/goals.UpdateDailyInstanceRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being modified",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/goals.DailyGoalInstance"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New instance version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid goal ID, JSON, date format or validation error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Goal not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Modified concurrently without If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match does not match the current version",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/goals/{goalId}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of a goal's daily instances within a date range. Follow the Link header with rel=\"next\" for the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "goals"
+                ],
+                "summary": "Get goal history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Goal ID",
+                        "name": "goalId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (YYYY-MM-DD format, defaults to 30 days before endDate)",
+                        "name": "startDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (YYYY-MM-DD format, defaults to today)",
+                        "name": "endDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only completed days",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "date"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (defaults to date)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (defaults to desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (defaults to 50, at most 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/goals.DailyGoalInstance"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Entity tag of the response"
+                            },
+                            "Link": {
+                                "type": "string",
+                                "description": "Link to the next page, if there is one"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, if there is one"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Invalid goal ID, filter or page parameters",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Goal not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Check if the API is running",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Import goals and history from a Loop Habit Tracker CSV export (ZIP) or database backup, a Habitica JSON export, or a generic CSV file. The file is sent either as the raw request body or as the \"file\" field of a multipart form. Habits already imported from the same source are reused and days that already have an instance are skipped, so re-importing a file is safe. Rows that cannot be parsed, and habits and entries that fail the validation of the goals endpoints, are reported per row in errors and do not stop the import. With dry_run=true nothing is saved, and the same errors are reported.",
+                "consumes": [
+                    "application/octet-stream",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "import"
+                ],
+                "summary": "Import from another habit tracker",
+                "parameters": [
+                    {
+                        "enum": [
+                            "loop-csv",
+                            "loop-sqlite",
+                            "habitica",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "File format",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Preview the import without saving it",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV: column holding the goal name",
+                        "name": "goal_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV: column holding the date",
+                        "name": "date_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV: column holding the numeric value",
+                        "name": "value_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV: column holding whether the day was completed",
+                        "name": "completed_column",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV: Go time layout of the date column (default 2006-01-02)",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV: goal type for created goals (default numeric if value_column is set, otherwise boolean)",
+                        "name": "goal_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to import, when sending a multipart form",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/importer.ImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format, mapping or file",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingest-tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the authenticated user's active ingestion tokens. Only token prefixes are returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "List ingestion tokens",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ingest.Token"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a token that lets another app push values into a numeric or duration goal. The token is only returned in this response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Create an ingestion token",
+                "parameters": [
+                    {
+                        "description": "Goal and default mode (replace or accumulate, defaults to replace)",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ingest.CreateTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/ingest.Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON, mode or goal type",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Goal not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingest-tokens/{tokenId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke an ingestion token so it can no longer push values",
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Revoke an ingestion token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token ID",
+                        "name": "tokenId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid token ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Token not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingest/{token}": {
+            "post": {
+                "description": "Push one or more values into the token's goal. Each value lands on the daily instance for the calendar date of its timestamp (in the timestamp's own offset, defaulting to now). Values with a source_id already seen for the goal are skipped. In replace mode the value overwrites the day's completed value; in accumulate mode it is added to it. In accumulate mode a value needs a source_id or a timestamp, from which one is derived, so that retries are not counted twice. Values that are negative, over 99999999.99, or would take the day's total over it are rejected individually and the rest are still applied.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Ingest values",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ingestion token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Values to ingest",
+                        "name": "values",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ingest.IngestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ingest.IngestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or mode",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Token not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Too many values",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/protected": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Example protected endpoint that requires authentication",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "protected"
+                ],
+                "summary": "Protected endpoint",
+                "responses": {
+                    "200": {
+                        "description": "Protected route accessed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Full-text search over the authenticated user's goal titles and descriptions, most relevant first. Matched words in the highlights are wrapped in \u003cmark\u003e and \u003c/mark\u003e.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Search goals",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search terms; supports \\",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "archived",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "Goals to search (defaults to all)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results (default 20, max 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/search.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid search parameters",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/sync": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get goals, daily instances and deletions changed since a cursor, oldest first. Omit since for a full sync. Records may be returned more than once across syncs, so clients must apply them idempotently, keeping the copy with the highest revision. Keep requesting with the returned cursor while has_more is true, and store the final cursor for the next sync.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sync"
+                ],
+                "summary": "Pull changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous sync",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum records per page (default 500, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/devicesync.Changes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor or limit",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apply goal and daily instance mutations made offline, using client-generated IDs. Only fields present in a mutation are written. If a record changed on the server since base_revision, the mutation wins only if its modified_at is later than the server's updated_at; otherwise it is rejected as a conflict. Mutations that fail the validation of the goals endpoints are rejected individually as invalid. The response contains the server's copy of every record touched, including those that lost a conflict.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sync"
+                ],
+                "summary": "Push changes",
+                "parameters": [
+                    {
+                        "description": "Mutations to apply",
+                        "name": "changes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/devicesync.PushRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/devicesync.PushResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or too many mutations",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the authenticated user's active webhook subscriptions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhooks.Subscription"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Register a URL to receive signed goal and check-in events. The signing secret is only returned in this response. Each delivery carries an X-Webhook-Timestamp header with the Unix time it was sent and an X-Webhook-Signature-256 header of \"sha256=\" followed by the hex HMAC-SHA256, keyed with the secret, of the timestamp, a dot and the body. Receivers should reject deliveries whose timestamp is more than a few minutes old. Deliveries are only sent to public addresses; a URL that resolves to a loopback, private or link-local address fails.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register a webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook subscription",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.CreateSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON, URL or event types",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{webhookId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deactivate a webhook subscription. Pending deliveries are abandoned; the delivery log is kept.",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{webhookId}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the most recent deliveries for a webhook with their status, attempt count and last response",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook delivery log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of deliveries (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhooks.Delivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook ID or limit",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Queue a new delivery with the same payload as an earlier one",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Redeliver a webhook event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.Delivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid webhook or delivery ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Delivery not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/xp": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the authenticated user's total experience, level and most recent ledger entries",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "xp"
+                ],
+                "summary": "Get experience summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of recent awards (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/xp.Summary"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "achievements.Achievement": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "progress": {
+                    "$ref": "#/definitions/achievements.Progress"
+                },
+                "unlocked": {
+                    "type": "boolean"
+                },
+                "unlocked_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "achievements.Progress": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "integer"
+                },
+                "target": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apperr.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "apperr.Problem": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "Invalid JSON"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apperr.FieldError"
+                    }
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "6f1c2a4e-93b1-4c55-a1c8-0d9f3e7b2a10"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Bad Request"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "calendar.Subscription": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "devicesync.Changes": {
+            "type": "object",
+            "properties": {
+                "cursor": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/devicesync.Tombstone"
+                    }
+                },
+                "goals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/devicesync.Goal"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "instances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/devicesync.Instance"
+                    }
+                }
+            }
+        },
+        "devicesync.Entity": {
+            "type": "string",
+            "enum": [
+                "goal",
+                "instance"
+            ],
+            "x-enum-varnames": [
+                "EntityGoal",
+                "EntityInstance"
+            ]
+        },
+        "devicesync.Goal": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "$ref": "#/definitions/goals.GoalDifficulty"
+                },
+                "goal_type": {
+                    "$ref": "#/definitions/goals.GoalType"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "revision": {
+                    "type": "string"
+                },
+                "target_value": {
+                    "type": "number"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/goals.GoalVisibility"
+                }
+            }
+        },
+        "devicesync.GoalMutation": {
+            "type": "object",
+            "properties": {
+                "base_revision": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "$ref": "#/definitions/goals.GoalDifficulty"
+                },
+                "goal_type": {
+                    "$ref": "#/definitions/goals.GoalType"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "modified_at": {
+                    "type": "string"
+                },
+                "target_value": {
+                    "type": "number"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/goals.GoalVisibility"
+                }
+            }
+        },
+        "devicesync.Instance": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "completed_value": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "goal_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_completed": {
+                    "type": "boolean"
+                },
+                "revision": {
+                    "type": "string"
+                },
+                "target_value": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "devicesync.InstanceMutation": {
+            "type": "object",
+            "properties": {
+                "base_revision": {
+                    "type": "string"
+                },
+                "completed_value": {
+                    "type": "number"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "goal_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_completed": {
+                    "type": "boolean"
+                },
+                "modified_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "devicesync.PushRequest": {
+            "type": "object",
+            "properties": {
+                "goals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/devicesync.GoalMutation"
+                    }
+                },
+                "instances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/devicesync.InstanceMutation"
+                    }
+                }
+            }
+        },
+        "devicesync.PushResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/devicesync.Tombstone"
+                    }
+                },
+                "goals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/devicesync.Goal"
+                    }
+                },
+                "instances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/devicesync.Instance"
+                    }
+                },
+                "rejected": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/devicesync.Rejection"
+                    }
+                }
+            }
+        },
+        "devicesync.RejectReason": {
+            "type": "string",
+            "enum": [
+                "conflict",
+                "invalid",
+                "not_found"
+            ],
+            "x-enum-varnames": [
+                "RejectConflict",
+                "RejectInvalid",
+                "RejectNotFound"
+            ]
+        },
+        "devicesync.Rejection": {
+            "type": "object",
+            "properties": {
+                "entity": {
+                    "$ref": "#/definitions/devicesync.Entity"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "reason": {
+                    "$ref": "#/definitions/devicesync.RejectReason"
+                }
+            }
+        },
+        "devicesync.Tombstone": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "type": "string"
+                },
+                "entity": {
+                    "$ref": "#/definitions/devicesync.Entity"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "revision": {
+                    "type": "string"
+                }
+            }
+        },
+        "export.Job": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "download_url": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/export.JobStatus"
+                }
+            }
+        },
+        "export.JobStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "completed",
+                "failed",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "JobPending",
+                "JobRunning",
+                "JobCompleted",
+                "JobFailed",
+                "JobExpired"
+            ]
+        },
+        "feed.Event": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "type": "string"
+                },
+                "actor_name": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "$ref": "#/definitions/feed.EventType"
+                },
+                "goal_id": {
+                    "type": "string"
+                },
+                "goal_title": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                }
+            }
+        },
+        "feed.EventType": {
+            "type": "string",
+            "enum": [
+                "goal_completed",
+                "streak_milestone"
+            ],
+            "x-enum-varnames": [
+                "EventGoalCompleted",
+                "EventStreakMilestone"
+            ]
+        },
+        "feed.FeedResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/feed.Event"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "feed.Follow": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "feed.FollowRequest": {
+            "type": "object",
+            "properties": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "goals.CreateGoalRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "$ref": "#/definitions/goals.GoalDifficulty"
+                },
+                "goal_type": {
+                    "$ref": "#/definitions/goals.GoalType"
+                },
+                "target_value": {
+                    "type": "number"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/goals.GoalVisibility"
+                }
+            }
+        },
+        "goals.DailyGoalInstance": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "completed_value": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "goal_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_completed": {
+                    "type": "boolean"
+                },
+                "target_value": {
+                    "type": "number"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "goals.Goal": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "$ref": "#/definitions/goals.GoalDifficulty"
+                },
+                "goal_type": {
+                    "$ref": "#/definitions/goals.GoalType"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "target_value": {
+                    "type": "number"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/goals.GoalVisibility"
+                }
+            }
+        },
+        "goals.GoalDifficulty": {
+            "type": "string",
+            "enum": [
+                "easy",
+                "medium",
+                "hard"
+            ],
+            "x-enum-varnames": [
+                "DifficultyEasy",
+                "DifficultyMedium",
+                "DifficultyHard"
+            ]
+        },
+        "goals.GoalType": {
+            "type": "string",
+            "enum": [
+                "boolean",
+                "numeric",
+                "duration"
+            ],
+            "x-enum-varnames": [
+                "GoalTypeBoolean",
+                "GoalTypeNumeric",
+                "GoalTypeDuration"
+            ]
+        },
+        "goals.GoalVisibility": {
+            "type": "string",
+            "enum": [
+                "private",
+                "followers",
+                "public"
+            ],
+            "x-enum-varnames": [
+                "VisibilityPrivate",
+                "VisibilityFollowers",
+                "VisibilityPublic"
+            ]
+        },
+        "goals.GoalWithTodayInstance": {
+            "type": "object",
+            "properties": {
+                "goal": {
+                    "$ref": "#/definitions/goals.Goal"
+                },
+                "today_instance": {
+                    "$ref": "#/definitions/goals.DailyGoalInstance"
+                }
+            }
+        },
+        "goals.UpdateDailyInstanceRequest": {
+            "type": "object",
+            "properties": {
+                "completed_value": {
+                    "type": "number"
+                },
+                "is_completed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "goals.UpdateGoalRequest": {
             "type": "object",
             "properties": {
                 "description": {
                     "type": "string"
                 },
-                "goal_type": {
-                    "$ref": "#/definitions/goals.GoalType"
+                "difficulty": {
+                    "$ref": "#/definitions/goals.GoalDifficulty"
+                },
+                "is_active": {
+                    "type": "boolean"
                 },
                 "target_value": {
                     "type": "number"
@@ -675,127 +3029,248 @@ const docTemplate = `{
                 },
                 "unit": {
                     "type": "string"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/goals.GoalVisibility"
                 }
             }
         },
-        "goals.DailyGoalInstance": {
+        "importer.Format": {
+            "type": "string",
+            "enum": [
+                "loop-csv",
+                "loop-sqlite",
+                "habitica",
+                "csv"
+            ],
+            "x-enum-varnames": [
+                "FormatLoopCSV",
+                "FormatLoopSQLite",
+                "FormatHabitica",
+                "FormatCSV"
+            ]
+        },
+        "importer.HabitSummary": {
             "type": "object",
             "properties": {
-                "completed_at": {
-                    "type": "string"
-                },
-                "completed_value": {
-                    "type": "number"
+                "entries": {
+                    "type": "integer"
                 },
-                "created_at": {
-                    "type": "string"
+                "existing": {
+                    "type": "boolean"
                 },
-                "date": {
+                "first_date": {
                     "type": "string"
                 },
                 "goal_id": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
+                "goal_type": {
+                    "$ref": "#/definitions/goals.GoalType"
                 },
-                "is_completed": {
-                    "type": "boolean"
+                "last_date": {
+                    "type": "string"
                 },
-                "target_value": {
-                    "type": "number"
+                "new_entries": {
+                    "type": "integer"
                 },
-                "user_id": {
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "goals.Goal": {
+        "importer.ImportResult": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "description": {
-                    "type": "string"
+                "entries_added": {
+                    "type": "integer"
                 },
-                "goal_type": {
-                    "$ref": "#/definitions/goals.GoalType"
+                "entries_skipped": {
+                    "type": "integer"
                 },
-                "id": {
-                    "type": "string"
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/importer.RowError"
+                    }
                 },
-                "is_active": {
-                    "type": "boolean"
+                "format": {
+                    "$ref": "#/definitions/importer.Format"
                 },
-                "target_value": {
-                    "type": "number"
+                "goals_created": {
+                    "type": "integer"
                 },
-                "title": {
+                "goals_reused": {
+                    "type": "integer"
+                },
+                "habits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/importer.HabitSummary"
+                    }
+                }
+            }
+        },
+        "importer.RowError": {
+            "type": "object",
+            "properties": {
+                "field": {
                     "type": "string"
                 },
-                "unit": {
+                "file": {
                     "type": "string"
                 },
-                "updated_at": {
+                "message": {
                     "type": "string"
                 },
-                "user_id": {
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ingest.CreateTokenRequest": {
+            "type": "object",
+            "properties": {
+                "goal_id": {
                     "type": "string"
+                },
+                "mode": {
+                    "$ref": "#/definitions/ingest.Mode"
                 }
             }
         },
-        "goals.GoalType": {
+        "ingest.IngestRequest": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "$ref": "#/definitions/ingest.Mode"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ingest.Value"
+                    }
+                }
+            }
+        },
+        "ingest.IngestResponse": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "duplicates": {
+                    "type": "integer"
+                },
+                "instances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/goals.DailyGoalInstance"
+                    }
+                },
+                "rejected": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ingest.Rejection"
+                    }
+                }
+            }
+        },
+        "ingest.Mode": {
             "type": "string",
             "enum": [
-                "boolean",
-                "numeric",
-                "duration"
+                "replace",
+                "accumulate"
             ],
             "x-enum-varnames": [
-                "GoalTypeBoolean",
-                "GoalTypeNumeric",
-                "GoalTypeDuration"
+                "ModeReplace",
+                "ModeAccumulate"
             ]
         },
-        "goals.GoalWithTodayInstance": {
+        "ingest.Rejection": {
             "type": "object",
             "properties": {
-                "goal": {
-                    "$ref": "#/definitions/goals.Goal"
+                "index": {
+                    "type": "integer"
                 },
-                "today_instance": {
-                    "$ref": "#/definitions/goals.DailyGoalInstance"
+                "message": {
+                    "type": "string"
                 }
             }
         },
-        "goals.UpdateDailyInstanceRequest": {
+        "ingest.Token": {
             "type": "object",
             "properties": {
-                "completed_value": {
-                    "type": "number"
+                "created_at": {
+                    "type": "string"
                 },
-                "is_completed": {
-                    "type": "boolean"
+                "goal_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "mode": {
+                    "$ref": "#/definitions/ingest.Mode"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "token_prefix": {
+                    "type": "string"
                 }
             }
         },
-        "goals.UpdateGoalRequest": {
+        "ingest.Value": {
             "type": "object",
             "properties": {
-                "description": {
+                "source_id": {
                     "type": "string"
                 },
-                "is_active": {
-                    "type": "boolean"
+                "timestamp": {
+                    "type": "string"
                 },
-                "target_value": {
+                "value": {
                     "type": "number"
+                }
+            }
+        },
+        "search.Response": {
+            "type": "object",
+            "properties": {
+                "query": {
+                    "type": "string"
                 },
-                "title": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/search.Result"
+                    }
+                }
+            }
+        },
+        "search.Result": {
+            "type": "object",
+            "properties": {
+                "description_highlight": {
+                    "description": "DescriptionHighlight holds the fragments of the description around\nmatched words, or null if the goal has no description.",
                     "type": "string"
                 },
-                "unit": {
+                "goal": {
+                    "$ref": "#/definitions/goals.Goal"
+                },
+                "rank": {
+                    "description": "Rank is the relevance of the match; results are ordered by it.",
+                    "type": "number"
+                },
+                "title_highlight": {
+                    "description": "TitleHighlight is the title with matched words marked.",
                     "type": "string"
                 }
             }
@@ -829,14 +3304,186 @@ const docTemplate = `{
                 "first_name": {
                     "type": "string"
                 },
-                "last_name": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhooks.CreateSubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webhooks.EventType"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhooks.Delivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
                     "type": "string"
                 },
-                "password": {
+                "event_type": {
+                    "$ref": "#/definitions/webhooks.EventType"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_attempt_at": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "response_status": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/webhooks.DeliveryStatus"
+                },
+                "subscription_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhooks.DeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "succeeded",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusSucceeded",
+                "StatusFailed"
+            ]
+        },
+        "webhooks.EventType": {
+            "type": "string",
+            "enum": [
+                "goal.created",
+                "goal.updated",
+                "goal.deleted",
+                "checkin.updated",
+                "checkin.completed",
+                "checkin.uncompleted",
+                "checkin.deleted"
+            ],
+            "x-enum-varnames": [
+                "EventGoalCreated",
+                "EventGoalUpdated",
+                "EventGoalDeleted",
+                "EventCheckinUpdated",
+                "EventCheckinCompleted",
+                "EventCheckinUncompleted",
+                "EventCheckinDeleted"
+            ]
+        },
+        "webhooks.Subscription": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webhooks.EventType"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "xp.Award": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "goal_id": {
+                    "type": "string"
+                },
+                "goal_title": {
                     "type": "string"
                 },
-                "username": {
+                "id": {
+                    "type": "string"
+                },
+                "instance_id": {
                     "type": "string"
+                },
+                "reason": {
+                    "$ref": "#/definitions/xp.Reason"
+                }
+            }
+        },
+        "xp.Reason": {
+            "type": "string",
+            "enum": [
+                "completion",
+                "reversal"
+            ],
+            "x-enum-varnames": [
+                "ReasonCompletion",
+                "ReasonReversal"
+            ]
+        },
+        "xp.Summary": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "type": "integer"
+                },
+                "level_floor_xp": {
+                    "type": "integer"
+                },
+                "next_level_xp": {
+                    "type": "integer"
+                },
+                "recent_awards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/xp.Award"
+                    }
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         }
@@ -855,7 +3502,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "",
 	Host:             "",
-	BasePath:         "",
+	BasePath:         "/api/v1",
 	Schemes:          []string{},
 	Title:            "",
 	Description:      "",
@@ -0,0 +1,95 @@
+package achievements
+
+import (
+	"context"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+// Engine evaluates Rules against a user's stats and persists any newly
+// unlocked achievements. Evaluation is idempotent: unlocked achievements
+// are never revoked and re-running it never changes their timestamps.
+type Engine struct {
+	achievementRepo *Repository
+	clock           func() time.Time
+}
+
+func NewEngine(achievementRepo *Repository, clock func() time.Time) *Engine {
+	return &Engine{
+		achievementRepo: achievementRepo,
+		clock:           clock,
+	}
+}
+
+// Evaluate unlocks every rule the user currently satisfies.
+func (e *Engine) Evaluate(ctx context.Context, userID string) error {
+	stats, err := e.achievementRepo.GetStats(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	for _, rule := range Rules {
+		if rule.Satisfied(stats) {
+			keys = append(keys, rule.Key)
+		}
+	}
+
+	return e.achievementRepo.Unlock(ctx, userID, keys, e.clock())
+}
+
+// List returns every achievement with the user's unlock state and progress.
+func (e *Engine) List(ctx context.Context, userID string) ([]Achievement, error) {
+	stats, err := e.achievementRepo.GetStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	unlocked, err := e.achievementRepo.GetUnlocked(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	achievements := make([]Achievement, 0, len(Rules))
+	for _, rule := range Rules {
+		achievement := Achievement{
+			Key:         rule.Key,
+			Name:        rule.Name,
+			Description: rule.Description,
+			Progress:    rule.Progress(stats),
+		}
+		if at, ok := unlocked[rule.Key]; ok {
+			achievement.Unlocked = true
+			achievement.UnlockedAt = &at
+			achievement.Progress.Current = achievement.Progress.Target
+		}
+		achievements = append(achievements, achievement)
+	}
+
+	return achievements, nil
+}
+
+// GoalChanged implements goals.Listener, re-evaluating rules whenever a
+// daily instance's completion state or value changes.
+func (e *Engine) GoalChanged(ctx context.Context, change goals.Change) error {
+	if change.Kind != goals.InstanceUpdated || change.Instance == nil {
+		return nil
+	}
+
+	if change.Previous != nil && !instanceStateChanged(change.Previous, change.Instance) {
+		return nil
+	}
+
+	return e.Evaluate(ctx, change.UserID)
+}
+
+func instanceStateChanged(before, after *goals.DailyGoalInstance) bool {
+	if before.IsCompleted != after.IsCompleted {
+		return true
+	}
+	if (before.CompletedValue == nil) != (after.CompletedValue == nil) {
+		return true
+	}
+	return before.CompletedValue != nil && *before.CompletedValue != *after.CompletedValue
+}
@@ -0,0 +1,47 @@
+// Package achievements unlocks badges when users hit goal milestones
+package achievements
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+)
+
+type Handlers struct {
+	engine *Engine
+}
+
+func NewHandlers(engine *Engine) *Handlers {
+	return &Handlers{
+		engine: engine,
+	}
+}
+
+// HandleGetAchievements godoc
+// @Summary List achievements
+// @Description List every achievement with whether the authenticated user has unlocked it and their progress towards it
+// @Tags achievements
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} Achievement
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /achievements [get]
+func (h *Handlers) HandleGetAchievements(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	achievements, err := h.engine.List(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(achievements)
+}
@@ -0,0 +1,46 @@
+package achievements
+
+import "time"
+
+// Metric names a per-user statistic that rules are evaluated against.
+type Metric string
+
+const (
+	MetricTotalCompletions Metric = "total_completions"
+	MetricLongestStreak    Metric = "longest_streak"
+	MetricOverTarget       Metric = "over_target_completions"
+)
+
+// Stats holds the current value of every Metric for a user.
+type Stats struct {
+	TotalCompletions int
+	LongestStreak    int
+	OverTarget       int
+}
+
+// Value returns the value of m in s.
+func (s Stats) Value(m Metric) int {
+	switch m {
+	case MetricTotalCompletions:
+		return s.TotalCompletions
+	case MetricLongestStreak:
+		return s.LongestStreak
+	case MetricOverTarget:
+		return s.OverTarget
+	}
+	return 0
+}
+
+type Achievement struct {
+	Key         string     `json:"key"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Unlocked    bool       `json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlocked_at"`
+	Progress    Progress   `json:"progress"`
+}
+
+type Progress struct {
+	Current int `json:"current"`
+	Target  int `json:"target"`
+}
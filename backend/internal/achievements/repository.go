@@ -0,0 +1,88 @@
+package achievements
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) GetStats(ctx context.Context, userID string) (Stats, error) {
+	query := `
+		WITH completed AS (
+			SELECT goal_id, date - (ROW_NUMBER() OVER (PARTITION BY goal_id ORDER BY date))::int AS grp
+			FROM daily_goal_instances
+			WHERE user_id = $1 AND is_completed = true
+		)
+		SELECT
+			(SELECT COUNT(*) FROM completed),
+			(SELECT COALESCE(MAX(n), 0) FROM (SELECT COUNT(*) AS n FROM completed GROUP BY goal_id, grp) streaks),
+			(SELECT COUNT(*)
+			 FROM daily_goal_instances dgi
+			 JOIN goals g ON g.id = dgi.goal_id
+			 WHERE dgi.user_id = $1
+			   AND dgi.is_completed = true
+			   AND g.goal_type = 'numeric'
+			   AND COALESCE(dgi.target_value, g.target_value) > 0
+			   AND dgi.completed_value >= COALESCE(dgi.target_value, g.target_value) * $2)
+	`
+	var stats Stats
+	err := r.db.QueryRowContext(ctx, query, userID, OverTargetRatio).Scan(&stats.TotalCompletions, &stats.LongestStreak, &stats.OverTarget)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to get achievement stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// Unlock records the given achievements as unlocked at the given time.
+// Achievements that are already unlocked keep their original timestamp.
+func (r *Repository) Unlock(ctx context.Context, userID string, keys []string, at time.Time) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO user_achievements (user_id, achievement_key, unlocked_at)
+		SELECT $1, key, $3 FROM unnest($2::text[]) AS key
+		ON CONFLICT (user_id, achievement_key) DO NOTHING
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, pq.Array(keys), at); err != nil {
+		return fmt.Errorf("failed to unlock achievements: %w", err)
+	}
+
+	return nil
+}
+
+// GetUnlocked returns the unlock time of every achievement the user has
+// unlocked, keyed by achievement key.
+func (r *Repository) GetUnlocked(ctx context.Context, userID string) (map[string]time.Time, error) {
+	query := `SELECT achievement_key, unlocked_at FROM user_achievements WHERE user_id = $1`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievements: %w", err)
+	}
+	defer rows.Close()
+
+	unlocked := make(map[string]time.Time)
+	for rows.Next() {
+		var key string
+		var unlockedAt time.Time
+		if err := rows.Scan(&key, &unlockedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan achievement: %w", err)
+		}
+		unlocked[key] = unlockedAt
+	}
+
+	return unlocked, nil
+}
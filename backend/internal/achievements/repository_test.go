@@ -0,0 +1,102 @@
+package achievements_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/achievements"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+)
+
+// TestEvaluateIdempotent evaluates the same stats twice and unlocks an
+// already unlocked badge again, and checks that neither moves the original
+// unlock time.
+func TestEvaluateIdempotent(t *testing.T) {
+	db := storetest.OpenPostgres(t)
+	ctx := t.Context()
+
+	owner := storetest.NewUser(t, db)
+	goalRepo := goals.NewRepository(db, time.Now)
+	target := 10.0
+	goal, err := goalRepo.CreateGoal(ctx, owner.ID, goals.CreateGoalRequest{Title: "Push-ups", GoalType: goals.GoalTypeNumeric, TargetValue: &target})
+	if err != nil {
+		t.Fatalf("CreateGoal: %v", err)
+	}
+	value, completed := 15.0, true
+	date := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	if _, err := goalRepo.UpsertDailyInstance(ctx, goal.ID, owner.ID, date, goals.UpdateDailyInstanceRequest{CompletedValue: &value, IsCompleted: &completed}, nil); err != nil {
+		t.Fatalf("UpsertDailyInstance: %v", err)
+	}
+
+	repo := achievements.NewRepository(db)
+	engine := achievements.NewEngine(repo, time.Now)
+	if err := engine.Evaluate(ctx, owner.ID); err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	first, err := repo.GetUnlocked(ctx, owner.ID)
+	if err != nil {
+		t.Fatalf("GetUnlocked: %v", err)
+	}
+	_, firstCompletion := first["first_completion"]
+	_, overTarget := first["over_target"]
+	if len(first) != 2 || !firstCompletion || !overTarget {
+		t.Fatalf("unlocked %v, want first_completion and over_target", first)
+	}
+
+	if err := engine.Evaluate(ctx, owner.ID); err != nil {
+		t.Fatalf("Evaluate again: %v", err)
+	}
+	if err := repo.Unlock(ctx, owner.ID, []string{"first_completion"}, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Unlock again: %v", err)
+	}
+
+	again, err := repo.GetUnlocked(ctx, owner.ID)
+	if err != nil {
+		t.Fatalf("GetUnlocked: %v", err)
+	}
+	if len(again) != len(first) {
+		t.Errorf("unlocked %v after re-evaluating, want %v", again, first)
+	}
+	for key, at := range first {
+		if !again[key].Equal(at) {
+			t.Errorf("%s unlocked at %v after re-evaluating, want %v", key, again[key], at)
+		}
+	}
+}
+
+// TestStatsOverTargetCompletedOnly checks that an instance past its target
+// counts as over target only while it is checked off.
+func TestStatsOverTargetCompletedOnly(t *testing.T) {
+	db := storetest.OpenPostgres(t)
+	ctx := t.Context()
+
+	owner := storetest.NewUser(t, db)
+	goalRepo := goals.NewRepository(db, time.Now)
+	target := 10.0
+	goal, err := goalRepo.CreateGoal(ctx, owner.ID, goals.CreateGoalRequest{Title: "Push-ups", GoalType: goals.GoalTypeNumeric, TargetValue: &target})
+	if err != nil {
+		t.Fatalf("CreateGoal: %v", err)
+	}
+	repo := achievements.NewRepository(db)
+	date := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+	for _, completed := range []bool{true, false} {
+		value := 15.0
+		if _, err := goalRepo.UpsertDailyInstance(ctx, goal.ID, owner.ID, date, goals.UpdateDailyInstanceRequest{CompletedValue: &value, IsCompleted: &completed}, nil); err != nil {
+			t.Fatalf("UpsertDailyInstance: %v", err)
+		}
+
+		stats, err := repo.GetStats(ctx, owner.ID)
+		if err != nil {
+			t.Fatalf("GetStats: %v", err)
+		}
+		want := 0
+		if completed {
+			want = 1
+		}
+		if stats.OverTarget != want || stats.TotalCompletions != want {
+			t.Errorf("stats with the instance completed=%v are %+v, want %d over target of %d completions", completed, stats, want, want)
+		}
+	}
+}
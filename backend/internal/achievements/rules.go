@@ -0,0 +1,81 @@
+package achievements
+
+// OverTargetRatio is how far past its target a numeric instance must finish
+// to count towards MetricOverTarget.
+const OverTargetRatio = 1.5
+
+// Rule unlocks an achievement once Metric reaches Threshold.
+type Rule struct {
+	Key         string
+	Name        string
+	Description string
+	Metric      Metric
+	Threshold   int
+}
+
+// Rules is the catalogue of achievements. Keys are persisted, so existing
+// keys must never be renamed.
+var Rules = []Rule{
+	{
+		Key:         "first_completion",
+		Name:        "First Step",
+		Description: "Complete a goal for the first time",
+		Metric:      MetricTotalCompletions,
+		Threshold:   1,
+	},
+	{
+		Key:         "streak_7",
+		Name:        "Week Warrior",
+		Description: "Reach a 7-day streak on any goal",
+		Metric:      MetricLongestStreak,
+		Threshold:   7,
+	},
+	{
+		Key:         "streak_30",
+		Name:        "Habit Formed",
+		Description: "Reach a 30-day streak on any goal",
+		Metric:      MetricLongestStreak,
+		Threshold:   30,
+	},
+	{
+		Key:         "streak_100",
+		Name:        "Unbreakable",
+		Description: "Reach a 100-day streak on any goal",
+		Metric:      MetricLongestStreak,
+		Threshold:   100,
+	},
+	{
+		Key:         "completions_100",
+		Name:        "Centurion",
+		Description: "Complete 100 daily goals",
+		Metric:      MetricTotalCompletions,
+		Threshold:   100,
+	},
+	{
+		Key:         "completions_1000",
+		Name:        "Grinder",
+		Description: "Complete 1000 daily goals",
+		Metric:      MetricTotalCompletions,
+		Threshold:   1000,
+	},
+	{
+		Key:         "over_target",
+		Name:        "Overachiever",
+		Description: "Finish a numeric goal at least 50% over its target",
+		Metric:      MetricOverTarget,
+		Threshold:   1,
+	},
+}
+
+// Satisfied reports whether stats meet the rule's threshold.
+func (r Rule) Satisfied(stats Stats) bool {
+	return stats.Value(r.Metric) >= r.Threshold
+}
+
+// Progress returns how far stats are towards the rule, capped at the threshold.
+func (r Rule) Progress(stats Stats) Progress {
+	return Progress{
+		Current: min(stats.Value(r.Metric), r.Threshold),
+		Target:  r.Threshold,
+	}
+}
@@ -0,0 +1,72 @@
+package achievements_test
+
+import (
+	"testing"
+
+	"github.com/JoshPugli/grindhouse-api/internal/achievements"
+)
+
+func rule(t *testing.T, key string) achievements.Rule {
+	t.Helper()
+	for _, r := range achievements.Rules {
+		if r.Key == key {
+			return r
+		}
+	}
+	t.Fatalf("no rule %q", key)
+	return achievements.Rule{}
+}
+
+func TestRuleSatisfied(t *testing.T) {
+	tests := []struct {
+		key   string
+		stats achievements.Stats
+		want  bool
+	}{
+		{"first_completion", achievements.Stats{}, false},
+		{"first_completion", achievements.Stats{TotalCompletions: 1}, true},
+		{"streak_7", achievements.Stats{TotalCompletions: 50, LongestStreak: 6}, false},
+		{"streak_7", achievements.Stats{LongestStreak: 7}, true},
+		{"streak_30", achievements.Stats{LongestStreak: 29}, false},
+		{"streak_100", achievements.Stats{LongestStreak: 150}, true},
+		{"completions_100", achievements.Stats{TotalCompletions: 99, LongestStreak: 100}, false},
+		{"completions_100", achievements.Stats{TotalCompletions: 100}, true},
+		{"completions_1000", achievements.Stats{TotalCompletions: 999}, false},
+		{"over_target", achievements.Stats{TotalCompletions: 10}, false},
+		{"over_target", achievements.Stats{OverTarget: 1}, true},
+	}
+	for _, tt := range tests {
+		if got := rule(t, tt.key).Satisfied(tt.stats); got != tt.want {
+			t.Errorf("%s satisfied by %+v is %v, want %v", tt.key, tt.stats, got, tt.want)
+		}
+	}
+}
+
+func TestRuleProgress(t *testing.T) {
+	tests := []struct {
+		key   string
+		stats achievements.Stats
+		want  achievements.Progress
+	}{
+		{"streak_30", achievements.Stats{}, achievements.Progress{Current: 0, Target: 30}},
+		{"streak_30", achievements.Stats{LongestStreak: 12}, achievements.Progress{Current: 12, Target: 30}},
+		{"streak_30", achievements.Stats{LongestStreak: 45}, achievements.Progress{Current: 30, Target: 30}},
+		{"completions_100", achievements.Stats{TotalCompletions: 100}, achievements.Progress{Current: 100, Target: 100}},
+		{"over_target", achievements.Stats{OverTarget: 3}, achievements.Progress{Current: 1, Target: 1}},
+	}
+	for _, tt := range tests {
+		if got := rule(t, tt.key).Progress(tt.stats); got != tt.want {
+			t.Errorf("%s progress for %+v is %+v, want %+v", tt.key, tt.stats, got, tt.want)
+		}
+	}
+}
+
+func TestRuleKeysUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, r := range achievements.Rules {
+		if seen[r.Key] {
+			t.Errorf("rule key %q is used twice", r.Key)
+		}
+		seen[r.Key] = true
+	}
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"github.com/JoshPugli/grindhouse-api/docs"
+	"github.com/JoshPugli/grindhouse-api/internal/apiversion"
+	"github.com/swaggo/swag"
+)
+
+// legacyDocsInstance is the swag instance describing the unversioned /api
+// routes, served at /swagger/legacy/.
+const legacyDocsInstance = "legacy"
+
+// The generated spec documents /api/v1. Every route is also served under the
+// legacy prefix, so its documentation is the same spec with that base path.
+func init() {
+	legacy := *docs.SwaggerInfo
+	legacy.InfoInstanceName = legacyDocsInstance
+	legacy.BasePath = apiversion.Legacy.Prefix()
+	legacy.Description = "Deprecated: these unversioned routes are removed after the Sunset date in their responses. Use /api/v1."
+	swag.Register(legacy.InstanceName(), &legacy)
+}
@@ -4,13 +4,35 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/JoshPugli/grindhouse-api/internal/achievements"
+	"github.com/JoshPugli/grindhouse-api/internal/apiversion"
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
 	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/calendar"
+	"github.com/JoshPugli/grindhouse-api/internal/devicesync"
+	"github.com/JoshPugli/grindhouse-api/internal/export"
+	"github.com/JoshPugli/grindhouse-api/internal/feed"
 	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/importer"
+	"github.com/JoshPugli/grindhouse-api/internal/ingest"
+	"github.com/JoshPugli/grindhouse-api/internal/search"
 	"github.com/JoshPugli/grindhouse-api/internal/user"
+	"github.com/JoshPugli/grindhouse-api/internal/webhooks"
+	"github.com/JoshPugli/grindhouse-api/internal/xp"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 )
 
+// legacyDeprecation marks the unversioned /api routes, which predate /api/v1
+// and are kept for app builds that still use them.
+var legacyDeprecation = apiversion.Deprecation{
+	Since:     time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+	Sunset:    time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+	Successor: apiversion.V1,
+}
+
 // protectedHandler godoc
 // @Summary Protected endpoint
 // @Description Example protected endpoint that requires authentication
@@ -19,7 +41,7 @@ import (
 // @Security BearerAuth
 // @Success 200 {string} string "Protected route accessed"
 // @Failure 401 {string} string "Unauthorized"
-// @Router /api/protected [get]
+// @Router /protected [get]
 func protectedHandler(w http.ResponseWriter, r *http.Request) {
 	userID, _ := auth.GetUserIDFromContext(r.Context())
 	fmt.Fprintf(w, "Protected route accessed by user: %s", userID)
@@ -31,69 +53,175 @@ func protectedHandler(w http.ResponseWriter, r *http.Request) {
 // @Tags health
 // @Produce plain
 // @Success 200 {string} string "OK"
-// @Router /api/health [get]
+// @Router /health [get]
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
 
+// addCoreRoutes registers the routes every database serves: accounts,
+// goals, health and the API documentation.
+func addCoreRoutes(
+	mux *http.ServeMux,
+	authn *auth.Authenticator,
+	userHandlers *user.Handlers,
+	goalHandlers *goals.Handlers,
+) {
+	protected := func(h http.HandlerFunc) http.Handler {
+		return authn.Middleware(h)
+	}
+
+	for _, g := range versionGroups(mux) {
+		// Public auth routes
+		g.handle("POST /auth/login", http.HandlerFunc(userHandlers.HandleLogin))
+		g.handle("POST /auth/register", http.HandlerFunc(userHandlers.HandleRegister))
+
+		// Protected routes
+		g.handle("GET /auth/me", protected(userHandlers.HandleMe))
+		g.handle("GET /protected", protected(protectedHandler))
+
+		// Goal routes
+		g.handle("POST /goals", protected(goalHandlers.HandleCreateGoal))
+		g.handle("GET /goals", protected(goalHandlers.HandleGetGoals))
+		g.handle("GET /goals/today", protected(goalHandlers.HandleGetGoalsToday))
+		g.handle("GET /goals/{goalId}", protected(goalHandlers.HandleGetGoal))
+		g.handle("PUT /goals/{goalId}", protected(goalHandlers.HandleUpdateGoal))
+		g.handle("DELETE /goals/{goalId}", protected(goalHandlers.HandleDeleteGoal))
+		g.handle("PUT /goals/{goalId}/daily", protected(goalHandlers.HandleUpdateDailyInstance))
+		g.handle("GET /goals/{goalId}/history", protected(goalHandlers.HandleGetGoalHistory))
+
+		// Public routes
+		g.handle("GET /health", http.HandlerFunc(healthHandler))
+	}
+
+	// Swagger documentation
+	mux.Handle("GET /swagger/", httpSwagger.WrapHandler)
+	mux.Handle("GET /swagger/legacy/", httpSwagger.Handler(httpSwagger.InstanceName(legacyDocsInstance)))
+}
+
+// addRoutes registers the core routes and those of the features that need
+// PostgreSQL.
 func addRoutes(
 	mux *http.ServeMux,
+	authn *auth.Authenticator,
 	userHandlers *user.Handlers,
 	goalHandlers *goals.Handlers,
+	feedHandlers *feed.Handlers,
+	achievementHandlers *achievements.Handlers,
+	xpHandlers *xp.Handlers,
+	webhookHandlers *webhooks.Handlers,
+	ingestHandlers *ingest.Handlers,
+	exportHandlers *export.Handlers,
+	importHandlers *importer.Handlers,
+	calendarHandlers *calendar.Handlers,
+	syncHandlers *devicesync.Handlers,
+	searchHandlers *search.Handlers,
 ) {
-	// Public auth routes
-	mux.HandleFunc("/api/auth/login", userHandlers.HandleLogin)
-	mux.HandleFunc("/api/auth/register", userHandlers.HandleRegister)
-	
-	// Protected routes
-	mux.Handle("/api/auth/me", auth.AuthMiddleware(http.HandlerFunc(userHandlers.HandleMe)))
-	mux.Handle("/api/protected", auth.AuthMiddleware(http.HandlerFunc(protectedHandler)))
-	
-	// Goal routes
-	mux.Handle("/api/goals", auth.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodPost:
-			goalHandlers.HandleCreateGoal(w, r)
-		case http.MethodGet:
-			goalHandlers.HandleGetGoals(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})))
-	mux.Handle("/api/goals/today", auth.AuthMiddleware(http.HandlerFunc(goalHandlers.HandleGetGoalsToday)))
-	mux.Handle("/api/goals/", auth.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path
-		if path == "/api/goals/" {
-			switch r.Method {
-			case http.MethodGet:
-				goalHandlers.HandleGetGoals(w, r)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		} else if len(path) > 12 && path[len(path)-8:] == "/history" {
-			goalHandlers.HandleGetGoalHistory(w, r)
-		} else if len(path) > 10 && path[len(path)-6:] == "/daily" {
-			goalHandlers.HandleUpdateDailyInstance(w, r)
-		} else {
-			switch r.Method {
-			case http.MethodGet:
-				goalHandlers.HandleGetGoal(w, r)
-			case http.MethodPut:
-				goalHandlers.HandleUpdateGoal(w, r)
-			case http.MethodDelete:
-				goalHandlers.HandleDeleteGoal(w, r)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
+	addCoreRoutes(mux, authn, userHandlers, goalHandlers)
+
+	protected := func(h http.HandlerFunc) http.Handler {
+		return authn.Middleware(h)
+	}
+
+	for _, g := range versionGroups(mux) {
+		// Feed routes
+		g.handle("GET /feed", protected(feedHandlers.HandleGetFeed))
+		g.handle("POST /follows", protected(feedHandlers.HandleFollow))
+		g.handle("GET /follows", protected(feedHandlers.HandleGetFollowing))
+		g.handle("DELETE /follows/{userId}", protected(feedHandlers.HandleUnfollow))
+
+		// Achievement routes
+		g.handle("GET /achievements", protected(achievementHandlers.HandleGetAchievements))
+
+		// XP routes
+		g.handle("GET /xp", protected(xpHandlers.HandleGetXP))
+
+		// Webhook routes
+		g.handle("POST /webhooks", protected(webhookHandlers.HandleCreateWebhook))
+		g.handle("GET /webhooks", protected(webhookHandlers.HandleGetWebhooks))
+		g.handle("DELETE /webhooks/{webhookId}", protected(webhookHandlers.HandleDeleteWebhook))
+		g.handle("GET /webhooks/{webhookId}/deliveries", protected(webhookHandlers.HandleGetDeliveries))
+		g.handle("POST /webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", protected(webhookHandlers.HandleRedeliver))
+
+		// Ingestion routes
+		g.handle("POST /ingest-tokens", protected(ingestHandlers.HandleCreateToken))
+		g.handle("GET /ingest-tokens", protected(ingestHandlers.HandleGetTokens))
+		g.handle("DELETE /ingest-tokens/{tokenId}", protected(ingestHandlers.HandleRevokeToken))
+
+		// Export routes
+		g.handle("GET /export", protected(exportHandlers.HandleExport))
+		g.handle("GET /export/jobs/{jobId}", protected(exportHandlers.HandleGetJob))
+
+		// Import routes
+		g.handle("POST /import", protected(importHandlers.HandleImport))
+
+		// Calendar routes
+		g.handle("GET /calendar/subscription", protected(calendarHandlers.HandleGetSubscription))
+		g.handle("POST /calendar/subscription", protected(calendarHandlers.HandleRotateSubscription))
+		g.handle("DELETE /calendar/subscription", protected(calendarHandlers.HandleDeleteSubscription))
+
+		// Sync routes
+		g.handle("GET /sync", protected(syncHandlers.HandlePull))
+		g.handle("POST /sync", protected(syncHandlers.HandlePush))
+
+		// Search routes
+		g.handle("GET /search", protected(searchHandlers.HandleSearch))
+
+		// Public routes
+		g.handle("POST /ingest/{token}", http.HandlerFunc(ingestHandlers.HandleIngest))
+		g.handle("GET /export/download/{token}", http.HandlerFunc(exportHandlers.HandleDownload))
+		g.handle("GET /calendar.ics", http.HandlerFunc(calendarHandlers.HandleFeed))
+	}
+}
+
+// versionGroups returns a group for each API version. Every version serves
+// the same handlers unless a later version replaces a route. Handlers that
+// shape responses per version read it with apiversion.FromContext.
+func versionGroups(mux *http.ServeMux) []versionGroup {
+	return []versionGroup{
+		{mux: mux, version: apiversion.V1},
+		{mux: mux, version: apiversion.Legacy, deprecation: &legacyDeprecation},
+	}
+}
+
+// versionGroup registers routes under the path prefix of an API version.
+type versionGroup struct {
+	mux         *http.ServeMux
+	version     apiversion.Version
+	deprecation *apiversion.Deprecation
+}
+
+// handle registers h for a pattern such as "GET /goals/{goalId}", with the
+// path relative to the version's prefix.
+func (g versionGroup) handle(pattern string, h http.Handler) {
+	method, path, _ := strings.Cut(pattern, " ")
+	g.mux.Handle(method+" "+g.version.Prefix()+path, apiversion.Middleware(g.version, g.deprecation)(h))
+}
+
+// problemErrors makes the 404 and 405 responses mux sends for requests that
+// match no route problem details like every other error. The Allow header
+// mux sets on a 405 is kept.
+func problemErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
 		}
-	})))
-	
-	// Public routes
-	mux.HandleFunc("/api/health", healthHandler)
-	
-	// Swagger documentation
-	mux.Handle("/swagger/", httpSwagger.WrapHandler)
-	
-	mux.Handle("/", http.NotFoundHandler())
+
+		status := &statusRecorder{header: w.Header(), code: http.StatusNotFound}
+		h.ServeHTTP(status, r)
+		apperr.WriteProblem(w, r, status.code, http.StatusText(status.code))
+	})
 }
+
+// statusRecorder captures the status and headers of a response and drops
+// its body.
+type statusRecorder struct {
+	header http.Header
+	code   int
+}
+
+func (s *statusRecorder) Header() http.Header         { return s.header }
+func (s *statusRecorder) Write(b []byte) (int, error) { return len(b), nil }
+func (s *statusRecorder) WriteHeader(code int)        { s.code = code }
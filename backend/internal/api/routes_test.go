@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/achievements"
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/calendar"
+	"github.com/JoshPugli/grindhouse-api/internal/devicesync"
+	"github.com/JoshPugli/grindhouse-api/internal/export"
+	"github.com/JoshPugli/grindhouse-api/internal/feed"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/importer"
+	"github.com/JoshPugli/grindhouse-api/internal/ingest"
+	"github.com/JoshPugli/grindhouse-api/internal/search"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+	"github.com/JoshPugli/grindhouse-api/internal/user"
+	"github.com/JoshPugli/grindhouse-api/internal/webhooks"
+	"github.com/JoshPugli/grindhouse-api/internal/xp"
+)
+
+const malformedID = "not-a-uuid"
+
+// testRoutes registers every route the way NewServer does. Goals and users
+// live in memory; the other features are built on a SQLite database without
+// their tables, which none of the requests below reach.
+func testRoutes(t *testing.T) (http.Handler, string) {
+	t.Helper()
+
+	db := storetest.OpenSQLite(t)
+	authn := auth.NewAuthenticator("test-secret", time.Hour)
+	token, err := authn.GenerateJWT("5f0c1d9e-8a4b-4e3c-9d2a-1b7e6f4a3c21")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goalStore := goals.NewMemoryStore(time.Now)
+	xpService := xp.NewService(xp.NewRepository(db), goalStore)
+
+	mux := http.NewServeMux()
+	addRoutes(mux, authn,
+		user.NewHandlers(user.NewMemoryStore(), nil, authn),
+		goals.NewHandlers(goalStore, time.Now),
+		feed.NewHandlers(feed.NewRepository(db)),
+		achievements.NewHandlers(achievements.NewEngine(achievements.NewRepository(db), time.Now)),
+		xp.NewHandlers(xpService),
+		webhooks.NewHandlers(webhooks.NewRepository(db)),
+		ingest.NewHandlers(ingest.NewRepository(db, goals.NewRepository(db, time.Now)), goalStore, nil, time.Now),
+		export.NewHandlers(export.NewRepository(db), 100, time.Minute, time.Now),
+		importer.NewHandlers(importer.NewRepository(db)),
+		calendar.NewHandlers(calendar.NewRepository(db), 365, time.Now),
+		devicesync.NewHandlers(devicesync.NewRepository(db, time.Now), nil),
+		search.NewHandlers(search.NewRepository(db)),
+	)
+	return problemErrors(mux), token
+}
+
+func TestRoutes(t *testing.T) {
+	handler, token := testRoutes(t)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		wantStatus  int
+		wantAllow   string
+		wantProblem bool
+	}{
+		{"public route", "GET", "/api/v1/health", http.StatusOK, "", false},
+		{"protected route", "GET", "/api/v1/goals", http.StatusOK, "", false},
+		{"more specific pattern wins", "GET", "/api/v1/goals/today", http.StatusOK, "", false},
+
+		{"unknown path", "GET", "/api/v1/nothing", http.StatusNotFound, "", true},
+		{"unknown version", "GET", "/api/v2/goals", http.StatusNotFound, "", true},
+		{"empty wildcard", "GET", "/api/v1/goals/", http.StatusNotFound, "", true},
+		{"trailing slash", "GET", "/api/v1/health/", http.StatusNotFound, "", true},
+		{"trailing slash after wildcard", "GET", "/api/v1/goals/" + malformedID + "/history/", http.StatusNotFound, "", true},
+
+		{"method not registered", "PATCH", "/api/v1/goals", http.StatusMethodNotAllowed, "GET, HEAD, POST", true},
+		{"method not registered on wildcard", "POST", "/api/v1/goals/" + malformedID, http.StatusMethodNotAllowed, "DELETE, GET, HEAD, PUT", true},
+		{"method not registered on public route", "DELETE", "/api/v1/health", http.StatusMethodNotAllowed, "GET, HEAD", true},
+		{"method not registered on legacy route", "PATCH", "/api/goals", http.StatusMethodNotAllowed, "GET, HEAD, POST", true},
+
+		{"malformed goal ID", "GET", "/api/v1/goals/" + malformedID, http.StatusBadRequest, "", true},
+		{"malformed goal ID on update", "PUT", "/api/v1/goals/" + malformedID, http.StatusBadRequest, "", true},
+		{"malformed goal ID on delete", "DELETE", "/api/v1/goals/" + malformedID, http.StatusBadRequest, "", true},
+		{"malformed goal ID on check-in", "PUT", "/api/v1/goals/" + malformedID + "/daily", http.StatusBadRequest, "", true},
+		{"malformed goal ID on history", "GET", "/api/v1/goals/" + malformedID + "/history", http.StatusBadRequest, "", true},
+		{"malformed followee ID", "DELETE", "/api/v1/follows/" + malformedID, http.StatusBadRequest, "", true},
+		{"malformed webhook ID", "DELETE", "/api/v1/webhooks/" + malformedID, http.StatusBadRequest, "", true},
+		{"malformed webhook ID on deliveries", "GET", "/api/v1/webhooks/" + malformedID + "/deliveries", http.StatusBadRequest, "", true},
+		{"malformed delivery ID", "POST", "/api/v1/webhooks/5f0c1d9e-8a4b-4e3c-9d2a-1b7e6f4a3c21/deliveries/" + malformedID + "/redeliver", http.StatusBadRequest, "", true},
+		{"malformed token ID", "DELETE", "/api/v1/ingest-tokens/" + malformedID, http.StatusBadRequest, "", true},
+		{"malformed job ID", "GET", "/api/v1/export/jobs/" + malformedID, http.StatusBadRequest, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader("{}"))
+			r.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status is %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow is %q, want %q", got, tt.wantAllow)
+			}
+			if !tt.wantProblem {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("Content-Type is %q, want application/problem+json", got)
+			}
+			var problem apperr.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("body is not a problem: %v: %s", err, w.Body)
+			}
+			if problem.Status != tt.wantStatus || problem.Title == "" {
+				t.Errorf("problem is %+v, want status %d with a title", problem, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestLegacyRoutes checks that every unversioned route answers like its
+// /api/v1 counterpart, and that only the unversioned one is deprecated.
+func TestLegacyRoutes(t *testing.T) {
+	handler, token := testRoutes(t)
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/health"},
+		{"GET", "/goals"},
+		{"GET", "/goals/today"},
+		{"GET", "/goals/" + malformedID},
+		{"PATCH", "/goals"},
+		{"GET", "/nothing"},
+		{"DELETE", "/webhooks/" + malformedID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			serve := func(prefix string) *httptest.ResponseRecorder {
+				r := httptest.NewRequest(tt.method, prefix+tt.path, nil)
+				r.Header.Set("Authorization", "Bearer "+token)
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				return w
+			}
+			v1, legacy := serve("/api/v1"), serve("/api")
+
+			if legacy.Code != v1.Code {
+				t.Errorf("legacy status is %d, /api/v1 is %d", legacy.Code, v1.Code)
+			}
+			if got, want := legacy.Body.String(), v1.Body.String(); got != want {
+				t.Errorf("legacy body is %s, /api/v1 is %s", got, want)
+			}
+			if got, want := legacy.Header().Get("Allow"), v1.Header().Get("Allow"); got != want {
+				t.Errorf("legacy Allow is %q, /api/v1 is %q", got, want)
+			}
+
+			// Only requests that reach a route know its version.
+			if legacy.Code == http.StatusNotFound || legacy.Code == http.StatusMethodNotAllowed {
+				return
+			}
+			if legacy.Header().Get("Deprecation") == "" || legacy.Header().Get("Sunset") == "" {
+				t.Errorf("legacy response has Deprecation %q and Sunset %q, want both", legacy.Header().Get("Deprecation"), legacy.Header().Get("Sunset"))
+			}
+			if v1.Header().Get("Deprecation") != "" || v1.Header().Get("Sunset") != "" {
+				t.Errorf("/api/v1 response has Deprecation %q and Sunset %q, want neither", v1.Header().Get("Deprecation"), v1.Header().Get("Sunset"))
+			}
+		})
+	}
+}
@@ -1,34 +1,185 @@
 package api
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"sync"
+	"time"
 
-	"github.com/JoshPugli/grindhouse-api/internal/database"
+	"github.com/JoshPugli/grindhouse-api/internal/achievements"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/calendar"
+	"github.com/JoshPugli/grindhouse-api/internal/config"
+	"github.com/JoshPugli/grindhouse-api/internal/devicesync"
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+	"github.com/JoshPugli/grindhouse-api/internal/export"
+	"github.com/JoshPugli/grindhouse-api/internal/feed"
 	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/idempotency"
+	"github.com/JoshPugli/grindhouse-api/internal/importer"
+	"github.com/JoshPugli/grindhouse-api/internal/ingest"
 	"github.com/JoshPugli/grindhouse-api/internal/middleware"
+	"github.com/JoshPugli/grindhouse-api/internal/search"
 	"github.com/JoshPugli/grindhouse-api/internal/user"
-	
-	_ "github.com/JoshPugli/grindhouse-api/docs"
+	"github.com/JoshPugli/grindhouse-api/internal/webhooks"
+	"github.com/JoshPugli/grindhouse-api/internal/xp"
 )
 
-// constructor is responsible for all the top-level HTTP stuff that applies to all endpoints,
-// like CORS, auth middleware, and logging
-func NewServer() http.Handler {
-	mux := http.NewServeMux()
-	
-	db, err := database.NewConnection()
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+// Server is the API: the HTTP handler for every endpoint, wrapped in the
+// middleware that applies to all of them, and the background workers that
+// deliver webhooks, build exports and expire idempotency keys.
+type Server struct {
+	handler http.Handler
+	workers []func(ctx context.Context)
+}
+
+// Deps are the collaborators the Server uses besides its database. The zero
+// value is what a deployment runs with; tests and embedders set the fields
+// they need to replace.
+type Deps struct {
+	// HTTPClient delivers webhooks. By default it is webhooks.NewClient with
+	// the configured timeout and allowed networks.
+	HTTPClient *http.Client
+	// Listeners are notified of goal changes after the built-in listeners,
+	// to feed notifiers that live outside the API.
+	Listeners goals.Listeners
+	// Clock is the time the stores, handlers and workers read. By default
+	// it is time.Now.
+	Clock func() time.Time
+	// Logger receives the errors that are not returned to a client: those
+	// of listeners, workers and internal server errors. By default it is
+	// slog.Default.
+	Logger *slog.Logger
+}
+
+// NewServer builds the API on db, which must already be migrated. It starts
+// nothing; the caller serves the Server and runs its workers with
+// RunWorkers, and closes db once both have stopped.
+func NewServer(cfg *config.Config, db *sql.DB, deps Deps) (*Server, error) {
+	if cfg == nil || db == nil {
+		return nil, errors.New("config and database are required")
 	}
 
+	if deps.HTTPClient == nil {
+		allowed, err := webhooks.ParseNetworks(cfg.Webhooks.AllowedNetworks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse webhook allowed networks: %w", err)
+		}
+		deps.HTTPClient = webhooks.NewClient(cfg.Webhooks.Timeout, allowed)
+	}
+	if deps.Clock == nil {
+		deps.Clock = time.Now
+	}
+	if deps.Logger == nil {
+		deps.Logger = slog.Default()
+	}
+
+	if cfg.Database.Driver == dialect.SQLite {
+		return newSQLiteServer(cfg, db, deps), nil
+	}
+
+	// Fail at startup rather than on the first export if the directory
+	// cannot be used.
+	if err := os.MkdirAll(cfg.Export.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	mux := http.NewServeMux()
+
+	goalRepo := goals.NewRepository(db, deps.Clock)
+
+	xpService := xp.NewService(xp.NewRepository(db), goalRepo)
+	xpHandlers := xp.NewHandlers(xpService)
+
 	userRepo := user.NewRepository(db)
-	userHandlers := user.NewHandlers(userRepo)
-	
-	goalRepo := goals.NewRepository(db)
-	goalHandlers := goals.NewHandlers(goalRepo)
+	authn := auth.NewAuthenticator(cfg.Auth.JWTSecret, cfg.Auth.TokenTTL)
+	userHandlers := user.NewHandlers(userRepo, xpService, authn)
+
+	feedRepo := feed.NewRepository(db)
+	feedHandlers := feed.NewHandlers(feedRepo)
+
+	webhookRepo := webhooks.NewRepository(db)
+	webhookHandlers := webhooks.NewHandlers(webhookRepo)
 
-	addRoutes(mux, userHandlers, goalHandlers)
+	achievementEngine := achievements.NewEngine(achievements.NewRepository(db), deps.Clock)
+	achievementHandlers := achievements.NewHandlers(achievementEngine)
 
-	return middleware.CORS(mux)
+	listeners := goals.Listeners{
+		feed.NewListener(feedRepo, goalRepo),
+		achievementEngine,
+		xpService,
+		webhooks.NewPublisher(webhookRepo, deps.Clock),
+	}
+	listeners = append(listeners, deps.Listeners...)
+	goalHandlers := goals.NewHandlers(goalRepo, deps.Clock, listeners...)
+	ingestHandlers := ingest.NewHandlers(ingest.NewRepository(db, goalRepo), goalRepo, listeners, deps.Clock)
+
+	exportRepo := export.NewRepository(db)
+	exportHandlers := export.NewHandlers(exportRepo, cfg.Export.SyncLimit, cfg.Database.QueryTimeout, deps.Clock)
+
+	importHandlers := importer.NewHandlers(importer.NewRepository(db))
+	calendarHandlers := calendar.NewHandlers(calendar.NewRepository(db), cfg.Calendar.HistoryDays, deps.Clock)
+	syncHandlers := devicesync.NewHandlers(devicesync.NewRepository(db, deps.Clock), listeners)
+	searchHandlers := search.NewHandlers(search.NewRepository(db))
+
+	exportWorker := export.NewWorker(exportRepo, cfg.Export.Dir, cfg.Export.LinkTTL, cfg.Export.PollInterval, cfg.Export.QueryTimeout, deps.Logger)
+	dispatcher := webhooks.NewDispatcher(webhookRepo, deps.HTTPClient, cfg.Webhooks.PollInterval, deps.Clock, deps.Logger)
+
+	addRoutes(mux, authn, userHandlers, goalHandlers, feedHandlers, achievementHandlers, xpHandlers, webhookHandlers, ingestHandlers, exportHandlers, importHandlers, calendarHandlers, syncHandlers, searchHandlers)
+
+	idempotencyStore := idempotency.NewStore(db)
+
+	cors := middleware.CORS(cfg.CORS.AllowedOrigins)
+	logger := middleware.Logger(deps.Logger)
+	return &Server{
+		handler: cors(middleware.RequestID(logger(idempotency.NewMiddleware(idempotencyStore, cfg.Idempotency.TTL, int64(cfg.Idempotency.MaxBodyBytes), authn).Handler(problemErrors(mux))))),
+		workers: []func(ctx context.Context){
+			exportWorker.Run,
+			dispatcher.Run,
+			func(ctx context.Context) {
+				idempotencyStore.RunCleanup(ctx, cfg.Idempotency.CleanupInterval, deps.Logger)
+			},
+		},
+	}, nil
+}
+
+// newSQLiteServer builds the API on a SQLite database, which holds only
+// users and goals. It serves accounts and goals alone: the other features
+// and idempotency keys need PostgreSQL, so their routes are not registered,
+// goal changes reach only the injected listeners, and there are no workers.
+func newSQLiteServer(cfg *config.Config, db *sql.DB, deps Deps) *Server {
+	mux := http.NewServeMux()
+
+	authn := auth.NewAuthenticator(cfg.Auth.JWTSecret, cfg.Auth.TokenTTL)
+	userHandlers := user.NewHandlers(user.NewSQLiteStore(db, deps.Clock), nil, authn)
+	goalHandlers := goals.NewHandlers(goals.NewSQLiteStore(db, deps.Clock), deps.Clock, deps.Listeners...)
+
+	addCoreRoutes(mux, authn, userHandlers, goalHandlers)
+
+	cors := middleware.CORS(cfg.CORS.AllowedOrigins)
+	logger := middleware.Logger(deps.Logger)
+	return &Server{handler: cors(middleware.RequestID(logger(problemErrors(mux))))}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// RunWorkers runs the background workers until ctx is cancelled and returns
+// once all of them have stopped.
+func (s *Server) RunWorkers(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, run := range s.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(ctx)
+		}()
+	}
+	wg.Wait()
 }
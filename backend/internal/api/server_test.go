@@ -0,0 +1,350 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoshPugli/grindhouse-api/internal/api"
+	"github.com/JoshPugli/grindhouse-api/internal/config"
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/idempotency"
+	"github.com/JoshPugli/grindhouse-api/internal/middleware"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+	"github.com/JoshPugli/grindhouse-api/internal/xp"
+)
+
+// TestServerSQLite boots the whole server on a SQLite database and walks
+// through an account's first goal, on a clock the day after the check-in.
+func TestServerSQLite(t *testing.T) {
+	cfg := testConfig(t, dialect.SQLite)
+	recorder := &changeRecorder{}
+	now := time.Date(2026, 10, 20, 9, 30, 0, 0, time.UTC)
+	deps := api.Deps{
+		Listeners: goals.Listeners{recorder},
+		Clock:     func() time.Time { return now },
+	}
+	srv := startServer(t, cfg, storetest.OpenSQLite(t), deps)
+
+	c := newClient(t, srv.URL)
+	resp := c.do("GET", "/api/v1/health", nil, http.StatusOK, nil)
+	if resp.Header.Get(middleware.HeaderRequestID) == "" {
+		t.Errorf("response has no %s header", middleware.HeaderRequestID)
+	}
+
+	c.register()
+	goal := c.createGoal()
+	if !goal.CreatedAt.Equal(now) {
+		t.Errorf("goal created at %v, want the injected clock's %v", goal.CreatedAt, now)
+	}
+	c.checkIn(goal.ID)
+
+	// The history ends today by the injected clock.
+	var history []goals.DailyGoalInstance
+	c.do("GET", "/api/v1/goals/"+goal.ID+"/history", nil, http.StatusOK, &history)
+	if len(history) != 1 || !history[0].IsCompleted {
+		t.Errorf("history is %+v, want the completed check-in", history)
+	}
+
+	// The legacy routes serve the same handlers, marked as deprecated.
+	var legacy []goals.Goal
+	resp = c.do("GET", "/api/goals", nil, http.StatusOK, &legacy)
+	if len(legacy) != 1 || legacy[0].ID != goal.ID {
+		t.Errorf("legacy goals are %+v, want the created goal", legacy)
+	}
+	if resp.Header.Get("Deprecation") == "" {
+		t.Error("legacy response has no Deprecation header")
+	}
+
+	// Features that need PostgreSQL are not served.
+	resp = c.do("GET", "/api/v1/feed", nil, http.StatusNotFound, nil)
+	if got := resp.Header.Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("404 Content-Type is %q, want application/problem+json", got)
+	}
+
+	if got, want := recorder.kinds(), []goals.ChangeKind{goals.GoalCreated, goals.InstanceUpdated}; !slices.Equal(got, want) {
+		t.Errorf("injected listener saw %v, want %v", got, want)
+	}
+}
+
+// TestServerLogsListenerFailures checks that a failing listener does not
+// fail the request and is logged to the injected logger with the request's
+// ID.
+func TestServerLogsListenerFailures(t *testing.T) {
+	cfg := testConfig(t, dialect.SQLite)
+	var logs lockedBuffer
+	deps := api.Deps{
+		Listeners: goals.Listeners{failingListener{}},
+		Logger:    slog.New(slog.NewJSONHandler(&logs, nil)),
+	}
+	srv := startServer(t, cfg, storetest.OpenSQLite(t), deps)
+
+	c := newClient(t, srv.URL)
+	c.register()
+	resp := c.do("POST", "/api/v1/goals", newGoal(), http.StatusCreated, nil)
+
+	var entry struct {
+		Msg       string `json:"msg"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("log is %q, want one JSON record: %v", logs.Bytes(), err)
+	}
+	if want := resp.Header.Get(middleware.HeaderRequestID); entry.Msg != "goal listener failed" || entry.RequestID != want {
+		t.Errorf("logged %+v, want the listener failure for request %s", entry, want)
+	}
+}
+
+// TestServerPostgres boots the whole server on PostgreSQL, with its
+// workers running, and checks that the features built on goal changes see
+// a check-in.
+func TestServerPostgres(t *testing.T) {
+	cfg := testConfig(t, dialect.Postgres)
+	recorder := &changeRecorder{}
+	srv := startServer(t, cfg, storetest.OpenPostgres(t), api.Deps{Listeners: goals.Listeners{recorder}})
+
+	c := newClient(t, srv.URL)
+	c.register()
+
+	// A retried create returns the first response instead of a second goal.
+	key := map[string]string{idempotency.HeaderKey: uuid.New().String()}
+	var first, retried goals.Goal
+	c.doWithHeaders("POST", "/api/v1/goals", key, newGoal(), http.StatusCreated, &first)
+	resp := c.doWithHeaders("POST", "/api/v1/goals", key, newGoal(), http.StatusCreated, &retried)
+	if retried.ID != first.ID || resp.Header.Get(idempotency.HeaderReplayed) != "true" {
+		t.Errorf("retried create returned goal %s (replayed %q), want a replay of %s", retried.ID, resp.Header.Get(idempotency.HeaderReplayed), first.ID)
+	}
+
+	c.checkIn(first.ID)
+
+	var summary xp.Summary
+	c.do("GET", "/api/v1/xp", nil, http.StatusOK, &summary)
+	if summary.Total <= 0 {
+		t.Errorf("experience after a check-in is %d, want an award", summary.Total)
+	}
+
+	c.do("GET", "/api/v1/feed", nil, http.StatusOK, nil)
+
+	resp = c.do("GET", "/api/v1/export", nil, http.StatusOK, nil)
+	if got := resp.Header.Get("Content-Type"); got != "application/zip" {
+		t.Errorf("export Content-Type is %q, want application/zip", got)
+	}
+
+	if got, want := recorder.kinds(), []goals.ChangeKind{goals.GoalCreated, goals.InstanceUpdated}; !slices.Equal(got, want) {
+		t.Errorf("injected listener saw %v, want %v", got, want)
+	}
+}
+
+func testConfig(t *testing.T, driver dialect.Dialect) *config.Config {
+	cfg := config.Default()
+	cfg.Env = config.EnvDevelopment
+	cfg.Database.Driver = driver
+	cfg.Export.Dir = t.TempDir()
+	cfg.Export.PollInterval = 10 * time.Millisecond
+	cfg.Webhooks.PollInterval = 10 * time.Millisecond
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return &cfg
+}
+
+// startServer serves the API over HTTP and runs its workers until the test
+// ends, failing it if they do not stop.
+func startServer(t *testing.T, cfg *config.Config, db *sql.DB, deps api.Deps) *httptest.Server {
+	t.Helper()
+
+	srv, err := api.NewServer(cfg, db, deps)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	ts := httptest.NewServer(srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		srv.RunWorkers(ctx)
+		close(done)
+	}()
+
+	t.Cleanup(func() {
+		ts.Close()
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("workers did not stop after cancellation")
+		}
+	})
+	return ts
+}
+
+type client struct {
+	t     *testing.T
+	base  string
+	token string
+}
+
+func newClient(t *testing.T, base string) *client {
+	return &client{t: t, base: base}
+}
+
+func (c *client) do(method, path string, body any, wantStatus int, out any) *http.Response {
+	c.t.Helper()
+	return c.doWithHeaders(method, path, nil, body, wantStatus, out)
+}
+
+// doWithHeaders sends a request as the registered user, fails the test
+// unless it gets wantStatus, and decodes the JSON response into out.
+func (c *client) doWithHeaders(method, path string, headers map[string]string, body any, wantStatus int, out any) *http.Response {
+	c.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.base+path, reader)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("%s %s: failed to read response: %v", method, path, err)
+	}
+	if resp.StatusCode != wantStatus {
+		c.t.Fatalf("%s %s returned %d, want %d: %s", method, path, resp.StatusCode, wantStatus, data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			c.t.Fatalf("%s %s: failed to decode response: %v", method, path, err)
+		}
+	}
+	return resp
+}
+
+// register creates an account and authenticates the client's later
+// requests as it.
+func (c *client) register() {
+	c.t.Helper()
+
+	var auth struct {
+		Token string `json:"token"`
+	}
+	c.do("POST", "/api/v1/auth/register", map[string]string{
+		"email":      "server-" + uuid.New().String() + "@example.com",
+		"first_name": "Ada",
+		"password":   "password1",
+	}, http.StatusCreated, &auth)
+	c.token = auth.Token
+
+	c.do("GET", "/api/v1/auth/me", nil, http.StatusOK, nil)
+}
+
+func newGoal() goals.CreateGoalRequest {
+	return goals.CreateGoalRequest{
+		Title:      "Meditate",
+		GoalType:   goals.GoalTypeBoolean,
+		Visibility: goals.VisibilityPublic,
+		Difficulty: goals.DifficultyMedium,
+	}
+}
+
+func (c *client) createGoal() goals.Goal {
+	c.t.Helper()
+
+	var goal goals.Goal
+	c.do("POST", "/api/v1/goals", newGoal(), http.StatusCreated, &goal)
+	return goal
+}
+
+// checkIn completes goalID for a fixed date.
+func (c *client) checkIn(goalID string) {
+	c.t.Helper()
+
+	completed := true
+	var instance goals.DailyGoalInstance
+	c.do("PUT", "/api/v1/goals/"+goalID+"/daily?date=2026-10-19", goals.UpdateDailyInstanceRequest{IsCompleted: &completed}, http.StatusOK, &instance)
+	if !instance.IsCompleted {
+		c.t.Errorf("check-in returned %+v, want it completed", instance)
+	}
+}
+
+// changeRecorder is a listener standing in for a notifier outside the API.
+type changeRecorder struct {
+	mu      sync.Mutex
+	changes []goals.Change
+}
+
+func (r *changeRecorder) GoalChanged(ctx context.Context, change goals.Change) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, change)
+	return nil
+}
+
+func (r *changeRecorder) kinds() []goals.ChangeKind {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kinds := make([]goals.ChangeKind, len(r.changes))
+	for i, change := range r.changes {
+		kinds[i] = change.Kind
+	}
+	return kinds
+}
+
+// failingListener is a listener whose every notification fails.
+type failingListener struct{}
+
+func (failingListener) GoalChanged(ctx context.Context, change goals.Change) error {
+	return errors.New("notifier unavailable")
+}
+
+// lockedBuffer is a bytes.Buffer that the server's goroutines may write to
+// while the test reads it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Clone(b.buf.Bytes())
+}
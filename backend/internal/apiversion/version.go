@@ -0,0 +1,86 @@
+// Package apiversion identifies which version of the API a request was routed
+// through, so handlers can shape responses for it, and marks deprecated
+// versions with Deprecation and Sunset headers.
+package apiversion
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is a major version of the API. Breaking changes to request or
+// response shapes need a new Version; everything else is shared.
+type Version int
+
+const (
+	// Legacy is the unversioned /api routes that shipped before versioning.
+	Legacy Version = iota
+	V1
+)
+
+// Latest is the version new clients should use and the one handlers assume
+// when a request was not routed through a version.
+const Latest = V1
+
+// Prefix returns the path prefix the version's routes are served under.
+func (v Version) Prefix() string {
+	if v == Legacy {
+		return "/api"
+	}
+	return "/api/" + v.String()
+}
+
+func (v Version) String() string {
+	if v == Legacy {
+		return "legacy"
+	}
+	return "v" + strconv.Itoa(int(v))
+}
+
+type contextKey string
+
+const versionKey contextKey = "apiVersion"
+
+// FromContext returns the version the request was routed through, or Latest
+// if it was not routed through one.
+func FromContext(ctx context.Context) Version {
+	if v, ok := ctx.Value(versionKey).(Version); ok {
+		return v
+	}
+	return Latest
+}
+
+// Path returns path under the prefix of the request's version, for links
+// and Location headers that should keep the client on the version it uses.
+func Path(ctx context.Context, path string) string {
+	return FromContext(ctx).Prefix() + path
+}
+
+// Deprecation describes when a version was deprecated, when it will be
+// removed, and which version replaces it.
+type Deprecation struct {
+	Since     time.Time
+	Sunset    time.Time
+	Successor Version
+}
+
+// Middleware records v in the request context. If dep is set, responses also
+// carry the Deprecation (RFC 9745) and Sunset (RFC 8594) headers and a
+// successor-version link to the same path under dep.Successor.
+func Middleware(v Version, dep *Deprecation) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if dep != nil {
+				successor := dep.Successor.Prefix() + strings.TrimPrefix(r.URL.Path, v.Prefix())
+				w.Header().Set("Deprecation", "@"+strconv.FormatInt(dep.Since.Unix(), 10))
+				w.Header().Set("Sunset", dep.Sunset.UTC().Format(http.TimeFormat))
+				w.Header().Add("Link", "<"+successor+`>; rel="successor-version"`)
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey, v)))
+		})
+	}
+}
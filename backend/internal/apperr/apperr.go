@@ -0,0 +1,108 @@
+// Package apperr defines the domain errors repositories return and the one
+// place they are translated into HTTP responses.
+package apperr
+
+import (
+	"errors"
+	"strings"
+)
+
+// Kind classifies an Error. Each kind maps to one HTTP status in Status.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+)
+
+// Sentinels for matching an Error by kind with errors.Is, e.g.
+// errors.Is(err, apperr.ErrNotFound).
+var (
+	ErrNotFound     = &Error{Kind: KindNotFound, Message: "not found"}
+	ErrConflict     = &Error{Kind: KindConflict, Message: "conflict"}
+	ErrValidation   = &Error{Kind: KindValidation, Message: "validation failed"}
+	ErrUnauthorized = &Error{Kind: KindUnauthorized, Message: "unauthorized"}
+	ErrForbidden    = &Error{Kind: KindForbidden, Message: "forbidden"}
+)
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error with a message that is safe to show to clients.
+// Err, if set, is the underlying cause and is never shown.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	details := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		details[i] = f.Field + ": " + f.Message
+	}
+	return e.Message + " (" + strings.Join(details, "; ") + ")"
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any Error of the same kind, so errors.Is(err, ErrNotFound)
+// holds for every not-found error. Errors declared as package sentinels
+// elsewhere are matched by identity as usual.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	switch t {
+	case ErrNotFound, ErrConflict, ErrValidation, ErrUnauthorized, ErrForbidden:
+		return e.Kind == t.Kind
+	}
+	return false
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+// Validation reports a request that is well-formed but not acceptable. Fields
+// lists the individual problems, if there are any to point at.
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+// KindOf returns the kind of the first Error in err's chain, or KindInternal
+// if there is none.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
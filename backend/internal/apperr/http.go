@@ -0,0 +1,77 @@
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/JoshPugli/grindhouse-api/internal/middleware"
+)
+
+// ContentTypeProblem is the media type of Problem responses.
+const ContentTypeProblem = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Every error response the
+// API sends has this shape.
+type Problem struct {
+	Type      string       `json:"type" example:"about:blank"`
+	Title     string       `json:"title" example:"Bad Request"`
+	Status    int          `json:"status" example:"400"`
+	Detail    string       `json:"detail,omitempty" example:"Invalid JSON"`
+	RequestID string       `json:"request_id,omitempty" example:"6f1c2a4e-93b1-4c55-a1c8-0d9f3e7b2a10"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Status returns the HTTP status code for err.
+func Status(err error) int {
+	switch KindOf(err) {
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Write sends err to the client as a Problem with the status from Status.
+// Domain errors are shown with their message and field errors; anything else
+// is logged and reported as an internal server error so that database and
+// driver details are not leaked.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		middleware.GetLogger(r.Context()).Error("internal error", "err", err)
+		WriteProblem(w, r, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	writeProblem(w, r, Status(err), e.Message, e.Fields)
+}
+
+// WriteProblem sends a Problem with the given status and detail message.
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	writeProblem(w, r, status, detail, nil)
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string, fields []FieldError) {
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		RequestID: middleware.GetRequestID(r.Context()),
+		Errors:    fields,
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,50 @@
+package apperr
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Postgres SQLSTATEs the domain errors are mapped from.
+const (
+	// uniqueViolation is a unique constraint violation.
+	uniqueViolation = "23505"
+	// foreignKeyViolation is a reference to a row that does not exist.
+	foreignKeyViolation = "23503"
+	// numericValueOutOfRange is a value too large for its numeric column.
+	numericValueOutOfRange = "22003"
+)
+
+// FromUniqueViolation returns a Conflict with message, wrapping err, if err is
+// a unique constraint violation from PostgreSQL or SQLite. Any other error is
+// returned unchanged.
+func FromUniqueViolation(err error, message string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation || isSQLiteUniqueViolation(err) {
+		return &Error{Kind: KindConflict, Message: message, Err: err}
+	}
+	return err
+}
+
+// FromForeignKeyViolation returns a NotFound with message, wrapping err, if
+// err is a foreign key violation from PostgreSQL, such as a reference to a
+// user that does not exist. Any other error is returned unchanged.
+func FromForeignKeyViolation(err error, message string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return &Error{Kind: KindNotFound, Message: message, Err: err}
+	}
+	return err
+}
+
+// FromOutOfRange returns a Validation error for field, wrapping err, if err
+// is a value too large for its PostgreSQL numeric column. Any other error,
+// including nil, is returned unchanged.
+func FromOutOfRange(err error, field FieldError) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == numericValueOutOfRange {
+		return &Error{Kind: KindValidation, Message: "request validation failed", Fields: []FieldError{field}, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package apperr
+
+import (
+	"errors"
+	"strings"
+)
+
+// Fields collects the problems found while validating a request so they can
+// be reported together rather than one per round trip.
+type Fields []FieldError
+
+// Add records that field was rejected with message.
+func (f *Fields) Add(field, message string) {
+	*f = append(*f, FieldError{Field: field, Message: message})
+}
+
+// Err returns a Validation error listing every collected problem, or nil if
+// there are none.
+func (f Fields) Err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return Validation("request validation failed", f...)
+}
+
+// FieldsOf returns the problems err reports: the Fields of the Error in its
+// chain or, if there are none, a single problem with no field and err's
+// message. It lets a batch report why each item was rejected.
+func FieldsOf(err error) []FieldError {
+	var e *Error
+	if !errors.As(err, &e) || len(e.Fields) == 0 {
+		return []FieldError{{Message: err.Error()}}
+	}
+	return e.Fields
+}
+
+// Summary describes err in one line, naming each invalid field, such as
+// "value must not be negative; source_id must be at most 255 characters".
+func Summary(err error) string {
+	fields := FieldsOf(err)
+	problems := make([]string, len(fields))
+	for i, f := range fields {
+		problems[i] = f.Message
+		if f.Field != "" {
+			problems[i] = f.Field + " " + f.Message
+		}
+	}
+	return strings.Join(problems, "; ")
+}
@@ -2,16 +2,32 @@ package auth
 
 import (
 	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateJWT(userID string) (string, error) {
+// Authenticator issues and checks the JWTs that authenticate API requests.
+type Authenticator struct {
+	secret   []byte
+	tokenTTL time.Duration
+}
+
+// NewAuthenticator returns an Authenticator that signs tokens with secret
+// and issues them valid for tokenTTL.
+func NewAuthenticator(secret string, tokenTTL time.Duration) *Authenticator {
+	return &Authenticator{
+		secret:   []byte(secret),
+		tokenTTL: tokenTTL,
+	}
+}
+
+func (a *Authenticator) GenerateJWT(userID string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"exp":     time.Now().Add(a.tokenTTL).Unix(),
 		"iat":     time.Now().Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
-}
\ No newline at end of file
+	return token.SignedString(a.secret)
+}
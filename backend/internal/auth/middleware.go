@@ -7,55 +7,64 @@ import (
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
-)
 
-var jwtSecret = []byte("your-secret-key") // In production, use environment variable
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
 
 type contextKey string
 
 const UserIDKey contextKey = "userID"
 
-func AuthMiddleware(next http.Handler) http.Handler {
+// Middleware rejects requests without a valid bearer token and records the
+// authenticated user ID in the context of the rest.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Missing authorization header", http.StatusUnauthorized)
+		userID, err := a.UserIDFromRequest(r)
+		if err != nil {
+			apperr.WriteProblem(w, r, http.StatusUnauthorized, err.Error())
 			return
 		}
 
-		bearerToken := strings.Split(authHeader, " ")
-		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
-			return
-		}
+		ctx := context.WithValue(r.Context(), UserIDKey, userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-		token, err := jwt.Parse(bearerToken[1], func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return jwtSecret, nil
-		})
+// UserIDFromRequest validates the request's bearer token and returns the
+// user ID it was issued for. The error message is suitable for a 401.
+func (a *Authenticator) UserIDFromRequest(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", fmt.Errorf("Missing authorization header")
+	}
 
-		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+	bearerToken := strings.Split(authHeader, " ")
+	if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
+		return "", fmt.Errorf("Invalid authorization header format")
+	}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
-			return
+	token, err := jwt.Parse(bearerToken[1], func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		return a.secret, nil
+	})
 
-		userID, ok := claims["user_id"].(string)
-		if !ok {
-			http.Error(w, "Invalid user ID in token", http.StatusUnauthorized)
-			return
-		}
+	if err != nil || !token.Valid {
+		return "", fmt.Errorf("Invalid token")
+	}
 
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", fmt.Errorf("Invalid token claims")
+	}
+
+	userID, ok := claims["user_id"].(string)
+	if !ok {
+		return "", fmt.Errorf("Invalid user ID in token")
+	}
+
+	return userID, nil
 }
 
 func GetUserIDFromContext(ctx context.Context) (string, bool) {
@@ -0,0 +1,176 @@
+// Package calendar serves goals as a secret-token iCalendar subscription feed
+package calendar
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apiversion"
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+)
+
+type Handlers struct {
+	calendarRepo *Repository
+	// historyDays is how many days of completed instances the feed includes.
+	historyDays int
+	clock       func() time.Time
+}
+
+func NewHandlers(calendarRepo *Repository, historyDays int, clock func() time.Time) *Handlers {
+	return &Handlers{
+		calendarRepo: calendarRepo,
+		historyDays:  historyDays,
+		clock:        clock,
+	}
+}
+
+// HandleGetSubscription godoc
+// @Summary Get calendar subscription
+// @Description Get the URL of the authenticated user's iCalendar feed
+// @Tags calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Subscription
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Calendar subscription not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /calendar/subscription [get]
+func (h *Handlers) HandleGetSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	token, createdAt, err := h.calendarRepo.GetToken(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Subscription{URL: feedURL(r, token), CreatedAt: createdAt})
+}
+
+// HandleRotateSubscription godoc
+// @Summary Create or rotate calendar subscription
+// @Description Issue a new iCalendar feed URL for the authenticated user. Any previous URL stops working.
+// @Tags calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} Subscription
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /calendar/subscription [post]
+func (h *Handlers) HandleRotateSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	token, createdAt, err := h.calendarRepo.RotateToken(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(Subscription{URL: feedURL(r, token), CreatedAt: createdAt})
+}
+
+// HandleDeleteSubscription godoc
+// @Summary Delete calendar subscription
+// @Description Revoke the authenticated user's iCalendar feed URL
+// @Tags calendar
+// @Security BearerAuth
+// @Success 204 "No Content"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Calendar subscription not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /calendar/subscription [delete]
+func (h *Handlers) HandleDeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	if err := h.calendarRepo.DeleteToken(r.Context(), userID); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleFeed godoc
+// @Summary iCalendar feed
+// @Description Subscribe to active goals as all-day events recurring daily. Days on which a goal was completed are marked with a check mark. The token in the URL is the credential; rotate it to revoke access.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token query string true "Calendar token"
+// @Success 200 {string} string "iCalendar document"
+// @Failure 404 {object} apperr.Problem "Calendar not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /calendar.ics [get]
+func (h *Handlers) HandleFeed(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		apperr.WriteProblem(w, r, http.StatusNotFound, "Calendar not found")
+		return
+	}
+
+	userID, err := h.calendarRepo.lookupToken(r.Context(), token)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	scheduled, err := h.calendarRepo.getScheduledGoals(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	now := h.clock().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	completions, err := h.calendarRepo.getCompletions(r.Context(), userID, today.AddDate(0, 0, -h.historyDays), today)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writeCalendar(&buf, scheduled, completions, now); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="goals.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=900")
+	buf.WriteTo(w)
+}
+
+// feedURL builds the absolute subscription URL calendar apps need, using
+// the scheme and host the request arrived on.
+func feedURL(r *http.Request, token string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     r.Host,
+		Path:     apiversion.Path(r.Context(), "/calendar.ics"),
+		RawQuery: url.Values{"token": {token}}.Encode(),
+	}
+	return u.String()
+}
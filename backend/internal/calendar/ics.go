@@ -0,0 +1,154 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	productID       = "-//Grindhouse//Goal Tracker//EN"
+	uidDomain       = "grindhouse"
+	dateFormat      = "20060102"
+	dateTimeFormat  = "20060102T150405Z"
+	maxLineOctets   = 75
+	completedPrefix = "✓ "
+)
+
+// writeCalendar writes an iCalendar (RFC 5545) document with one all-day,
+// daily-recurring VEVENT per goal. Each completed instance is emitted as an
+// override of that day's occurrence (same UID plus RECURRENCE-ID) with a
+// check mark in its summary, so calendar apps show which days were done.
+func writeCalendar(w io.Writer, scheduled []scheduledGoal, completions []completion, now time.Time) error {
+	cw := &contentWriter{w: bufio.NewWriter(w)}
+
+	byGoal := make(map[string][]completion)
+	for _, c := range completions {
+		byGoal[c.GoalID] = append(byGoal[c.GoalID], c)
+	}
+
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:" + productID)
+	cw.line("CALSCALE:GREGORIAN")
+	cw.line("METHOD:PUBLISH")
+	cw.line("X-WR-CALNAME:Goals")
+	cw.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	cw.line("X-PUBLISHED-TTL:PT1H")
+
+	for _, goal := range scheduled {
+		uid := "goal-" + goal.ID + "@" + uidDomain
+
+		cw.line("BEGIN:VEVENT")
+		cw.line("UID:" + uid)
+		cw.line("DTSTAMP:" + goal.UpdatedAt.UTC().Format(dateTimeFormat))
+		cw.line("DTSTART;VALUE=DATE:" + goal.Start.Format(dateFormat))
+		cw.line("RRULE:FREQ=DAILY")
+		cw.line("SUMMARY:" + escapeText(goal.Title))
+		if description := goalDescription(goal); description != "" {
+			cw.line("DESCRIPTION:" + escapeText(description))
+		}
+		cw.line("TRANSP:TRANSPARENT")
+		cw.line("END:VEVENT")
+
+		for _, c := range byGoal[goal.ID] {
+			date := c.Date.Format(dateFormat)
+			stamp := now
+			if c.CompletedAt != nil {
+				stamp = *c.CompletedAt
+			}
+
+			cw.line("BEGIN:VEVENT")
+			cw.line("UID:" + uid)
+			cw.line("DTSTAMP:" + stamp.UTC().Format(dateTimeFormat))
+			cw.line("RECURRENCE-ID;VALUE=DATE:" + date)
+			cw.line("DTSTART;VALUE=DATE:" + date)
+			cw.line("SUMMARY:" + escapeText(completedPrefix+goal.Title))
+			cw.line("DESCRIPTION:" + escapeText(completionDescription(goal, c)))
+			cw.line("TRANSP:TRANSPARENT")
+			cw.line("END:VEVENT")
+		}
+	}
+
+	cw.line("END:VCALENDAR")
+
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.w.Flush()
+}
+
+func goalDescription(goal scheduledGoal) string {
+	var parts []string
+	if goal.Description != nil && *goal.Description != "" {
+		parts = append(parts, *goal.Description)
+	}
+	if goal.TargetValue != nil {
+		parts = append(parts, "Target: "+formatAmount(*goal.TargetValue, goal.Unit))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func completionDescription(goal scheduledGoal, c completion) string {
+	if c.CompletedValue == nil {
+		return "Completed"
+	}
+	description := "Completed: " + formatAmount(*c.CompletedValue, goal.Unit)
+	if goal.TargetValue != nil {
+		description += " of " + formatAmount(*goal.TargetValue, goal.Unit)
+	}
+	return description
+}
+
+func formatAmount(value float64, unit *string) string {
+	s := strconv.FormatFloat(value, 'f', -1, 64)
+	if unit != nil && *unit != "" {
+		s += " " + *unit
+	}
+	return s
+}
+
+// escapeText escapes a TEXT property value (RFC 5545 section 3.3.11).
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// contentWriter writes CRLF-terminated content lines, folding any longer
+// than 75 octets without splitting a UTF-8 sequence (RFC 5545 section 3.1).
+type contentWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (cw *contentWriter) line(s string) {
+	if cw.err != nil {
+		return
+	}
+
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if _, cw.err = fmt.Fprintf(cw.w, "%s\r\n ", s[:cut]); cw.err != nil {
+			return
+		}
+		s = s[cut:]
+		// Continuation lines start with a space, which counts towards the
+		// limit.
+		limit = maxLineOctets - 1
+	}
+	_, cw.err = fmt.Fprintf(cw.w, "%s\r\n", s)
+}
@@ -0,0 +1,124 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Read", "Read"},
+		{"Run; stretch", `Run\; stretch`},
+		{"Eggs, milk", `Eggs\, milk`},
+		{`C:\notes`, `C:\\notes`},
+		{"one\ntwo", `one\ntwo`},
+		{"one\r\ntwo\rthree", `one\ntwo\nthree`},
+		{`a\,b`, `a\\\,b`},
+	}
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestContentWriterFolds(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		lines int
+	}{
+		{"short", "SUMMARY:Read", 1},
+		{"exactly 75 octets", "SUMMARY:" + strings.Repeat("a", 67), 1},
+		{"76 octets", "SUMMARY:" + strings.Repeat("a", 68), 2},
+		// Each prefix puts a rune across octet 75, where a naive fold cuts.
+		{"two-octet runes at the fold", "SUMMARY:" + strings.Repeat("é", 60), 2},
+		{"three-octet runes at the fold", "SUMMARY:" + strings.Repeat("✓", 60), 3},
+		{"four-octet runes at the fold", "SUMMARY:a" + strings.Repeat("🏃", 40), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cw := &contentWriter{w: bufio.NewWriter(&buf)}
+			cw.line(tt.line)
+			if cw.err != nil {
+				t.Fatalf("line: %v", cw.err)
+			}
+			cw.w.Flush()
+
+			out := buf.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("output %q does not end with CRLF", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			if len(lines) != tt.lines {
+				t.Errorf("folded into %d lines, want %d: %q", len(lines), tt.lines, lines)
+			}
+			for i, l := range lines {
+				if len(l) > maxLineOctets {
+					t.Errorf("line %d is %d octets, want at most %d", i, len(l), maxLineOctets)
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("line %d %q splits a UTF-8 sequence", i, l)
+				}
+				if i > 0 && !strings.HasPrefix(l, " ") {
+					t.Errorf("continuation line %d %q does not start with a space", i, l)
+				}
+			}
+
+			unfolded := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", "")
+			if unfolded != tt.line {
+				t.Errorf("unfolds to %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
+
+func TestWriteCalendar(t *testing.T) {
+	day := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	completedAt := day.Add(7 * time.Hour)
+	value, target, unit := 12.0, 10.0, "km"
+	goal := scheduledGoal{
+		ID:          "0b8f0ab2-4d5c-4f8a-9d8e-3f1e2d3c4b5a",
+		Title:       "Run, then stretch",
+		GoalType:    "numeric",
+		TargetValue: &target,
+		Unit:        &unit,
+		Start:       day.AddDate(0, 0, -7),
+		UpdatedAt:   day,
+	}
+
+	var buf bytes.Buffer
+	err := writeCalendar(&buf, []scheduledGoal{goal}, []completion{{GoalID: goal.ID, Date: day, CompletedValue: &value, CompletedAt: &completedAt}}, day)
+	if err != nil {
+		t.Fatalf("writeCalendar: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:goal-" + goal.ID + "@" + uidDomain + "\r\n",
+		"DTSTART;VALUE=DATE:20261012\r\n",
+		"RRULE:FREQ=DAILY\r\n",
+		`SUMMARY:Run\, then stretch` + "\r\n",
+		"RECURRENCE-ID;VALUE=DATE:20261019\r\n",
+		"DTSTAMP:20261019T070000Z\r\n",
+		`SUMMARY:✓ Run\, then stretch` + "\r\n",
+		"DESCRIPTION:Completed: 12 km of 10 km\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("calendar does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "BEGIN:VEVENT") != 2 {
+		t.Errorf("calendar has %d events, want the goal and its completion", strings.Count(out, "BEGIN:VEVENT"))
+	}
+}
@@ -0,0 +1,32 @@
+package calendar
+
+import "time"
+
+// Subscription is a user's secret calendar feed. The URL embeds the token,
+// so anyone holding it can read the feed until the token is rotated.
+type Subscription struct {
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// scheduledGoal is an active goal along with the first date it recurs from.
+// Start is the earlier of the goal's creation date and its first instance,
+// so imported history falls on a recurrence of the feed's event.
+type scheduledGoal struct {
+	ID          string
+	Title       string
+	Description *string
+	GoalType    string
+	TargetValue *float64
+	Unit        *string
+	Start       time.Time
+	UpdatedAt   time.Time
+}
+
+// completion is a past completed instance of a scheduled goal.
+type completion struct {
+	GoalID         string
+	Date           time.Time
+	CompletedValue *float64
+	CompletedAt    *time.Time
+}
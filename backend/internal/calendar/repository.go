@@ -0,0 +1,162 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetToken returns the user's current feed token.
+func (r *Repository) GetToken(ctx context.Context, userID string) (string, time.Time, error) {
+	var token string
+	var createdAt time.Time
+	query := `SELECT token, created_at FROM calendar_tokens WHERE user_id = $1`
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&token, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", time.Time{}, apperr.NotFound("calendar subscription not found")
+		}
+		return "", time.Time{}, fmt.Errorf("failed to get calendar token: %w", err)
+	}
+
+	return token, createdAt, nil
+}
+
+// RotateToken issues a new feed token for the user, replacing any previous
+// one so old subscription URLs stop working.
+func (r *Repository) RotateToken(ctx context.Context, userID string) (string, time.Time, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	query := `
+		INSERT INTO calendar_tokens (user_id, token)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token = EXCLUDED.token, created_at = CURRENT_TIMESTAMP
+		RETURNING created_at
+	`
+	var createdAt time.Time
+	if err := r.db.QueryRowContext(ctx, query, userID, token).Scan(&createdAt); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to rotate calendar token: %w", err)
+	}
+
+	return token, createdAt, nil
+}
+
+func (r *Repository) DeleteToken(ctx context.Context, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM calendar_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("calendar subscription not found")
+	}
+
+	return nil
+}
+
+// lookupToken resolves a feed token to the user it belongs to.
+func (r *Repository) lookupToken(ctx context.Context, token string) (string, error) {
+	var userID string
+	err := r.db.QueryRowContext(ctx, `SELECT user_id FROM calendar_tokens WHERE token = $1`, token).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", apperr.NotFound("calendar not found")
+		}
+		return "", fmt.Errorf("failed to get calendar token: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (r *Repository) getScheduledGoals(ctx context.Context, userID string) ([]scheduledGoal, error) {
+	query := `
+		SELECT g.id, g.title, g.description, g.goal_type, g.target_value, g.unit,
+		       LEAST(g.created_at::date, COALESCE(MIN(i.date), g.created_at::date)), g.updated_at
+		FROM goals g
+		LEFT JOIN daily_goal_instances i ON i.goal_id = g.id
+		WHERE g.user_id = $1 AND g.is_active = true
+		GROUP BY g.id
+		ORDER BY g.created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals: %w", err)
+	}
+	defer rows.Close()
+
+	var scheduled []scheduledGoal
+	for rows.Next() {
+		var g scheduledGoal
+		err := rows.Scan(&g.ID, &g.Title, &g.Description, &g.GoalType, &g.TargetValue, &g.Unit, &g.Start, &g.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		scheduled = append(scheduled, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get goals: %w", err)
+	}
+
+	return scheduled, nil
+}
+
+// getCompletions returns completed instances of the user's active goals
+// dated between since and today inclusive.
+func (r *Repository) getCompletions(ctx context.Context, userID string, since, today time.Time) ([]completion, error) {
+	query := `
+		SELECT i.goal_id, i.date, i.completed_value, i.completed_at
+		FROM daily_goal_instances i
+		JOIN goals g ON g.id = i.goal_id
+		WHERE i.user_id = $1 AND g.is_active = true AND i.is_completed = true
+		  AND i.date >= $2 AND i.date <= $3
+		ORDER BY i.goal_id, i.date
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, since, today)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completed instances: %w", err)
+	}
+	defer rows.Close()
+
+	var completions []completion
+	for rows.Next() {
+		var c completion
+		if err := rows.Scan(&c.GoalID, &c.Date, &c.CompletedValue, &c.CompletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan completed instance: %w", err)
+		}
+		completions = append(completions, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get completed instances: %w", err)
+	}
+
+	return completions, nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate calendar token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,376 @@
+// Package config loads the server's settings into a typed Config. Defaults
+// are overridden by an optional YAML file, named by CONFIG_FILE, and then
+// by environment variables, so a deployment can keep most settings in a
+// file and supply secrets through its environment.
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+)
+
+// Environments the server can run in. Development relaxes the checks that
+// protect a deployment, such as refusing the default JWT secret.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// DefaultJWTSecret signs tokens in development. It is public, so the server
+// refuses to start with it anywhere else.
+const DefaultJWTSecret = "your-secret-key"
+
+// minJWTSecretLength is the shortest secret accepted outside development:
+// 32 bytes, the output size of the HS256 hash.
+const minJWTSecretLength = 32
+
+type Config struct {
+	Env         string      `yaml:"env"`
+	Server      Server      `yaml:"server"`
+	Database    Database    `yaml:"database"`
+	Auth        Auth        `yaml:"auth"`
+	CORS        CORS        `yaml:"cors"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Export      Export      `yaml:"export"`
+	Calendar    Calendar    `yaml:"calendar"`
+	Webhooks    Webhooks    `yaml:"webhooks"`
+}
+
+type Server struct {
+	Addr            string        `yaml:"addr"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type Database struct {
+	// Driver is the database the server stores its data in. SQLite keeps
+	// everything in the file at Path and serves only accounts and goals;
+	// the other settings are for PostgreSQL.
+	Driver dialect.Dialect `yaml:"driver"`
+	Path   string          `yaml:"path"`
+
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	// QueryTimeout bounds every statement but those of the export worker,
+	// which has its own. It matches the server's write timeout by default,
+	// after which nobody is waiting for the result.
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+}
+
+type Auth struct {
+	JWTSecret string        `yaml:"jwt_secret"`
+	TokenTTL  time.Duration `yaml:"token_ttl"`
+}
+
+type CORS struct {
+	// AllowedOrigins are the origins browsers may call the API from. "*"
+	// allows any origin. Empty sends no CORS headers, which limits browsers
+	// to the API's own origin.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+type Idempotency struct {
+	TTL time.Duration `yaml:"ttl"`
+	// CleanupInterval is how often expired keys are deleted.
+	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+	// MaxBodyBytes is the largest request body accepted with a key. The
+	// body is read into memory to fingerprint the request.
+	MaxBodyBytes int `yaml:"max_body_bytes"`
+}
+
+type Export struct {
+	Dir string `yaml:"dir"`
+	// SyncLimit is the most daily instances an account may have for its
+	// export to be returned in the response; larger accounts are exported
+	// in the background.
+	SyncLimit int `yaml:"sync_limit"`
+	// LinkTTL is how long a download link for a background export works.
+	LinkTTL time.Duration `yaml:"link_ttl"`
+	// PollInterval is how often the worker looks for queued exports.
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// QueryTimeout bounds each statement the worker runs to read an
+	// account, in place of the database query timeout.
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+}
+
+type Calendar struct {
+	// HistoryDays is how many days of completed instances the calendar
+	// feed includes.
+	HistoryDays int `yaml:"history_days"`
+}
+
+type Webhooks struct {
+	// Timeout bounds each delivery, including reading the response.
+	Timeout time.Duration `yaml:"timeout"`
+	// PollInterval is how often the dispatcher looks for due deliveries.
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// AllowedNetworks are CIDR ranges deliveries may be sent to although
+	// they are not public, such as an internal network hosting receivers.
+	// Loopback, private and link-local addresses are refused otherwise.
+	AllowedNetworks []string `yaml:"allowed_networks"`
+}
+
+// Default returns the settings used when nothing overrides them. They suit
+// a production deployment except for the secrets, which must be supplied.
+func Default() Config {
+	return Config{
+		Env: EnvProduction,
+		Server: Server{
+			Addr:            ":8000",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     120 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+		},
+		Database: Database{
+			Driver:       dialect.Postgres,
+			Path:         "grindhouse.db",
+			Host:         "localhost",
+			Port:         "5432",
+			User:         "myuser",
+			Password:     "mypassword",
+			Name:         "testdb",
+			SSLMode:      "disable",
+			QueryTimeout: 30 * time.Second,
+		},
+		Auth: Auth{
+			JWTSecret: DefaultJWTSecret,
+			TokenTTL:  24 * time.Hour,
+		},
+		Idempotency: Idempotency{
+			TTL:             24 * time.Hour,
+			CleanupInterval: time.Hour,
+			MaxBodyBytes:    1 << 20,
+		},
+		Export: Export{
+			Dir:          filepath.Join(os.TempDir(), "grindhouse-exports"),
+			SyncLimit:    5000,
+			LinkTTL:      24 * time.Hour,
+			PollInterval: 10 * time.Second,
+			QueryTimeout: time.Hour,
+		},
+		Calendar: Calendar{
+			HistoryDays: 365,
+		},
+		Webhooks: Webhooks{
+			Timeout:      10 * time.Second,
+			PollInterval: 5 * time.Second,
+		},
+	}
+}
+
+// Load reads the configuration and validates it.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	// Browser clients run on other origins during development.
+	if cfg.Env == EnvDevelopment && len(cfg.CORS.AllowedOrigins) == 0 {
+		cfg.CORS.AllowedOrigins = []string{"*"}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv overrides settings with the environment variables that are set.
+func (c *Config) loadEnv(lookup func(string) (string, bool)) error {
+	str := func(key string, dst *string) {
+		if value, ok := lookup(key); ok && value != "" {
+			*dst = value
+		}
+	}
+
+	var errs []string
+	duration := func(key string, dst *time.Duration) {
+		value, ok := lookup(key)
+		if !ok || value == "" {
+			return
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid %s %q", key, value))
+			return
+		}
+		*dst = parsed
+	}
+	integer := func(key string, dst *int) {
+		value, ok := lookup(key)
+		if !ok || value == "" {
+			return
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid %s %q", key, value))
+			return
+		}
+		*dst = parsed
+	}
+	list := func(key string, dst *[]string) {
+		value, ok := lookup(key)
+		if !ok || value == "" {
+			return
+		}
+		*dst = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*dst = append(*dst, item)
+			}
+		}
+	}
+
+	str("APP_ENV", &c.Env)
+
+	str("HTTP_ADDR", &c.Server.Addr)
+	duration("HTTP_READ_TIMEOUT", &c.Server.ReadTimeout)
+	duration("HTTP_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	duration("HTTP_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	duration("HTTP_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+
+	str("DB_DRIVER", (*string)(&c.Database.Driver))
+	str("DB_PATH", &c.Database.Path)
+	str("DB_HOST", &c.Database.Host)
+	str("DB_PORT", &c.Database.Port)
+	str("DB_USER", &c.Database.User)
+	str("DB_PASSWORD", &c.Database.Password)
+	str("DB_NAME", &c.Database.Name)
+	str("DB_SSLMODE", &c.Database.SSLMode)
+	duration("DB_QUERY_TIMEOUT", &c.Database.QueryTimeout)
+
+	str("JWT_SECRET", &c.Auth.JWTSecret)
+	duration("JWT_TTL", &c.Auth.TokenTTL)
+
+	list("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+
+	duration("IDEMPOTENCY_TTL", &c.Idempotency.TTL)
+	duration("IDEMPOTENCY_CLEANUP_INTERVAL", &c.Idempotency.CleanupInterval)
+	integer("IDEMPOTENCY_MAX_BODY_BYTES", &c.Idempotency.MaxBodyBytes)
+
+	str("EXPORT_DIR", &c.Export.Dir)
+	integer("EXPORT_SYNC_LIMIT", &c.Export.SyncLimit)
+	duration("EXPORT_LINK_TTL", &c.Export.LinkTTL)
+	duration("EXPORT_POLL_INTERVAL", &c.Export.PollInterval)
+	duration("EXPORT_QUERY_TIMEOUT", &c.Export.QueryTimeout)
+
+	integer("CALENDAR_HISTORY_DAYS", &c.Calendar.HistoryDays)
+
+	duration("WEBHOOK_TIMEOUT", &c.Webhooks.Timeout)
+	duration("WEBHOOK_POLL_INTERVAL", &c.Webhooks.PollInterval)
+	list("WEBHOOK_ALLOWED_NETWORKS", &c.Webhooks.AllowedNetworks)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid environment: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Validate checks that the settings are usable, and that a deployment
+// outside development has been given its own secrets. All problems are
+// reported together.
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.Env != EnvDevelopment && c.Env != EnvProduction {
+		problems = append(problems, fmt.Sprintf("env must be %s or %s", EnvDevelopment, EnvProduction))
+	}
+
+	if c.Server.Addr == "" {
+		problems = append(problems, "server address is required")
+	}
+	for name, d := range map[string]time.Duration{
+		"server read timeout":          c.Server.ReadTimeout,
+		"server write timeout":         c.Server.WriteTimeout,
+		"server idle timeout":          c.Server.IdleTimeout,
+		"server shutdown timeout":      c.Server.ShutdownTimeout,
+		"database query timeout":       c.Database.QueryTimeout,
+		"JWT TTL":                      c.Auth.TokenTTL,
+		"idempotency TTL":              c.Idempotency.TTL,
+		"idempotency cleanup interval": c.Idempotency.CleanupInterval,
+		"export link TTL":              c.Export.LinkTTL,
+		"export poll interval":         c.Export.PollInterval,
+		"export query timeout":         c.Export.QueryTimeout,
+		"webhook timeout":              c.Webhooks.Timeout,
+		"webhook poll interval":        c.Webhooks.PollInterval,
+	} {
+		if d <= 0 {
+			problems = append(problems, name+" must be positive")
+		}
+	}
+
+	if _, err := dialect.Parse(string(c.Database.Driver)); err != nil {
+		problems = append(problems, err.Error())
+	} else if c.Database.Driver == dialect.SQLite && c.Database.Path == "" {
+		problems = append(problems, "database path is required for sqlite")
+	}
+
+	switch {
+	case c.Auth.JWTSecret == "":
+		problems = append(problems, "JWT secret is required")
+	case c.Env != EnvDevelopment && c.Auth.JWTSecret == DefaultJWTSecret:
+		problems = append(problems, "JWT secret must be changed from the default outside development")
+	case c.Env != EnvDevelopment && len(c.Auth.JWTSecret) < minJWTSecretLength:
+		problems = append(problems, fmt.Sprintf("JWT secret must be at least %d bytes outside development", minJWTSecretLength))
+	}
+
+	if c.Export.Dir == "" {
+		problems = append(problems, "export directory is required")
+	}
+	if c.Export.SyncLimit < 0 {
+		problems = append(problems, "export sync limit must not be negative")
+	}
+	if c.Idempotency.MaxBodyBytes <= 0 {
+		problems = append(problems, "idempotency max body bytes must be positive")
+	}
+	if c.Calendar.HistoryDays <= 0 {
+		problems = append(problems, "calendar history days must be positive")
+	}
+	for _, network := range c.Webhooks.AllowedNetworks {
+		if _, err := netip.ParsePrefix(network); err != nil {
+			problems = append(problems, fmt.Sprintf("webhook allowed network %q must be a CIDR range", network))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -3,36 +3,50 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"os"
+	"net/url"
 
 	_ "github.com/lib/pq"
-)
+	_ "modernc.org/sqlite"
 
-func NewConnection() (*sql.DB, error) {
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "myuser")
-	password := getEnv("DB_PASSWORD", "mypassword")
-	dbname := getEnv("DB_NAME", "testdb")
+	"github.com/JoshPugli/grindhouse-api/internal/config"
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+)
 
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
+// NewConnection opens the database and checks that it is reachable. Every
+// statement run on a PostgreSQL connection is cancelled by the server after
+// cfg.QueryTimeout, unless a transaction raises the limit for itself with
+// SET LOCAL statement_timeout; callers cancel earlier by passing a context.
+// SQLite has no such timeout, so only the context bounds its statements.
+func NewConnection(cfg config.Database) (*sql.DB, error) {
+	driver, dsn := "postgres", fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s statement_timeout=%d",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode, cfg.QueryTimeout.Milliseconds())
+	if cfg.Driver == dialect.SQLite {
+		driver, dsn = "sqlite", sqliteDSN(cfg.Path)
+	}
 
-	db, err := sql.Open("postgres", connStr)
+	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	return db, nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
\ No newline at end of file
+// sqliteDSN configures every connection to the file at path to enforce
+// foreign keys, as PostgreSQL does, and to let readers run alongside the
+// writer. Transactions take the write lock when they begin, so one that
+// reads before it writes waits for other writers instead of failing when
+// it upgrades.
+func sqliteDSN(path string) string {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Set("_txlock", "immediate")
+	return "file:" + path + "?" + params.Encode()
+}
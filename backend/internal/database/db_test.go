@@ -0,0 +1,82 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/JoshPugli/grindhouse-api/internal/config"
+	"github.com/JoshPugli/grindhouse-api/internal/database"
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+)
+
+// Queries that run until they are cancelled.
+const (
+	postgresSleep = `SELECT pg_sleep(60)`
+	sqliteSpin    = `WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n) SELECT COUNT(*) FROM n`
+)
+
+func TestContextCancelAbortsQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   func(t *testing.T) config.Database
+		query string
+	}{
+		{"postgres", func(t *testing.T) config.Database { return storetest.PostgresConfig(t) }, postgresSleep},
+		{"sqlite", func(t *testing.T) config.Database {
+			cfg := config.Default().Database
+			cfg.Driver = dialect.SQLite
+			cfg.Path = filepath.Join(t.TempDir(), "test.db")
+			return cfg
+		}, sqliteSpin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg(t)
+			db, err := database.NewConnection(cfg)
+			if err != nil {
+				t.Fatalf("NewConnection: %v", err)
+			}
+			defer db.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			var n int64
+			err = db.QueryRowContext(ctx, tt.query).Scan(&n)
+			if err == nil {
+				t.Fatal("query finished, want it cancelled")
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("query ran for %v after its context was cancelled", elapsed)
+			}
+
+			// The connection is usable afterwards.
+			if err := db.PingContext(context.Background()); err != nil {
+				t.Errorf("Ping after cancelling: %v", err)
+			}
+		})
+	}
+}
+
+func TestStatementTimeout(t *testing.T) {
+	cfg := storetest.PostgresConfig(t)
+	cfg.QueryTimeout = 200 * time.Millisecond
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		t.Fatalf("NewConnection: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.ExecContext(context.Background(), postgresSleep)
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "57014" {
+		t.Errorf("query past the statement timeout returned %v, want query_canceled", err)
+	}
+}
@@ -0,0 +1,82 @@
+package devicesync
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
+
+// Source order breaks ties between records written by the same transaction.
+const (
+	sourceGoals = iota
+	sourceInstances
+	sourceTombstones
+)
+
+// cursor is the decoded form of the opaque sync cursor.
+//
+// Rows are stamped with the ID of the transaction that wrote them, and a
+// transaction ID is allocated before the transaction commits, so a row can
+// become visible with an ID lower than rows already synced. To never miss
+// such a row, a sync does not resume after the highest ID it returned but
+// from the oldest transaction still running when it started (its snapshot
+// xmin). Rows at or above that point may be sent again; clients apply them
+// idempotently.
+//
+// Since is the lower bound for this sync, Until the xmin captured on its
+// first page, which becomes the next sync's Since. While paging, After*
+// is the position of the last record returned.
+type cursor struct {
+	Since       uint64
+	Until       uint64
+	Paging      bool
+	AfterXID    uint64
+	AfterSource int
+	AfterID     string
+}
+
+func (c cursor) encode() string {
+	raw := strconv.FormatUint(c.Since, 10) + "|" + strconv.FormatUint(c.Until, 10)
+	if c.Paging {
+		raw += "|" + strconv.FormatUint(c.AfterXID, 10) + "|" + strconv.Itoa(c.AfterSource) + "|" + c.AfterID
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (cursor, error) {
+	if s == "" {
+		return cursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, apperr.Validation("invalid cursor")
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 2 && len(parts) != 5 {
+		return cursor{}, apperr.Validation("invalid cursor")
+	}
+
+	var c cursor
+	if c.Since, err = strconv.ParseUint(parts[0], 10, 64); err != nil {
+		return cursor{}, apperr.Validation("invalid cursor")
+	}
+	if c.Until, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+		return cursor{}, apperr.Validation("invalid cursor")
+	}
+	if len(parts) == 5 {
+		c.Paging = true
+		if c.AfterXID, err = strconv.ParseUint(parts[2], 10, 64); err != nil {
+			return cursor{}, apperr.Validation("invalid cursor")
+		}
+		if c.AfterSource, err = strconv.Atoi(parts[3]); err != nil || c.AfterSource < sourceGoals || c.AfterSource > sourceTombstones {
+			return cursor{}, apperr.Validation("invalid cursor")
+		}
+		c.AfterID = parts[4]
+	}
+
+	return c, nil
+}
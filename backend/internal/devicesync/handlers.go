@@ -0,0 +1,116 @@
+// Package devicesync implements delta sync for offline-first clients
+package devicesync
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+const (
+	defaultSyncLimit    = 500
+	maxSyncLimit        = 1000
+	maxPushMutations    = 500
+	maxPushRequestBytes = 4 << 20
+)
+
+type Handlers struct {
+	syncRepo  *Repository
+	listeners goals.Listeners
+}
+
+func NewHandlers(syncRepo *Repository, listeners goals.Listeners) *Handlers {
+	return &Handlers{
+		syncRepo:  syncRepo,
+		listeners: listeners,
+	}
+}
+
+// HandlePull godoc
+// @Summary Pull changes
+// @Description Get goals, daily instances and deletions changed since a cursor, oldest first. Omit since for a full sync. Records may be returned more than once across syncs, so clients must apply them idempotently, keeping the copy with the highest revision. Keep requesting with the returned cursor while has_more is true, and store the final cursor for the next sync.
+// @Tags sync
+// @Produce json
+// @Security BearerAuth
+// @Param since query string false "Cursor returned by the previous sync"
+// @Param limit query int false "Maximum records per page (default 500, max 1000)"
+// @Success 200 {object} Changes
+// @Failure 400 {object} apperr.Problem "Invalid cursor or limit"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /sync [get]
+func (h *Handlers) HandlePull(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	limit := defaultSyncLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxSyncLimit {
+			apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	changes, err := h.syncRepo.GetChanges(r.Context(), userID, r.URL.Query().Get("since"), limit)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+// HandlePush godoc
+// @Summary Push changes
+// @Description Apply goal and daily instance mutations made offline, using client-generated IDs. Only fields present in a mutation are written. If a record changed on the server since base_revision, the mutation wins only if its modified_at is later than the server's updated_at; otherwise it is rejected as a conflict. Mutations that fail the validation of the goals endpoints are rejected individually as invalid. The response contains the server's copy of every record touched, including those that lost a conflict.
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param changes body PushRequest true "Mutations to apply"
+// @Success 200 {object} PushResponse
+// @Failure 400 {object} apperr.Problem "Invalid JSON or too many mutations"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /sync [post]
+func (h *Handlers) HandlePush(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req PushRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPushRequestBytes)).Decode(&req); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if len(req.Goals)+len(req.Instances) > maxPushMutations {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Too many mutations, maximum is "+strconv.Itoa(maxPushMutations))
+		return
+	}
+
+	response, changes, err := h.syncRepo.Push(r.Context(), userID, req)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	for _, change := range changes {
+		h.listeners.Notify(r.Context(), change)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
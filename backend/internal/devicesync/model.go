@@ -0,0 +1,113 @@
+package devicesync
+
+import (
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+// Entity names the kind of record a tombstone or rejection refers to.
+type Entity string
+
+const (
+	EntityGoal     Entity = "goal"
+	EntityInstance Entity = "instance"
+)
+
+// Goal is a goal as seen by sync clients. Revision changes on every write
+// and is what clients send back as base_revision when they modify it.
+type Goal struct {
+	goals.Goal
+	Revision string `json:"revision"`
+}
+
+// Instance is a daily goal instance as seen by sync clients.
+type Instance struct {
+	goals.DailyGoalInstance
+	UpdatedAt time.Time `json:"updated_at"`
+	Revision  string    `json:"revision"`
+}
+
+// Tombstone records that a goal or instance was permanently deleted.
+type Tombstone struct {
+	Entity    Entity    `json:"entity"`
+	ID        string    `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+	Revision  string    `json:"revision"`
+}
+
+// Changes is one page of a delta sync. Clients apply it and pass Cursor as
+// since on the next request, immediately if HasMore is set.
+type Changes struct {
+	Goals     []Goal      `json:"goals"`
+	Instances []Instance  `json:"instances"`
+	Deleted   []Tombstone `json:"deleted"`
+	Cursor    string      `json:"cursor"`
+	HasMore   bool        `json:"has_more"`
+}
+
+// GoalMutation creates or modifies a goal. ID is generated by the client.
+// Nil fields are left unchanged, so a client that only sends the fields it
+// edited merges with edits other devices made to other fields.
+type GoalMutation struct {
+	ID           string                `json:"id"`
+	BaseRevision *string               `json:"base_revision"`
+	ModifiedAt   time.Time             `json:"modified_at"`
+	Deleted      bool                  `json:"deleted"`
+	Title        *string               `json:"title"`
+	Description  *string               `json:"description"`
+	GoalType     *goals.GoalType       `json:"goal_type"`
+	TargetValue  *float64              `json:"target_value"`
+	Unit         *string               `json:"unit"`
+	Visibility   *goals.GoalVisibility `json:"visibility"`
+	Difficulty   *goals.GoalDifficulty `json:"difficulty"`
+	IsActive     *bool                 `json:"is_active"`
+}
+
+// InstanceMutation creates, modifies or deletes the instance of GoalID on
+// Date (YYYY-MM-DD). If the server already has an instance for that day
+// under a different ID, the mutation applies to it and the response carries
+// the server's ID.
+type InstanceMutation struct {
+	ID             string    `json:"id"`
+	GoalID         string    `json:"goal_id"`
+	Date           string    `json:"date"`
+	BaseRevision   *string   `json:"base_revision"`
+	ModifiedAt     time.Time `json:"modified_at"`
+	Deleted        bool      `json:"deleted"`
+	CompletedValue *float64  `json:"completed_value"`
+	IsCompleted    *bool     `json:"is_completed"`
+}
+
+type PushRequest struct {
+	Goals     []GoalMutation     `json:"goals"`
+	Instances []InstanceMutation `json:"instances"`
+}
+
+// RejectReason explains why a mutation was not applied.
+type RejectReason string
+
+const (
+	// RejectConflict means the record changed on the server after
+	// base_revision and the server's change is newer than modified_at.
+	RejectConflict RejectReason = "conflict"
+	RejectInvalid  RejectReason = "invalid"
+	RejectNotFound RejectReason = "not_found"
+)
+
+type Rejection struct {
+	Entity  Entity       `json:"entity"`
+	ID      string       `json:"id"`
+	Reason  RejectReason `json:"reason"`
+	Message string       `json:"message"`
+}
+
+// PushResponse carries the server's copy of every record a push touched,
+// including records whose mutation lost a conflict, so clients can replace
+// their local copies.
+type PushResponse struct {
+	Goals     []Goal      `json:"goals"`
+	Instances []Instance  `json:"instances"`
+	Deleted   []Tombstone `json:"deleted"`
+	Rejected  []Rejection `json:"rejected"`
+}
@@ -0,0 +1,636 @@
+package devicesync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/google/uuid"
+)
+
+const (
+	goalColumns = `id, user_id, title, description, goal_type, target_value, unit, visibility, difficulty,
+		is_active, created_at, updated_at, version, change_xid::text`
+	instanceColumns = `id, goal_id, user_id, date, target_value, completed_value, is_completed, completed_at,
+		created_at, updated_at, version, change_xid::text`
+	tombstoneColumns = `id, entity, entity_id, deleted_at, change_xid::text`
+)
+
+type Repository struct {
+	db    *sql.DB
+	clock func() time.Time
+}
+
+func NewRepository(db *sql.DB, clock func() time.Time) *Repository {
+	return &Repository{db: db, clock: clock}
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanGoal(row scanner) (*Goal, error) {
+	var g Goal
+	err := row.Scan(&g.ID, &g.UserID, &g.Title, &g.Description, &g.GoalType, &g.TargetValue, &g.Unit,
+		&g.Visibility, &g.Difficulty, &g.IsActive, &g.CreatedAt, &g.UpdatedAt, &g.Version, &g.Revision)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func scanInstance(row scanner) (*Instance, error) {
+	var i Instance
+	err := row.Scan(&i.ID, &i.GoalID, &i.UserID, &i.Date, &i.TargetValue, &i.CompletedValue,
+		&i.IsCompleted, &i.CompletedAt, &i.CreatedAt, &i.UpdatedAt, &i.Version, &i.Revision)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// change is one record of a delta, tagged with its position in sync order.
+type change struct {
+	xid       uint64
+	source    int
+	id        string
+	goal      *Goal
+	instance  *Instance
+	tombstone *Tombstone
+}
+
+func (c change) before(o change) bool {
+	if c.xid != o.xid {
+		return c.xid < o.xid
+	}
+	if c.source != o.source {
+		return c.source < o.source
+	}
+	return c.id < o.id
+}
+
+// GetChanges returns up to limit goals, instances and tombstones written
+// since the cursor, oldest first. An empty cursor starts a full sync.
+func (r *Repository) GetChanges(ctx context.Context, userID, since string, limit int) (*Changes, error) {
+	cur, err := decodeCursor(since)
+	if err != nil {
+		return nil, err
+	}
+	if cur.Paging {
+		if _, err := uuid.Parse(cur.AfterID); err != nil {
+			return nil, apperr.Validation("invalid cursor")
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Every query below must see the same snapshot as the xmin captured
+	// here, or a transaction committing in between could be skipped.
+	if _, err := tx.ExecContext(ctx, `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY`); err != nil {
+		return nil, fmt.Errorf("failed to start sync snapshot: %w", err)
+	}
+
+	if !cur.Paging {
+		var xmin string
+		if err := tx.QueryRowContext(ctx, `SELECT pg_snapshot_xmin(pg_current_snapshot())::text`).Scan(&xmin); err != nil {
+			return nil, fmt.Errorf("failed to get sync snapshot: %w", err)
+		}
+		if cur.Until, err = strconv.ParseUint(xmin, 10, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse sync snapshot: %w", err)
+		}
+	}
+
+	var changes []change
+
+	goalRows, err := r.queryChanges(ctx, tx, "goals", goalColumns, sourceGoals, userID, cur, limit)
+	if err != nil {
+		return nil, err
+	}
+	for goalRows.Next() {
+		g, err := scanGoal(goalRows)
+		if err != nil {
+			goalRows.Close()
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		xid, _ := strconv.ParseUint(g.Revision, 10, 64)
+		changes = append(changes, change{xid: xid, source: sourceGoals, id: g.ID, goal: g})
+	}
+	goalRows.Close()
+	if err := goalRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get changed goals: %w", err)
+	}
+
+	instanceRows, err := r.queryChanges(ctx, tx, "daily_goal_instances", instanceColumns, sourceInstances, userID, cur, limit)
+	if err != nil {
+		return nil, err
+	}
+	for instanceRows.Next() {
+		i, err := scanInstance(instanceRows)
+		if err != nil {
+			instanceRows.Close()
+			return nil, fmt.Errorf("failed to scan daily instance: %w", err)
+		}
+		xid, _ := strconv.ParseUint(i.Revision, 10, 64)
+		changes = append(changes, change{xid: xid, source: sourceInstances, id: i.ID, instance: i})
+	}
+	instanceRows.Close()
+	if err := instanceRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get changed daily instances: %w", err)
+	}
+
+	tombstoneRows, err := r.queryChanges(ctx, tx, "sync_tombstones", tombstoneColumns, sourceTombstones, userID, cur, limit)
+	if err != nil {
+		return nil, err
+	}
+	for tombstoneRows.Next() {
+		var id string
+		var t Tombstone
+		if err := tombstoneRows.Scan(&id, &t.Entity, &t.ID, &t.DeletedAt, &t.Revision); err != nil {
+			tombstoneRows.Close()
+			return nil, fmt.Errorf("failed to scan tombstone: %w", err)
+		}
+		xid, _ := strconv.ParseUint(t.Revision, 10, 64)
+		changes = append(changes, change{xid: xid, source: sourceTombstones, id: id, tombstone: &t})
+	}
+	tombstoneRows.Close()
+	if err := tombstoneRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get tombstones: %w", err)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].before(changes[j])
+	})
+
+	result := &Changes{Goals: []Goal{}, Instances: []Instance{}, Deleted: []Tombstone{}}
+	if len(changes) > limit {
+		changes = changes[:limit]
+		last := changes[limit-1]
+		result.HasMore = true
+		result.Cursor = cursor{
+			Since:       cur.Since,
+			Until:       cur.Until,
+			Paging:      true,
+			AfterXID:    last.xid,
+			AfterSource: last.source,
+			AfterID:     last.id,
+		}.encode()
+	} else {
+		next := cursor{Since: cur.Until}
+		if next.Since < cur.Since {
+			next.Since = cur.Since
+		}
+		result.Cursor = next.encode()
+	}
+
+	for _, c := range changes {
+		switch {
+		case c.goal != nil:
+			result.Goals = append(result.Goals, *c.goal)
+		case c.instance != nil:
+			result.Instances = append(result.Instances, *c.instance)
+		case c.tombstone != nil:
+			result.Deleted = append(result.Deleted, *c.tombstone)
+		}
+	}
+
+	return result, nil
+}
+
+// queryChanges selects the next limit+1 rows of one table in sync order.
+func (r *Repository) queryChanges(ctx context.Context, tx *sql.Tx, table, columns string, source int, userID string, cur cursor, limit int) (*sql.Rows, error) {
+	args := []any{userID, strconv.FormatUint(cur.Since, 10), limit + 1}
+
+	position := ""
+	if cur.Paging {
+		args = append(args, strconv.FormatUint(cur.AfterXID, 10), cur.AfterID)
+		switch {
+		case source > cur.AfterSource:
+			position = `AND change_xid >= $4::xid8`
+		case source < cur.AfterSource:
+			position = `AND change_xid > $4::xid8`
+		default:
+			position = `AND (change_xid > $4::xid8 OR (change_xid = $4::xid8 AND id > $5::uuid))`
+		}
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s
+		WHERE user_id = $1 AND change_xid >= $2::xid8 %s
+		ORDER BY change_xid, id
+		LIMIT $3
+	`, columns, table, position)
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changes from %s: %w", table, err)
+	}
+
+	return rows, nil
+}
+
+// Push applies client mutations in one transaction: goals first, so
+// instances can refer to goals created in the same push. A mutation to a
+// record that changed on the server since base_revision is resolved by last
+// writer wins on modified_at; because only non-nil fields are written, the
+// winner overwrites just the fields it edited. Mutations that cannot be
+// applied are rejected individually without failing the push. The returned
+// changes are for the caller to notify goal listeners once committed.
+func (r *Repository) Push(ctx context.Context, userID string, req PushRequest) (*PushResponse, []goals.Change, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	p := &push{
+		tx:     tx,
+		userID: userID,
+		now:    r.clock(),
+		response: &PushResponse{
+			Goals:     []Goal{},
+			Instances: []Instance{},
+			Deleted:   []Tombstone{},
+			Rejected:  []Rejection{},
+		},
+	}
+
+	for _, m := range req.Goals {
+		if err := p.applyGoal(ctx, m); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, m := range req.Instances {
+		if err := p.applyInstance(ctx, m); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit sync: %w", err)
+	}
+
+	return p.response, p.changes, nil
+}
+
+type push struct {
+	tx     *sql.Tx
+	userID string
+	// now stamps deletions and completions that the client left undated.
+	now      time.Time
+	response *PushResponse
+	changes  []goals.Change
+}
+
+func (p *push) reject(entity Entity, id string, reason RejectReason, message string) {
+	p.response.Rejected = append(p.response.Rejected, Rejection{Entity: entity, ID: id, Reason: reason, Message: message})
+}
+
+// wins reports whether a mutation may overwrite a record at revision,
+// last updated at updatedAt.
+func wins(baseRevision *string, modifiedAt time.Time, revision string, updatedAt time.Time) bool {
+	if baseRevision != nil && *baseRevision == revision {
+		return true
+	}
+	return modifiedAt.After(updatedAt)
+}
+
+func (p *push) applyGoal(ctx context.Context, m GoalMutation) error {
+	if _, err := uuid.Parse(m.ID); err != nil {
+		p.reject(EntityGoal, m.ID, RejectInvalid, "id must be a UUID")
+		return nil
+	}
+	if err := m.updateRequest().Validate(); err != nil {
+		p.reject(EntityGoal, m.ID, RejectInvalid, apperr.Summary(err))
+		return nil
+	}
+
+	current, err := scanGoal(p.tx.QueryRowContext(ctx, `SELECT `+goalColumns+` FROM goals WHERE id = $1 FOR UPDATE`, m.ID))
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get goal: %w", err)
+	}
+
+	if current == nil {
+		if m.Deleted {
+			return nil
+		}
+		return p.createGoal(ctx, m)
+	}
+
+	if current.UserID != p.userID {
+		p.reject(EntityGoal, m.ID, RejectNotFound, "goal not found")
+		return nil
+	}
+
+	if !wins(m.BaseRevision, m.ModifiedAt, current.Revision, current.UpdatedAt) {
+		p.reject(EntityGoal, m.ID, RejectConflict, "goal was changed on the server after base_revision")
+		p.response.Goals = append(p.response.Goals, *current)
+		return nil
+	}
+
+	if m.GoalType != nil && *m.GoalType != current.GoalType {
+		p.reject(EntityGoal, m.ID, RejectInvalid, "goal_type cannot be changed")
+		p.response.Goals = append(p.response.Goals, *current)
+		return nil
+	}
+	if err := m.updateRequest().ValidateFor(current.GoalType); err != nil {
+		p.reject(EntityGoal, m.ID, RejectInvalid, apperr.Summary(err))
+		p.response.Goals = append(p.response.Goals, *current)
+		return nil
+	}
+
+	updated := *current
+	if m.Title != nil {
+		updated.Title = *m.Title
+	}
+	if m.Description != nil {
+		updated.Description = m.Description
+	}
+	if m.TargetValue != nil {
+		updated.TargetValue = m.TargetValue
+	}
+	if m.Unit != nil {
+		updated.Unit = m.Unit
+	}
+	if m.Visibility != nil {
+		updated.Visibility = *m.Visibility
+	}
+	if m.Difficulty != nil {
+		updated.Difficulty = *m.Difficulty
+	}
+	if m.IsActive != nil {
+		updated.IsActive = *m.IsActive
+	}
+	// Goals are soft deleted, as DELETE /api/goals/{id} does.
+	if m.Deleted {
+		updated.IsActive = false
+	}
+
+	query := `
+		UPDATE goals
+		SET title = $1, description = $2, target_value = $3, unit = $4, visibility = $5, difficulty = $6, is_active = $7
+		WHERE id = $8
+		RETURNING ` + goalColumns
+	goal, err := scanGoal(p.tx.QueryRowContext(ctx, query, updated.Title, updated.Description, updated.TargetValue, updated.Unit,
+		updated.Visibility, updated.Difficulty, updated.IsActive, m.ID))
+	if err != nil {
+		return fmt.Errorf("failed to update goal: %w", err)
+	}
+
+	p.response.Goals = append(p.response.Goals, *goal)
+
+	kind := goals.GoalUpdated
+	if current.IsActive && !goal.IsActive {
+		kind = goals.GoalDeleted
+	}
+	p.changes = append(p.changes, goals.Change{Kind: kind, UserID: p.userID, Goal: &goal.Goal})
+
+	return nil
+}
+
+func (p *push) createGoal(ctx context.Context, m GoalMutation) error {
+	if err := m.createRequest().Validate(); err != nil {
+		p.reject(EntityGoal, m.ID, RejectInvalid, apperr.Summary(err))
+		return nil
+	}
+
+	visibility := goals.VisibilityPrivate
+	if m.Visibility != nil {
+		visibility = *m.Visibility
+	}
+	difficulty := goals.DifficultyMedium
+	if m.Difficulty != nil {
+		difficulty = *m.Difficulty
+	}
+	isActive := !m.Deleted
+	if m.IsActive != nil {
+		isActive = *m.IsActive
+	}
+
+	query := `
+		INSERT INTO goals (id, user_id, title, description, goal_type, target_value, unit, visibility, difficulty, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + goalColumns
+	goal, err := scanGoal(p.tx.QueryRowContext(ctx, query, m.ID, p.userID, *m.Title, m.Description, *m.GoalType,
+		m.TargetValue, m.Unit, visibility, difficulty, isActive))
+	if err != nil {
+		return fmt.Errorf("failed to create goal: %w", err)
+	}
+
+	p.response.Goals = append(p.response.Goals, *goal)
+	p.changes = append(p.changes, goals.Change{Kind: goals.GoalCreated, UserID: p.userID, Goal: &goal.Goal})
+
+	return nil
+}
+
+// createRequest and updateRequest express a mutation as the request the
+// goals API takes for the same change, so that sync is held to the same
+// validation rules.
+func (m GoalMutation) createRequest() goals.CreateGoalRequest {
+	req := goals.CreateGoalRequest{
+		Description: m.Description,
+		TargetValue: m.TargetValue,
+		Unit:        m.Unit,
+	}
+	if m.Title != nil {
+		req.Title = *m.Title
+	}
+	if m.GoalType != nil {
+		req.GoalType = *m.GoalType
+	}
+	if m.Visibility != nil {
+		req.Visibility = *m.Visibility
+	}
+	if m.Difficulty != nil {
+		req.Difficulty = *m.Difficulty
+	}
+	return req
+}
+
+func (m GoalMutation) updateRequest() goals.UpdateGoalRequest {
+	return goals.UpdateGoalRequest{
+		Title:       m.Title,
+		Description: m.Description,
+		TargetValue: m.TargetValue,
+		Unit:        m.Unit,
+		Visibility:  m.Visibility,
+		Difficulty:  m.Difficulty,
+		IsActive:    m.IsActive,
+	}
+}
+
+func (p *push) applyInstance(ctx context.Context, m InstanceMutation) error {
+	if _, err := uuid.Parse(m.ID); err != nil {
+		p.reject(EntityInstance, m.ID, RejectInvalid, "id must be a UUID")
+		return nil
+	}
+	if _, err := uuid.Parse(m.GoalID); err != nil {
+		p.reject(EntityInstance, m.ID, RejectInvalid, "goal_id must be a UUID")
+		return nil
+	}
+	date, err := time.Parse("2006-01-02", m.Date)
+	if err != nil {
+		p.reject(EntityInstance, m.ID, RejectInvalid, "date must be YYYY-MM-DD")
+		return nil
+	}
+	if err := (goals.UpdateDailyInstanceRequest{CompletedValue: m.CompletedValue}).Validate(); err != nil {
+		p.reject(EntityInstance, m.ID, RejectInvalid, apperr.Summary(err))
+		return nil
+	}
+
+	goal, err := scanGoal(p.tx.QueryRowContext(ctx, `SELECT `+goalColumns+` FROM goals WHERE id = $1 AND user_id = $2`, m.GoalID, p.userID))
+	if err == sql.ErrNoRows {
+		p.reject(EntityInstance, m.ID, RejectNotFound, "goal not found")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get goal: %w", err)
+	}
+
+	current, err := p.lockInstance(ctx, m.ID, m.GoalID, date)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.UserID != p.userID {
+		p.reject(EntityInstance, m.ID, RejectNotFound, "daily instance not found")
+		return nil
+	}
+	if current != nil && (current.GoalID != m.GoalID || !current.Date.Equal(date)) {
+		p.reject(EntityInstance, m.ID, RejectInvalid, "goal_id and date of an instance cannot be changed")
+		p.response.Instances = append(p.response.Instances, *current)
+		return nil
+	}
+
+	var previous *goals.DailyGoalInstance
+	if current == nil {
+		if m.Deleted {
+			return nil
+		}
+
+		current, err = p.createInstance(ctx, m.ID, goal, date)
+		if err != nil {
+			return err
+		}
+		// As with PUT /api/goals/{id}/daily, listeners see a new instance
+		// as a change from its empty initial state.
+		previous = &current.DailyGoalInstance
+	} else {
+		if !wins(m.BaseRevision, m.ModifiedAt, current.Revision, current.UpdatedAt) {
+			p.reject(EntityInstance, m.ID, RejectConflict, "daily instance was changed on the server after base_revision")
+			p.response.Instances = append(p.response.Instances, *current)
+			return nil
+		}
+		previous = &current.DailyGoalInstance
+	}
+
+	if m.Deleted {
+		var revision string
+		err := p.tx.QueryRowContext(ctx, `DELETE FROM daily_goal_instances WHERE id = $1 RETURNING pg_current_xact_id()::text`, current.ID).Scan(&revision)
+		if err != nil {
+			return fmt.Errorf("failed to delete daily instance: %w", err)
+		}
+		p.response.Deleted = append(p.response.Deleted, Tombstone{
+			Entity:    EntityInstance,
+			ID:        current.ID,
+			DeletedAt: p.now.UTC(),
+			Revision:  revision,
+		})
+		// Listeners undo what the instance counted for, such as its
+		// experience award.
+		p.changes = append(p.changes, goals.Change{
+			Kind:     goals.InstanceDeleted,
+			UserID:   p.userID,
+			Goal:     &goal.Goal,
+			Instance: &current.DailyGoalInstance,
+			Previous: previous,
+		})
+		return nil
+	}
+
+	updated := current.DailyGoalInstance
+	if m.CompletedValue != nil {
+		updated.CompletedValue = m.CompletedValue
+	}
+	if m.IsCompleted != nil {
+		updated.IsCompleted = *m.IsCompleted
+		if updated.IsCompleted && updated.CompletedAt == nil {
+			completedAt := m.ModifiedAt
+			if completedAt.IsZero() {
+				completedAt = p.now
+			}
+			updated.CompletedAt = &completedAt
+		} else if !updated.IsCompleted {
+			updated.CompletedAt = nil
+		}
+	}
+
+	query := `
+		UPDATE daily_goal_instances
+		SET completed_value = $1, is_completed = $2, completed_at = $3
+		WHERE id = $4
+		RETURNING ` + instanceColumns
+	instance, err := scanInstance(p.tx.QueryRowContext(ctx, query, updated.CompletedValue, updated.IsCompleted, updated.CompletedAt, current.ID))
+	if err != nil {
+		return fmt.Errorf("failed to update daily instance: %w", err)
+	}
+
+	p.response.Instances = append(p.response.Instances, *instance)
+	p.changes = append(p.changes, goals.Change{
+		Kind:     goals.InstanceUpdated,
+		UserID:   p.userID,
+		Goal:     &goal.Goal,
+		Instance: &instance.DailyGoalInstance,
+		Previous: previous,
+	})
+
+	return nil
+}
+
+// lockInstance finds the instance a mutation refers to, by ID or else by
+// goal and date, and locks it for the rest of the push.
+func (p *push) lockInstance(ctx context.Context, id, goalID string, date time.Time) (*Instance, error) {
+	query := `
+		SELECT ` + instanceColumns + ` FROM daily_goal_instances
+		WHERE id = $1 OR (goal_id = $2 AND date = $3)
+		ORDER BY id = $1 DESC
+		LIMIT 1
+		FOR UPDATE
+	`
+	instance, err := scanInstance(p.tx.QueryRowContext(ctx, query, id, goalID, date))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily instance: %w", err)
+	}
+	return instance, nil
+}
+
+// createInstance inserts an empty instance with the client's ID. If another
+// request created the goal's instance for that day first, that instance is
+// locked and returned instead.
+func (p *push) createInstance(ctx context.Context, id string, goal *Goal, date time.Time) (*Instance, error) {
+	query := `
+		INSERT INTO daily_goal_instances (id, goal_id, user_id, date, target_value)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (goal_id, date) DO NOTHING
+		RETURNING ` + instanceColumns
+	instance, err := scanInstance(p.tx.QueryRowContext(ctx, query, id, goal.ID, p.userID, date, goal.TargetValue))
+	if err == sql.ErrNoRows {
+		instance, err = p.lockInstance(ctx, id, goal.ID, date)
+		if err == nil && instance == nil {
+			err = fmt.Errorf("daily instance disappeared")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create daily instance: %w", err)
+	}
+	return instance, nil
+}
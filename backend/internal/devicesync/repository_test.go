@@ -0,0 +1,133 @@
+package devicesync_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoshPugli/grindhouse-api/internal/devicesync"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+	"github.com/JoshPugli/grindhouse-api/internal/xp"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestPush(t *testing.T) {
+	db := storetest.OpenPostgres(t)
+	ctx := t.Context()
+
+	repo := devicesync.NewRepository(db, time.Now)
+	xpRepo := xp.NewRepository(db)
+	listeners := goals.Listeners{xp.NewService(xpRepo, goals.NewRepository(db, time.Now))}
+
+	owner := storetest.NewUser(t, db)
+
+	push := func(req devicesync.PushRequest) (*devicesync.PushResponse, []goals.Change) {
+		t.Helper()
+		response, changes, err := repo.Push(ctx, owner.ID, req)
+		if err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+		for _, change := range changes {
+			listeners.Notify(ctx, change)
+		}
+		return response, changes
+	}
+
+	numeric := func(id string) devicesync.GoalMutation {
+		return devicesync.GoalMutation{
+			ID:          id,
+			ModifiedAt:  time.Now(),
+			Title:       ptr("Run"),
+			GoalType:    ptr(goals.GoalTypeNumeric),
+			TargetValue: ptr(5.0),
+			Unit:        ptr("km"),
+		}
+	}
+
+	valid := numeric(uuid.New().String())
+	// 255 characters is the limit, however many bytes they take.
+	valid.Title = ptr(strings.Repeat("é", 255))
+	tooLarge := numeric(uuid.New().String())
+	tooLarge.TargetValue = ptr(1e12)
+	longUnit := numeric(uuid.New().String())
+	longUnit.Unit = ptr(strings.Repeat("u", 51))
+	booleanWithTarget := numeric(uuid.New().String())
+	booleanWithTarget.GoalType = ptr(goals.GoalTypeBoolean)
+
+	instance := devicesync.InstanceMutation{
+		ID:             uuid.New().String(),
+		GoalID:         valid.ID,
+		Date:           "2026-10-19",
+		ModifiedAt:     time.Now(),
+		CompletedValue: ptr(5.0),
+		IsCompleted:    ptr(true),
+	}
+	negative := devicesync.InstanceMutation{
+		ID:             uuid.New().String(),
+		GoalID:         valid.ID,
+		Date:           "2026-10-18",
+		ModifiedAt:     time.Now(),
+		CompletedValue: ptr(-1.0),
+	}
+
+	response, _ := push(devicesync.PushRequest{
+		Goals:     []devicesync.GoalMutation{valid, tooLarge, longUnit, booleanWithTarget},
+		Instances: []devicesync.InstanceMutation{instance, negative},
+	})
+
+	rejected := make(map[string]devicesync.RejectReason)
+	for _, r := range response.Rejected {
+		rejected[r.ID] = r.Reason
+	}
+	for name, id := range map[string]string{
+		"target_value over the column limit": tooLarge.ID,
+		"unit over 50 characters":            longUnit.ID,
+		"boolean goal with a target":         booleanWithTarget.ID,
+		"negative completed_value":           negative.ID,
+	} {
+		if rejected[id] != devicesync.RejectInvalid {
+			t.Errorf("%s: rejection is %q, want %q", name, rejected[id], devicesync.RejectInvalid)
+		}
+	}
+	if len(response.Goals) != 1 || len(response.Instances) != 1 {
+		t.Fatalf("push applied %d goals and %d instances, want 1 of each: rejected %+v", len(response.Goals), len(response.Instances), response.Rejected)
+	}
+
+	total, err := xpRepo.GetTotal(ctx, owner.ID)
+	if err != nil {
+		t.Fatalf("GetTotal: %v", err)
+	}
+	if total <= 0 {
+		t.Fatalf("experience after completing an instance is %d, want an award", total)
+	}
+
+	// Deleting the instance reverses its award and keeps both entries.
+	deleted := instance
+	deleted.Deleted = true
+	deleted.BaseRevision = &response.Instances[0].Revision
+	response, changes := push(devicesync.PushRequest{Instances: []devicesync.InstanceMutation{deleted}})
+	if len(response.Deleted) != 1 {
+		t.Fatalf("push deleted %d records, want 1: rejected %+v", len(response.Deleted), response.Rejected)
+	}
+	if len(changes) != 1 || changes[0].Kind != goals.InstanceDeleted {
+		t.Errorf("delete produced changes %+v, want one %s", changes, goals.InstanceDeleted)
+	}
+
+	if total, err = xpRepo.GetTotal(ctx, owner.ID); err != nil {
+		t.Fatalf("GetTotal: %v", err)
+	}
+	if total != 0 {
+		t.Errorf("experience after deleting the instance is %d, want 0", total)
+	}
+	var entries int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM xp_ledger WHERE instance_id = $1`, instance.ID).Scan(&entries); err != nil {
+		t.Fatalf("failed to count ledger entries: %v", err)
+	}
+	if entries != 2 {
+		t.Errorf("ledger has %d entries for the deleted instance, want the award and its reversal", entries)
+	}
+}
@@ -0,0 +1,66 @@
+// Package dialect covers the differences between the SQL databases the
+// stores run on: PostgreSQL, and SQLite for self-hosting without a database
+// server.
+//
+// Most differences are in the schema, and the SQLite migrations handle them:
+// ids are generated by the stores rather than by uuid_generate_v4, enums are
+// TEXT columns with CHECK constraints, and DECIMAL columns are REAL. What is
+// left is how statements cast their arguments and how times are stored.
+package dialect
+
+import (
+	"fmt"
+	"time"
+)
+
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// Layouts of times stored as text on SQLite. Without a zone and with
+// trailing zeros of the fraction dropped, they sort as text in time order,
+// so they can be compared and ordered in SQL.
+const (
+	TimestampLayout = "2006-01-02T15:04:05.999999"
+	DateLayout      = time.DateOnly
+)
+
+// Parse returns the dialect named s.
+func Parse(s string) (Dialect, error) {
+	switch d := Dialect(s); d {
+	case Postgres, SQLite:
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown database driver %q, want %s or %s", s, Postgres, SQLite)
+}
+
+// Cast returns expr converted to typ, a PostgreSQL type name. SQLite has no
+// column types to convert to, so expr is compared as it is stored.
+func (d Dialect) Cast(expr, typ string) string {
+	if d == SQLite {
+		return expr
+	}
+	return expr + "::" + typ
+}
+
+// Timestamp returns t as a statement argument for a TIMESTAMP column, with
+// the microsecond precision PostgreSQL stores.
+func (d Dialect) Timestamp(t time.Time) any {
+	t = t.UTC().Truncate(time.Microsecond)
+	if d == SQLite {
+		return t.Format(TimestampLayout)
+	}
+	return t
+}
+
+// Date returns the date of t as a statement argument for a DATE column.
+func (d Dialect) Date(t time.Time) any {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if d == SQLite {
+		return date.Format(DateLayout)
+	}
+	return date
+}
@@ -0,0 +1,199 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+var (
+	goalCSVHeader = []string{
+		"id", "title", "description", "goal_type", "target_value", "unit",
+		"visibility", "difficulty", "is_active", "created_at", "updated_at",
+	}
+	instanceCSVHeader = []string{
+		"id", "goal_id", "date", "target_value", "completed_value",
+		"is_completed", "completed_at", "created_at",
+	}
+)
+
+// WriteArchive streams a ZIP of everything the user owns to w. Goals and
+// daily instances are written as both JSON and CSV; the profile and settings
+// are JSON only. Rows are read from the database as they are written, so
+// memory use does not grow with the size of the account.
+func WriteArchive(ctx context.Context, w io.Writer, exportRepo *Repository, userID string) error {
+	zw := zip.NewWriter(w)
+
+	profile, err := exportRepo.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(zw, "profile.json", profile); err != nil {
+		return err
+	}
+
+	settings, err := exportRepo.GetSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(zw, "settings.json", settings); err != nil {
+		return err
+	}
+
+	err = writeJSONArray(zw, "goals.json", func(emit func(any) error) error {
+		return exportRepo.EachGoal(ctx, userID, func(goal goals.Goal) error { return emit(goal) })
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeCSV(zw, "goals.csv", goalCSVHeader, func(emit func([]string) error) error {
+		return exportRepo.EachGoal(ctx, userID, func(goal goals.Goal) error { return emit(goalRecord(goal)) })
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeJSONArray(zw, "daily_instances.json", func(emit func(any) error) error {
+		return exportRepo.EachInstance(ctx, userID, func(instance goals.DailyGoalInstance) error { return emit(instance) })
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeCSV(zw, "daily_instances.csv", instanceCSVHeader, func(emit func([]string) error) error {
+		return exportRepo.EachInstance(ctx, userID, func(instance goals.DailyGoalInstance) error { return emit(instanceRecord(instance)) })
+	})
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeJSONFile(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func writeJSONArray(zw *zip.Writer, name string, each func(emit func(any) error) error) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	if _, err := io.WriteString(f, "["); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	first := true
+	err = each(func(v any) error {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		sep := ",\n"
+		if first {
+			sep = "\n"
+			first = false
+		}
+		if _, err := io.WriteString(f, sep); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		_, err = f.Write(b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(f, "\n]\n"); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func writeCSV(zw *zip.Writer, name string, header []string, each func(emit func([]string) error) error) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	cw := csv.NewWriter(f)
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	if err := each(cw.Write); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func goalRecord(goal goals.Goal) []string {
+	return []string{
+		goal.ID,
+		goal.Title,
+		stringOrEmpty(goal.Description),
+		string(goal.GoalType),
+		floatOrEmpty(goal.TargetValue),
+		stringOrEmpty(goal.Unit),
+		string(goal.Visibility),
+		string(goal.Difficulty),
+		strconv.FormatBool(goal.IsActive),
+		goal.CreatedAt.UTC().Format(time.RFC3339),
+		goal.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func instanceRecord(instance goals.DailyGoalInstance) []string {
+	completedAt := ""
+	if instance.CompletedAt != nil {
+		completedAt = instance.CompletedAt.UTC().Format(time.RFC3339)
+	}
+
+	return []string{
+		instance.ID,
+		instance.GoalID,
+		instance.Date.Format("2006-01-02"),
+		floatOrEmpty(instance.TargetValue),
+		floatOrEmpty(instance.CompletedValue),
+		strconv.FormatBool(instance.IsCompleted),
+		completedAt,
+		instance.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func floatOrEmpty(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
@@ -0,0 +1,171 @@
+// Package export produces ZIP archives of a user's data in JSON and CSV
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apiversion"
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/middleware"
+	"github.com/google/uuid"
+)
+
+type Handlers struct {
+	exportRepo *Repository
+	// syncLimit is the largest number of daily instances exported inline;
+	// bigger accounts are exported by the background worker.
+	syncLimit int
+	// queryTimeout bounds each statement of an inline export, as it does
+	// every other statement a request runs.
+	queryTimeout time.Duration
+	clock        func() time.Time
+}
+
+func NewHandlers(exportRepo *Repository, syncLimit int, queryTimeout time.Duration, clock func() time.Time) *Handlers {
+	return &Handlers{
+		exportRepo:   exportRepo,
+		syncLimit:    syncLimit,
+		queryTimeout: queryTimeout,
+		clock:        clock,
+	}
+}
+
+// HandleExport godoc
+// @Summary Export all data
+// @Description Export the authenticated user's profile, settings, goals and daily instances as a ZIP of JSON and CSV files. Small accounts get the archive streamed directly; large accounts, or requests with async=true, get a background job to poll instead.
+// @Tags export
+// @Produce application/zip
+// @Produce json
+// @Security BearerAuth
+// @Param async query bool false "Always export in the background"
+// @Success 200 {file} file "ZIP archive"
+// @Success 202 {object} Job
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /export [get]
+func (h *Handlers) HandleExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	async := r.URL.Query().Get("async") == "true"
+	if !async {
+		count, err := h.exportRepo.CountInstances(r.Context(), userID)
+		if err != nil {
+			apperr.Write(w, r, err)
+			return
+		}
+		async = count > h.syncLimit
+	}
+
+	if async {
+		job, err := h.exportRepo.CreateJob(r.Context(), userID)
+		if err != nil {
+			apperr.Write(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", apiversion.Path(r.Context(), "/export/jobs/"+job.ID))
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", archiveDisposition(h.clock()))
+	err := h.exportRepo.inSnapshot(r.Context(), h.queryTimeout, func(exportRepo *Repository) error {
+		return WriteArchive(r.Context(), w, exportRepo, userID)
+	})
+	if err != nil {
+		// Headers are already sent, so the client sees a truncated archive.
+		middleware.GetLogger(r.Context()).Error("inline export failed", "user_id", userID, "err", err)
+	}
+}
+
+// HandleGetJob godoc
+// @Summary Get export job
+// @Description Get the status of a background export. Completed jobs include a download link that expires at expires_at.
+// @Tags export
+// @Produce json
+// @Security BearerAuth
+// @Param jobId path string true "Export job ID"
+// @Success 200 {object} Job
+// @Failure 400 {object} apperr.Problem "Invalid job ID"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Export job not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /export/jobs/{jobId} [get]
+func (h *Handlers) HandleGetJob(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	jobID := r.PathValue("jobId")
+	if _, err := uuid.Parse(jobID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, token, err := h.exportRepo.GetJob(r.Context(), jobID, userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	if job.Status == JobCompleted && token != "" {
+		downloadURL := apiversion.Path(r.Context(), "/export/download/"+token)
+		job.DownloadURL = &downloadURL
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// HandleDownload godoc
+// @Summary Download an export
+// @Description Download a completed export archive. The link itself is the credential and stops working once it expires.
+// @Tags export
+// @Produce application/zip
+// @Param token path string true "Download token"
+// @Success 200 {file} file "ZIP archive"
+// @Failure 404 {object} apperr.Problem "Export not found or expired"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /export/download/{token} [get]
+func (h *Handlers) HandleDownload(w http.ResponseWriter, r *http.Request) {
+	path, err := h.exportRepo.GetDownload(r.Context(), r.PathValue("token"))
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		apperr.WriteProblem(w, r, http.StatusNotFound, "Export not found or expired")
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", archiveDisposition(info.ModTime()))
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+func archiveDisposition(at time.Time) string {
+	return fmt.Sprintf(`attachment; filename="grindhouse-export-%s.zip"`, at.UTC().Format("2006-01-02"))
+}
@@ -0,0 +1,61 @@
+package export
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+	JobExpired   JobStatus = "expired"
+)
+
+// Job is a background export. Once completed, DownloadURL links to the
+// archive until ExpiresAt, after which the file is deleted.
+type Job struct {
+	ID          string     `json:"id" db:"id"`
+	Status      JobStatus  `json:"status" db:"status"`
+	Error       *string    `json:"error,omitempty" db:"error"`
+	DownloadURL *string    `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+}
+
+type Profile struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookSetting struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type IngestionTokenSetting struct {
+	ID          string     `json:"id"`
+	GoalID      string     `json:"goal_id"`
+	TokenPrefix string     `json:"token_prefix"`
+	Mode        string     `json:"mode"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
+
+type FollowSetting struct {
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Settings collects per-user configuration that is not part of a goal.
+type Settings struct {
+	Following       []FollowSetting         `json:"following"`
+	Webhooks        []WebhookSetting        `json:"webhooks"`
+	IngestionTokens []IngestionTokenSetting `json:"ingestion_tokens"`
+}
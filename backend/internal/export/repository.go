@@ -0,0 +1,354 @@
+package export
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/lib/pq"
+)
+
+// querier is a *sql.DB or a *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Repository struct {
+	db querier
+	// pool starts transactions. It is nil on a Repository that reads from
+	// one.
+	pool *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db, pool: db}
+}
+
+// inSnapshot calls fn with a Repository that reads from a single read-only
+// transaction, so an archive is consistent however long it takes to write.
+// Its statements may each run for up to timeout instead of the pool's
+// statement timeout, which is sized for requests and would cancel the
+// streaming reads of a large account.
+func (r *Repository) inSnapshot(ctx context.Context, timeout time.Duration, fn func(*Repository) error) error {
+	tx, err := r.pool.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// SET takes no parameters. LOCAL ends with the transaction, so the
+	// connection goes back to the pool with its usual timeout.
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+		return fmt.Errorf("failed to set statement timeout: %w", err)
+	}
+
+	if err := fn(&Repository{db: tx}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit export snapshot: %w", err)
+	}
+	return nil
+}
+
+// claimedJob is a job the worker has moved to running.
+type claimedJob struct {
+	ID     string
+	UserID string
+}
+
+func (r *Repository) CountInstances(ctx context.Context, userID string) (int, error) {
+	query := `SELECT COUNT(*) FROM daily_goal_instances WHERE user_id = $1`
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count daily instances: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *Repository) GetProfile(ctx context.Context, userID string) (*Profile, error) {
+	query := `SELECT id, email, COALESCE(first_name, ''), created_at FROM users WHERE id = $1`
+	var profile Profile
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&profile.ID, &profile.Email, &profile.FirstName, &profile.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &profile, nil
+}
+
+// EachGoal calls fn for every goal the user owns, including deleted ones,
+// without loading them all into memory.
+func (r *Repository) EachGoal(ctx context.Context, userID string, fn func(goals.Goal) error) error {
+	query := `
+		SELECT id, user_id, title, description, goal_type, target_value, unit, visibility, difficulty, is_active, created_at, updated_at, version
+		FROM goals
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get goals: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var goal goals.Goal
+		err := rows.Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.GoalType, &goal.TargetValue, &goal.Unit, &goal.Visibility, &goal.Difficulty, &goal.IsActive, &goal.CreatedAt, &goal.UpdatedAt, &goal.Version)
+		if err != nil {
+			return fmt.Errorf("failed to scan goal: %w", err)
+		}
+		if err := fn(goal); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// EachInstance calls fn for every daily instance the user owns, ordered by
+// goal and date, without loading them all into memory.
+func (r *Repository) EachInstance(ctx context.Context, userID string, fn func(goals.DailyGoalInstance) error) error {
+	query := `
+		SELECT id, goal_id, user_id, date, target_value, completed_value, is_completed, completed_at, created_at, version
+		FROM daily_goal_instances
+		WHERE user_id = $1
+		ORDER BY goal_id, date
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get daily instances: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var instance goals.DailyGoalInstance
+		err := rows.Scan(&instance.ID, &instance.GoalID, &instance.UserID, &instance.Date,
+			&instance.TargetValue, &instance.CompletedValue, &instance.IsCompleted,
+			&instance.CompletedAt, &instance.CreatedAt, &instance.Version)
+		if err != nil {
+			return fmt.Errorf("failed to scan daily instance: %w", err)
+		}
+		if err := fn(instance); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *Repository) GetSettings(ctx context.Context, userID string) (*Settings, error) {
+	settings := &Settings{
+		Following:       []FollowSetting{},
+		Webhooks:        []WebhookSetting{},
+		IngestionTokens: []IngestionTokenSetting{},
+	}
+
+	followRows, err := r.db.QueryContext(ctx, `SELECT followee_id, created_at FROM user_follows WHERE follower_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follows: %w", err)
+	}
+	defer followRows.Close()
+	for followRows.Next() {
+		var follow FollowSetting
+		if err := followRows.Scan(&follow.UserID, &follow.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan follow: %w", err)
+		}
+		settings.Following = append(settings.Following, follow)
+	}
+	if err := followRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get follows: %w", err)
+	}
+
+	webhookRows, err := r.db.QueryContext(ctx, `SELECT id, url, event_types, is_active, created_at FROM webhook_subscriptions WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+	defer webhookRows.Close()
+	for webhookRows.Next() {
+		var webhook WebhookSetting
+		var eventTypes pq.StringArray
+		if err := webhookRows.Scan(&webhook.ID, &webhook.URL, &eventTypes, &webhook.IsActive, &webhook.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		webhook.EventTypes = eventTypes
+		settings.Webhooks = append(settings.Webhooks, webhook)
+	}
+	if err := webhookRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+
+	tokenRows, err := r.db.QueryContext(ctx, `SELECT id, goal_id, token_prefix, mode, created_at, revoked_at FROM ingestion_tokens WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ingestion tokens: %w", err)
+	}
+	defer tokenRows.Close()
+	for tokenRows.Next() {
+		var token IngestionTokenSetting
+		if err := tokenRows.Scan(&token.ID, &token.GoalID, &token.TokenPrefix, &token.Mode, &token.CreatedAt, &token.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ingestion token: %w", err)
+		}
+		settings.IngestionTokens = append(settings.IngestionTokens, token)
+	}
+	if err := tokenRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get ingestion tokens: %w", err)
+	}
+
+	return settings, nil
+}
+
+func (r *Repository) CreateJob(ctx context.Context, userID string) (*Job, error) {
+	query := `
+		INSERT INTO export_jobs (user_id)
+		VALUES ($1)
+		RETURNING id, status, created_at
+	`
+	job := &Job{}
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&job.ID, &job.Status, &job.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	return job, nil
+}
+
+// GetJob returns one of the user's jobs along with its download token, which
+// is empty unless the job has completed and not yet expired.
+func (r *Repository) GetJob(ctx context.Context, jobID, userID string) (*Job, string, error) {
+	query := `
+		SELECT id, status, error, expires_at, created_at, completed_at, COALESCE(download_token, '')
+		FROM export_jobs
+		WHERE id = $1 AND user_id = $2
+	`
+	var job Job
+	var token string
+	err := r.db.QueryRowContext(ctx, query, jobID, userID).Scan(&job.ID, &job.Status, &job.Error, &job.ExpiresAt, &job.CreatedAt, &job.CompletedAt, &token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", apperr.NotFound("export job not found")
+		}
+		return nil, "", fmt.Errorf("failed to get export job: %w", err)
+	}
+
+	return &job, token, nil
+}
+
+// GetDownload resolves a download token to the archive path, provided the
+// job has completed and the link has not expired.
+func (r *Repository) GetDownload(ctx context.Context, token string) (string, error) {
+	query := `
+		SELECT file_path
+		FROM export_jobs
+		WHERE download_token = $1 AND status = 'completed' AND expires_at > NOW()
+	`
+	var filePath string
+	if err := r.db.QueryRowContext(ctx, query, token).Scan(&filePath); err != nil {
+		if err == sql.ErrNoRows {
+			return "", apperr.NotFound("export not found or expired")
+		}
+		return "", fmt.Errorf("failed to get export: %w", err)
+	}
+
+	return filePath, nil
+}
+
+// claimJob moves the oldest pending job to running. Jobs left running for
+// over an hour by a worker that died are picked up again.
+func (r *Repository) claimJob(ctx context.Context) (*claimedJob, error) {
+	query := `
+		UPDATE export_jobs
+		SET status = 'running', started_at = NOW()
+		WHERE id = (
+			SELECT id FROM export_jobs
+			WHERE status = 'pending'
+			   OR (status = 'running' AND started_at < NOW() - INTERVAL '1 hour')
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id
+	`
+	var job claimedJob
+	if err := r.db.QueryRowContext(ctx, query).Scan(&job.ID, &job.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim export job: %w", err)
+	}
+
+	return &job, nil
+}
+
+func (r *Repository) completeJob(ctx context.Context, jobID, filePath string, ttl time.Duration) error {
+	token, err := newDownloadToken()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE export_jobs
+		SET status = 'completed', file_path = $2, download_token = $3,
+		    completed_at = NOW(), expires_at = NOW() + $4::float8 * INTERVAL '1 second'
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, jobID, filePath, token, ttl.Seconds()); err != nil {
+		return fmt.Errorf("failed to complete export job: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) failJob(ctx context.Context, jobID string, jobErr error) error {
+	query := `UPDATE export_jobs SET status = 'failed', error = $2, completed_at = NOW() WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, jobID, jobErr.Error()); err != nil {
+		return fmt.Errorf("failed to record export job failure: %w", err)
+	}
+
+	return nil
+}
+
+// expireJobs marks completed jobs past their expiry as expired and returns
+// the archive paths that can now be deleted.
+func (r *Repository) expireJobs(ctx context.Context) ([]string, error) {
+	query := `
+		UPDATE export_jobs
+		SET status = 'expired', download_token = NULL
+		WHERE status = 'completed' AND expires_at <= NOW()
+		RETURNING file_path
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire export jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan export job: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, rows.Err()
+}
+
+func newDownloadToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate download token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
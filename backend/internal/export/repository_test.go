@@ -0,0 +1,40 @@
+package export
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/database"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+)
+
+// TestInSnapshotTimeout checks that the worker's reads may outlast the
+// pool's statement timeout, and that the pool keeps its own afterwards.
+func TestInSnapshotTimeout(t *testing.T) {
+	cfg := storetest.PostgresConfig(t)
+	cfg.QueryTimeout = 100 * time.Millisecond
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		t.Fatalf("NewConnection: %v", err)
+	}
+	defer db.Close()
+	// One connection, so the last query reuses the snapshot's.
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	repo := NewRepository(db)
+	const slow = `SELECT pg_sleep(0.5)`
+
+	err = repo.inSnapshot(ctx, 5*time.Second, func(snapshot *Repository) error {
+		_, err := snapshot.db.ExecContext(ctx, slow)
+		return err
+	})
+	if err != nil {
+		t.Errorf("slow read in a snapshot: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, slow); err == nil {
+		t.Error("slow read outside a snapshot succeeded, want the pool's statement timeout to cancel it")
+	}
+}
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Worker builds queued export archives on disk and deletes them once their
+// download links expire.
+type Worker struct {
+	exportRepo   *Repository
+	dir          string
+	linkTTL      time.Duration
+	pollInterval time.Duration
+	// queryTimeout bounds each statement that reads an account's data.
+	queryTimeout time.Duration
+	logger       *slog.Logger
+}
+
+func NewWorker(exportRepo *Repository, dir string, linkTTL, pollInterval, queryTimeout time.Duration, logger *slog.Logger) *Worker {
+	return &Worker{
+		exportRepo:   exportRepo,
+		dir:          dir,
+		linkTTL:      linkTTL,
+		pollInterval: pollInterval,
+		queryTimeout: queryTimeout,
+		logger:       logger,
+	}
+}
+
+// Run processes pending jobs and expires old archives every poll interval
+// until ctx is cancelled.
+func (wk *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(wk.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := wk.ProcessPending(ctx); err != nil {
+			wk.logger.Error("failed to process export jobs", "err", err)
+		}
+		if err := wk.RemoveExpired(ctx); err != nil {
+			wk.logger.Error("failed to expire export jobs", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessPending builds archives for pending jobs until none are left.
+func (wk *Worker) ProcessPending(ctx context.Context) error {
+	for {
+		job, err := wk.exportRepo.claimJob(ctx)
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			return nil
+		}
+
+		path, err := wk.build(ctx, job)
+		if err != nil {
+			if failErr := wk.exportRepo.failJob(ctx, job.ID, err); failErr != nil {
+				return failErr
+			}
+			continue
+		}
+
+		if err := wk.exportRepo.completeJob(ctx, job.ID, path, wk.linkTTL); err != nil {
+			return err
+		}
+	}
+}
+
+// RemoveExpired deletes archives whose download links have expired.
+func (wk *Worker) RemoveExpired(ctx context.Context) error {
+	paths, err := wk.exportRepo.expireJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			wk.logger.Error("failed to remove expired export", "path", path, "err", err)
+		}
+	}
+
+	return nil
+}
+
+func (wk *Worker) build(ctx context.Context, job *claimedJob) (string, error) {
+	if err := os.MkdirAll(wk.dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	path := filepath.Join(wk.dir, job.ID+".zip")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create export file: %w", err)
+	}
+
+	err = wk.exportRepo.inSnapshot(ctx, wk.queryTimeout, func(exportRepo *Repository) error {
+		return WriteArchive(ctx, f, exportRepo, job.UserID)
+	})
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,168 @@
+// Package feed records social activity events and serves per-user feeds
+package feed
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type Handlers struct {
+	feedRepo *Repository
+}
+
+func NewHandlers(feedRepo *Repository) *Handlers {
+	return &Handlers{
+		feedRepo: feedRepo,
+	}
+}
+
+// HandleGetFeed godoc
+// @Summary Get activity feed
+// @Description Get activity events from the authenticated user and the users they follow, newest first by default
+// @Tags feed
+// @Produce json
+// @Security BearerAuth
+// @Param order query string false "Sort order (defaults to desc)" Enums(asc, desc)
+// @Param limit query int false "Page size (defaults to 50, at most 200)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} FeedResponse
+// @Header 200 {string} Link "Link to the next page, if there is one"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page, if there is one"
+// @Failure 400 {object} apperr.Problem "Invalid page parameters"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /feed [get]
+func (h *Handlers) HandleGetFeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var fields apperr.Fields
+	page := pagination.Parse(r, &fields, FeedSorts, "created_at", "desc")
+	if err := fields.Err(); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	events, next, err := h.feedRepo.GetFeed(r.Context(), userID, page)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	feed := FeedResponse{Events: events}
+	if next != nil {
+		encoded := next.Encode()
+		feed.NextCursor = &encoded
+	}
+
+	pagination.WriteNext(w, r, next)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feed)
+}
+
+// HandleFollow godoc
+// @Summary Follow a user
+// @Description Follow another user so their non-private activity appears in your feed
+// @Tags feed
+// @Accept json
+// @Security BearerAuth
+// @Param follow body FollowRequest true "User to follow"
+// @Success 204 "No Content"
+// @Failure 400 {object} apperr.Problem "Invalid JSON or user ID"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "User not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /follows [post]
+func (h *Handlers) HandleFollow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req FollowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if _, err := uuid.Parse(req.UserID); err != nil || req.UserID == userID {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.feedRepo.Follow(r.Context(), userID, req.UserID); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetFollowing godoc
+// @Summary List followed users
+// @Description List the users the authenticated user follows
+// @Tags feed
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} Follow
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /follows [get]
+func (h *Handlers) HandleGetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	follows, err := h.feedRepo.GetFollowing(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(follows)
+}
+
+// HandleUnfollow godoc
+// @Summary Unfollow a user
+// @Description Stop following a user. Events already in your feed are kept.
+// @Tags feed
+// @Security BearerAuth
+// @Param userId path string true "User ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} apperr.Problem "Invalid user ID"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Follow not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /follows/{userId} [delete]
+func (h *Handlers) HandleUnfollow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	followeeID := r.PathValue("userId")
+	if _, err := uuid.Parse(followeeID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.feedRepo.Unfollow(r.Context(), userID, followeeID); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
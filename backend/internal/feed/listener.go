@@ -0,0 +1,58 @@
+package feed
+
+import (
+	"context"
+	"slices"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+// Listener records feed events when daily goal instances change state.
+type Listener struct {
+	feedRepo *Repository
+	goalRepo goals.GoalStore
+}
+
+func NewListener(feedRepo *Repository, goalRepo goals.GoalStore) *Listener {
+	return &Listener{
+		feedRepo: feedRepo,
+		goalRepo: goalRepo,
+	}
+}
+
+func (l *Listener) GoalChanged(ctx context.Context, change goals.Change) error {
+	if change.Kind != goals.InstanceUpdated || change.Instance == nil {
+		return nil
+	}
+
+	wasCompleted := change.Previous != nil && change.Previous.IsCompleted
+	if !change.Instance.IsCompleted || wasCompleted {
+		return nil
+	}
+
+	goal := change.Goal
+	private := goal.Visibility == goals.VisibilityPrivate
+	date := change.Instance.Date.Format("2006-01-02")
+
+	err := l.feedRepo.RecordEvent(ctx, change.UserID, goal.ID, private, EventGoalCompleted, goal.ID+":"+date, map[string]any{
+		"date":            date,
+		"completed_value": change.Instance.CompletedValue,
+	})
+	if err != nil {
+		return err
+	}
+
+	streak, err := l.goalRepo.GetCurrentStreak(ctx, goal.ID, change.Instance.Date)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(StreakMilestones, streak) {
+		return nil
+	}
+
+	return l.feedRepo.RecordEvent(ctx, change.UserID, goal.ID, private, EventStreakMilestone, goal.ID+":"+date, map[string]any{
+		"date":   date,
+		"streak": streak,
+	})
+}
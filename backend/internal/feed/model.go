@@ -0,0 +1,43 @@
+package feed
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventGoalCompleted   EventType = "goal_completed"
+	EventStreakMilestone EventType = "streak_milestone"
+)
+
+// StreakMilestones are the streak lengths, in days, that produce a
+// streak_milestone event.
+var StreakMilestones = []int{3, 7, 14, 30, 50, 100, 365}
+
+type Event struct {
+	ID        string          `json:"id" db:"id"`
+	ActorID   string          `json:"actor_id" db:"actor_id"`
+	ActorName string          `json:"actor_name" db:"first_name"`
+	GoalID    *string         `json:"goal_id" db:"goal_id"`
+	GoalTitle *string         `json:"goal_title" db:"title"`
+	EventType EventType       `json:"event_type" db:"event_type"`
+	Payload   json.RawMessage `json:"payload" swaggertype:"object" db:"payload"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+type FeedResponse struct {
+	Events     []Event `json:"events"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+type Follow struct {
+	UserID    string    `json:"user_id" db:"followee_id"`
+	FirstName string    `json:"first_name" db:"first_name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type FollowRequest struct {
+	UserID string `json:"user_id"`
+}
@@ -0,0 +1,174 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/pagination"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// RecordEvent stores an event and fans it out to the actor's own feed and,
+// unless the goal is private, to the feeds of everyone following the actor.
+// Events are deduplicated on (actor, type, dedupeKey) so replaying the same
+// state change is a no-op.
+func (r *Repository) RecordEvent(ctx context.Context, actorID, goalID string, private bool, eventType EventType, dedupeKey string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode event payload: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO activity_events (actor_id, goal_id, event_type, dedupe_key, payload)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (actor_id, event_type, dedupe_key) DO NOTHING
+		RETURNING id, created_at
+	`
+	var eventID string
+	var createdAt time.Time
+	err = tx.QueryRowContext(ctx, query, actorID, goalID, eventType, dedupeKey, body).Scan(&eventID, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record event: %w", err)
+	}
+
+	fanOutQuery := `
+		INSERT INTO feed_items (user_id, event_id, created_at)
+		SELECT $1, $2, $3
+		UNION
+		SELECT follower_id, $2, $3 FROM user_follows WHERE followee_id = $1 AND NOT $4
+	`
+	if _, err := tx.ExecContext(ctx, fanOutQuery, actorID, eventID, createdAt, private); err != nil {
+		return fmt.Errorf("failed to fan out event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit event: %w", err)
+	}
+
+	return nil
+}
+
+// FeedSorts are the orders a feed can be requested in. Events are placed in
+// a feed when they happen, so only newest or oldest first is offered.
+var FeedSorts = pagination.Sorts{
+	"created_at": {Column: "fi.created_at", Type: "timestamp"},
+}
+
+// GetFeed returns a page of a user's feed and the cursor of the next page,
+// which is nil on the last page. Events for goals that have since been made
+// private are hidden from everyone but their owner.
+func (r *Repository) GetFeed(ctx context.Context, userID string, page pagination.Page) ([]Event, *pagination.Cursor, error) {
+	var q pagination.Query
+	q.Where("fi.user_id = ?", userID)
+	q.Where("(e.actor_id = ? OR g.id IS NULL OR g.visibility <> 'private')", userID)
+
+	query, args := q.Build(`
+		SELECT e.id, e.actor_id, COALESCE(u.first_name, ''), e.goal_id, g.title, e.event_type, e.payload, fi.created_at
+		FROM feed_items fi
+		JOIN activity_events e ON e.id = fi.event_id
+		JOIN users u ON u.id = e.actor_id
+		LEFT JOIN goals g ON g.id = e.goal_id`, page, FeedSorts, "fi.event_id")
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get feed: %w", err)
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var event Event
+		err := rows.Scan(&event.ID, &event.ActorID, &event.ActorName, &event.GoalID, &event.GoalTitle,
+			&event.EventType, &event.Payload, &event.CreatedAt)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get feed: %w", err)
+	}
+
+	events, next := pagination.Trim(events, page, func(e Event) (string, string) {
+		return pagination.Timestamp(e.CreatedAt), e.ID
+	})
+	return events, next, nil
+}
+
+func (r *Repository) Follow(ctx context.Context, followerID, followeeID string) error {
+	query := `
+		INSERT INTO user_follows (follower_id, followee_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := r.db.ExecContext(ctx, query, followerID, followeeID); err != nil {
+		return fmt.Errorf("failed to follow user: %w", apperr.FromForeignKeyViolation(err, "user not found"))
+	}
+
+	return nil
+}
+
+func (r *Repository) Unfollow(ctx context.Context, followerID, followeeID string) error {
+	query := `DELETE FROM user_follows WHERE follower_id = $1 AND followee_id = $2`
+	result, err := r.db.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return fmt.Errorf("failed to unfollow user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("follow not found")
+	}
+
+	return nil
+}
+
+func (r *Repository) GetFollowing(ctx context.Context, followerID string) ([]Follow, error) {
+	query := `
+		SELECT f.followee_id, COALESCE(u.first_name, ''), f.created_at
+		FROM user_follows f
+		JOIN users u ON u.id = f.followee_id
+		WHERE f.follower_id = $1
+		ORDER BY f.created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, followerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follows: %w", err)
+	}
+	defer rows.Close()
+
+	follows := []Follow{}
+	for rows.Next() {
+		var follow Follow
+		if err := rows.Scan(&follow.UserID, &follow.FirstName, &follow.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan follow: %w", err)
+		}
+		follows = append(follows, follow)
+	}
+
+	return follows, nil
+}
@@ -0,0 +1,113 @@
+package goals
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
+
+// ErrVersionMismatch is returned by conditional writes when the record is no
+// longer at the version the caller expected.
+var ErrVersionMismatch = apperr.Conflict("version mismatch")
+
+// versionETag is the ETag of a single goal or instance: its version as a
+// strong entity tag.
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// Versions are the versions a conditional write may modify. A nil Versions
+// allows any version. Otherwise the record must be at one of the listed
+// versions, so an empty Versions allows none.
+type Versions []int
+
+// Allows reports whether a record at version may be modified.
+func (v Versions) Allows(version int) bool {
+	return v == nil || slices.Contains(v, version)
+}
+
+// ifMatchVersions reads the If-Match header. It returns nil when the header
+// is absent or "*", meaning any version may be modified, and otherwise the
+// version of every entity tag listed, since the precondition holds if any of
+// them matches (RFC 9110 section 13.1.1). Tags this API never issues, such
+// as weak ones, are left out, so a header naming none of its versions fails.
+func ifMatchVersions(r *http.Request) Versions {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := Versions{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			continue
+		}
+		if version, err := strconv.Atoi(tag[1 : len(tag)-1]); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// versionMismatch reports a failed conditional write. With If-Match the
+// client's precondition failed; without it, another request updated the
+// record between our read and write, which is a conflict the client can
+// simply retry.
+func versionMismatch(w http.ResponseWriter, r *http.Request, ifMatch Versions) {
+	if ifMatch != nil {
+		apperr.WriteProblem(w, r, http.StatusPreconditionFailed, "Precondition failed: the resource has been modified")
+		return
+	}
+	apperr.WriteProblem(w, r, http.StatusConflict, "The resource was modified concurrently, retry the request")
+}
+
+// noneMatch reports whether the If-None-Match header lists etag, using the
+// weak comparison RFC 9110 specifies for If-None-Match.
+func noneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSONWithETag writes v as JSON with an ETag derived from its encoding,
+// or 304 Not Modified if the client already holds that representation.
+// Because every write bumps a version that appears in the body, the hash
+// changes whenever anything in the list does.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, v any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if noneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	buf.WriteTo(w)
+}
@@ -0,0 +1,45 @@
+package goals
+
+import (
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestIfMatchVersions(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Versions
+	}{
+		{"", nil},
+		{"*", nil},
+		{`"3"`, Versions{3}},
+		{`"3", "4"`, Versions{3, 4}},
+		{` "3" ,"4",W/"5"`, Versions{3, 4}},
+		{`W/"3"`, Versions{}},
+		{`"abc", 4, "`, Versions{}},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("PUT", "/goals/1", nil)
+		if tt.header != "" {
+			r.Header.Set("If-Match", tt.header)
+		}
+
+		got := ifMatchVersions(r)
+		if (got == nil) != (tt.want == nil) || !slices.Equal(got, tt.want) {
+			t.Errorf("If-Match %q gave %#v, want %#v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestVersionsAllows(t *testing.T) {
+	if !Versions(nil).Allows(7) {
+		t.Error("nil Versions does not allow every version")
+	}
+	if (Versions{}).Allows(1) {
+		t.Error("empty Versions allows a version")
+	}
+	if v := (Versions{3, 4}); !v.Allows(4) || v.Allows(5) {
+		t.Errorf("%v allows the wrong versions", v)
+	}
+}
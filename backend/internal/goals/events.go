@@ -0,0 +1,50 @@
+package goals
+
+import (
+	"context"
+
+	"github.com/JoshPugli/grindhouse-api/internal/middleware"
+)
+
+// ChangeKind identifies the kind of mutation described by a Change.
+type ChangeKind string
+
+const (
+	GoalCreated     ChangeKind = "goal.created"
+	GoalUpdated     ChangeKind = "goal.updated"
+	GoalDeleted     ChangeKind = "goal.deleted"
+	InstanceUpdated ChangeKind = "instance.updated"
+	InstanceDeleted ChangeKind = "instance.deleted"
+)
+
+// Change describes a committed mutation to a goal or one of its daily
+// instances. Instance and Previous are only set for InstanceUpdated, where
+// Previous is the instance as it was before the mutation, and for
+// InstanceDeleted, where both are the instance as it was when deleted.
+type Change struct {
+	Kind     ChangeKind
+	UserID   string
+	Goal     *Goal
+	Instance *DailyGoalInstance
+	Previous *DailyGoalInstance
+}
+
+// Listener is notified after a Change has been written to the database.
+type Listener interface {
+	GoalChanged(ctx context.Context, change Change) error
+}
+
+// Listeners fans a Change out to every registered Listener. A failing
+// listener is logged and never fails the request that caused the change.
+// Listeners are not cancelled with ctx: the change is already committed by
+// the time they run, so a client disconnecting must not skip them.
+type Listeners []Listener
+
+func (ls Listeners) Notify(ctx context.Context, change Change) {
+	ctx = context.WithoutCancel(ctx)
+	for _, l := range ls {
+		if err := l.GoalChanged(ctx, change); err != nil {
+			middleware.GetLogger(ctx).Error("goal listener failed", "kind", change.Kind, "err", err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package goals
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
+
+// historyDays is how far back a goal's history goes when no start date is
+// given.
+const historyDays = 30
+
+// parseGoalFilter reads the goal list filters from the query string and adds
+// any problems to fields.
+func parseGoalFilter(r *http.Request, fields *apperr.Fields) GoalFilter {
+	query := r.URL.Query()
+	filter := GoalFilter{Status: GoalStatusActive}
+
+	switch goalType := GoalType(query.Get("goal_type")); goalType {
+	case "":
+	case GoalTypeBoolean, GoalTypeNumeric, GoalTypeDuration:
+		filter.GoalType = goalType
+	default:
+		fields.Add("goal_type", "must be one of boolean, numeric, duration")
+	}
+
+	switch status := GoalStatus(query.Get("status")); status {
+	case "":
+	case GoalStatusActive, GoalStatusArchived, GoalStatusAll:
+		filter.Status = status
+	default:
+		fields.Add("status", "must be one of active, archived, all")
+	}
+
+	filter.CreatedAfter = parseTimeParam(query.Get("created_after"), "created_after", fields)
+	filter.CreatedBefore = parseTimeParam(query.Get("created_before"), "created_before", fields)
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		fields.Add("created_before", "must be after created_after")
+	}
+
+	return filter
+}
+
+// parseInstanceFilter reads a goal history's date range and completed filter
+// from the query string and adds any problems to fields. The range defaults
+// to the 30 days up to now.
+func parseInstanceFilter(r *http.Request, now time.Time, fields *apperr.Fields) InstanceFilter {
+	query := r.URL.Query()
+	filter := InstanceFilter{EndDate: now}
+
+	if endDateStr := query.Get("endDate"); endDateStr != "" {
+		parsed, err := time.Parse(time.DateOnly, endDateStr)
+		if err != nil {
+			fields.Add("endDate", "must be a date in YYYY-MM-DD format")
+		} else {
+			filter.EndDate = parsed
+		}
+	}
+
+	filter.StartDate = filter.EndDate.AddDate(0, 0, -historyDays)
+	if startDateStr := query.Get("startDate"); startDateStr != "" {
+		parsed, err := time.Parse(time.DateOnly, startDateStr)
+		if err != nil {
+			fields.Add("startDate", "must be a date in YYYY-MM-DD format")
+		} else {
+			filter.StartDate = parsed
+		}
+	}
+
+	if completedStr := query.Get("completed"); completedStr != "" {
+		completed, err := strconv.ParseBool(completedStr)
+		if err != nil {
+			fields.Add("completed", "must be true or false")
+		} else {
+			filter.CompletedOnly = completed
+		}
+	}
+
+	return filter
+}
+
+// parseTimeParam parses an RFC 3339 time or a YYYY-MM-DD date, which means
+// midnight UTC. It returns nil if value is empty or invalid.
+func parseTimeParam(value, field string, fields *apperr.Fields) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+
+	fields.Add(field, "must be an RFC 3339 time or a date in YYYY-MM-DD format")
+	return nil
+}
@@ -2,19 +2,27 @@ package goals
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
 	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/pagination"
+	"github.com/google/uuid"
 )
 
 type Handlers struct {
-	goalRepo *Repository
+	goalRepo  GoalStore
+	clock     func() time.Time
+	listeners Listeners
 }
 
-func NewHandlers(goalRepo *Repository) *Handlers {
+func NewHandlers(goalRepo GoalStore, clock func() time.Time, listeners ...Listener) *Handlers {
 	return &Handlers{
-		goalRepo: goalRepo,
+		goalRepo:  goalRepo,
+		clock:     clock,
+		listeners: listeners,
 	}
 }
 
@@ -27,49 +35,44 @@ func NewHandlers(goalRepo *Repository) *Handlers {
 // @Security BearerAuth
 // @Param goal body CreateGoalRequest true "Goal data"
 // @Success 201 {object} Goal
-// @Failure 400 {string} string "Invalid JSON or validation error"
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 500 {string} string "Internal server error"
-// @Router /api/goals [post]
+// @Failure 400 {object} apperr.Problem "Invalid JSON or validation error"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /goals [post]
 func (h *Handlers) HandleCreateGoal(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
 	var req CreateGoalRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
-	if req.Title == "" {
-		http.Error(w, "Title is required", http.StatusBadRequest)
+	if err := req.Validate(); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	if req.GoalType == "" {
-		http.Error(w, "Goal type is required", http.StatusBadRequest)
-		return
+	if req.Visibility == "" {
+		req.Visibility = VisibilityPrivate
 	}
 
-	if req.GoalType != GoalTypeBoolean && req.GoalType != GoalTypeNumeric && req.GoalType != GoalTypeDuration {
-		http.Error(w, "Invalid goal type", http.StatusBadRequest)
-		return
+	if req.Difficulty == "" {
+		req.Difficulty = DifficultyMedium
 	}
 
-	goal, err := h.goalRepo.CreateGoal(userID, req)
+	goal, err := h.goalRepo.CreateGoal(r.Context(), userID, req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
+	h.listeners.Notify(r.Context(), Change{Kind: GoalCreated, UserID: userID, Goal: goal})
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(goal)
@@ -78,34 +81,51 @@ func (h *Handlers) HandleCreateGoal(w http.ResponseWriter, r *http.Request) {
 // HandleGetGoals godoc
 
 // @Summary Get user's goals
-// @Description Get all active goals for the authenticated user
+// @Description Get a page of the authenticated user's goals, active ones by default. Follow the Link header with rel="next" for the next page.
 // @Tags goals
 // @Produce json
 // @Security BearerAuth
+// @Param goal_type query string false "Only goals of this type" Enums(boolean, numeric, duration)
+// @Param status query string false "Goals to include (defaults to active)" Enums(active, archived, all)
+// @Param created_after query string false "Only goals created at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Only goals created before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param sort query string false "Sort field (defaults to created_at)" Enums(created_at, updated_at, title)
+// @Param order query string false "Sort order (defaults to desc)" Enums(asc, desc)
+// @Param limit query int false "Page size (defaults to 50, at most 200)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {array} Goal
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 500 {string} string "Internal server error"
-// @Router /api/goals [get]
+// @Header 200 {string} ETag "Entity tag of the response"
+// @Header 200 {string} Link "Link to the next page, if there is one"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page, if there is one"
+// @Success 304 "Not Modified"
+// @Failure 400 {object} apperr.Problem "Invalid filter or page parameters"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /goals [get]
 func (h *Handlers) HandleGetGoals(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
-	userID, ok := auth.GetUserIDFromContext(r.Context())
-	if !ok {
-		http.Error(w, "User not found in context", http.StatusUnauthorized)
+	var fields apperr.Fields
+	filter := parseGoalFilter(r, &fields)
+	page := pagination.Parse(r, &fields, GoalSorts, "created_at", "desc")
+	if err := fields.Err(); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	goals, err := h.goalRepo.GetGoalsByUserID(userID)
+	goals, next, err := h.goalRepo.GetGoalsByUserID(r.Context(), userID, filter, page)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(goals)
+	pagination.WriteNext(w, r, next)
+	writeJSONWithETag(w, r, goals)
 }
 
 // HandleGetGoalsToday godoc
@@ -114,30 +134,27 @@ func (h *Handlers) HandleGetGoals(w http.ResponseWriter, r *http.Request) {
 // @Tags goals
 // @Produce json
 // @Security BearerAuth
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {array} GoalWithTodayInstance
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 500 {string} string "Internal server error"
-// @Router /api/goals/today [get]
+// @Header 200 {string} ETag "Entity tag of the response"
+// @Success 304 "Not Modified"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /goals/today [get]
 func (h *Handlers) HandleGetGoalsToday(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
-	goals, err := h.goalRepo.GetGoalsWithTodayInstances(userID)
+	goals, err := h.goalRepo.GetGoalsWithTodayInstances(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(goals)
+	writeJSONWithETag(w, r, goals)
 }
 
 // HandleGetGoal godoc
@@ -146,37 +163,38 @@ func (h *Handlers) HandleGetGoalsToday(w http.ResponseWriter, r *http.Request) {
 // @Tags goals
 // @Produce json
 // @Security BearerAuth
-// @Param id path string true "Goal ID"
+// @Param goalId path string true "Goal ID"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} Goal
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 404 {string} string "Goal not found"
-// @Failure 500 {string} string "Internal server error"
-// @Router /api/goals/{id} [get]
+// @Header 200 {string} ETag "Goal version"
+// @Success 304 "Not Modified"
+// @Failure 400 {object} apperr.Problem "Invalid goal ID"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Goal not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /goals/{goalId} [get]
 func (h *Handlers) HandleGetGoal(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
-	goalID := r.URL.Path[len("/api/goals/"):]
-	if goalID == "" {
-		http.Error(w, "Goal ID is required", http.StatusBadRequest)
+	goalID := r.PathValue("goalId")
+	if _, err := uuid.Parse(goalID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid goal ID")
 		return
 	}
 
-	goal, err := h.goalRepo.GetGoalByID(goalID, userID)
+	goal, err := h.goalRepo.GetGoalByID(r.Context(), goalID, userID)
 	if err != nil {
-		if err.Error() == "goal not found" {
-			http.Error(w, "Goal not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(goal.Version))
+	if noneMatch(r, versionETag(goal.Version)) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
@@ -191,48 +209,56 @@ func (h *Handlers) HandleGetGoal(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param id path string true "Goal ID"
+// @Param goalId path string true "Goal ID"
 // @Param goal body UpdateGoalRequest true "Updated goal data"
+// @Param If-Match header string false "ETag of the version being modified"
 // @Success 200 {object} Goal
-// @Failure 400 {string} string "Invalid JSON"
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 404 {string} string "Goal not found"
-// @Failure 500 {string} string "Internal server error"
-// @Router /api/goals/{id} [put]
+// @Header 200 {string} ETag "New goal version"
+// @Failure 400 {object} apperr.Problem "Invalid goal ID, JSON or validation error"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Goal not found"
+// @Failure 409 {object} apperr.Problem "Modified concurrently without If-Match"
+// @Failure 412 {object} apperr.Problem "If-Match does not match the current version"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /goals/{goalId} [put]
 func (h *Handlers) HandleUpdateGoal(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
-	goalID := r.URL.Path[len("/api/goals/"):]
-	if goalID == "" {
-		http.Error(w, "Goal ID is required", http.StatusBadRequest)
+	goalID := r.PathValue("goalId")
+	if _, err := uuid.Parse(goalID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid goal ID")
 		return
 	}
 
 	var req UpdateGoalRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	goal, err := h.goalRepo.UpdateGoal(goalID, userID, req)
+	ifMatch := ifMatchVersions(r)
+	goal, err := h.goalRepo.UpdateGoal(r.Context(), goalID, userID, req, ifMatch)
 	if err != nil {
-		if err.Error() == "goal not found" {
-			http.Error(w, "Goal not found", http.StatusNotFound)
+		if errors.Is(err, ErrVersionMismatch) {
+			versionMismatch(w, r, ifMatch)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
+	h.listeners.Notify(r.Context(), Change{Kind: GoalUpdated, UserID: userID, Goal: goal})
+
+	w.Header().Set("ETag", versionETag(goal.Version))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(goal)
 }
@@ -242,40 +268,45 @@ func (h *Handlers) HandleUpdateGoal(w http.ResponseWriter, r *http.Request) {
 // @Description Soft delete a specific goal by ID for the authenticated user
 // @Tags goals
 // @Security BearerAuth
-// @Param id path string true "Goal ID"
+// @Param goalId path string true "Goal ID"
+// @Param If-Match header string false "ETag of the version being modified"
 // @Success 204 "No Content"
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 404 {string} string "Goal not found"
-// @Failure 500 {string} string "Internal server error"
-// @Router /api/goals/{id} [delete]
+// @Failure 400 {object} apperr.Problem "Invalid goal ID"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Goal not found"
+// @Failure 409 {object} apperr.Problem "Modified concurrently without If-Match"
+// @Failure 412 {object} apperr.Problem "If-Match does not match the current version"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /goals/{goalId} [delete]
 func (h *Handlers) HandleDeleteGoal(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
-	goalID := r.URL.Path[len("/api/goals/"):]
-	if goalID == "" {
-		http.Error(w, "Goal ID is required", http.StatusBadRequest)
+	goalID := r.PathValue("goalId")
+	if _, err := uuid.Parse(goalID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid goal ID")
 		return
 	}
 
-	err := h.goalRepo.DeleteGoal(goalID, userID)
+	ifMatch := ifMatchVersions(r)
+	err := h.goalRepo.DeleteGoal(r.Context(), goalID, userID, ifMatch)
 	if err != nil {
-		if err.Error() == "goal not found" {
-			http.Error(w, "Goal not found", http.StatusNotFound)
+		if errors.Is(err, ErrVersionMismatch) {
+			versionMismatch(w, r, ifMatch)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
+	// Deletion is soft, so the goal can still be read back for listeners.
+	if goal, err := h.goalRepo.GetGoalByID(r.Context(), goalID, userID); err == nil {
+		h.listeners.Notify(r.Context(), Change{Kind: GoalDeleted, UserID: userID, Goal: goal})
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -289,143 +320,135 @@ func (h *Handlers) HandleDeleteGoal(w http.ResponseWriter, r *http.Request) {
 // @Param goalId path string true "Goal ID"
 // @Param date query string false "Date (YYYY-MM-DD format, defaults to today)"
 // @Param instance body UpdateDailyInstanceRequest true "Daily instance data"
+// @Param If-Match header string false "ETag of the version being modified"
 // @Success 200 {object} DailyGoalInstance
-// @Failure 400 {string} string "Invalid JSON or date format"
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 404 {string} string "Goal not found"
-// @Failure 500 {string} string "Internal server error"
-// @Router /api/goals/{goalId}/daily [put]
+// @Header 200 {string} ETag "New instance version"
+// @Failure 400 {object} apperr.Problem "Invalid goal ID, JSON, date format or validation error"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Goal not found"
+// @Failure 409 {object} apperr.Problem "Modified concurrently without If-Match"
+// @Failure 412 {object} apperr.Problem "If-Match does not match the current version"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /goals/{goalId}/daily [put]
 func (h *Handlers) HandleUpdateDailyInstance(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
-	goalID := r.URL.Path[len("/api/goals/"):]
-	goalID = goalID[:len(goalID)-len("/daily")]
-	if goalID == "" {
-		http.Error(w, "Goal ID is required", http.StatusBadRequest)
+	goalID := r.PathValue("goalId")
+	if _, err := uuid.Parse(goalID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid goal ID")
 		return
 	}
 
 	dateStr := r.URL.Query().Get("date")
 	var date time.Time
 	if dateStr == "" {
-		date = time.Now()
+		date = h.clock()
 	} else {
 		var err error
 		date, err = time.Parse("2006-01-02", dateStr)
 		if err != nil {
-			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid date format, use YYYY-MM-DD")
 			return
 		}
 	}
 
-	instance, err := h.goalRepo.GetOrCreateDailyInstance(goalID, userID, date)
-	if err != nil {
-		if err.Error() == "goal not found" {
-			http.Error(w, "Goal not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	var req UpdateDailyInstanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
-	var req UpdateDailyInstanceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := req.Validate(); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	updatedInstance, err := h.goalRepo.UpdateDailyInstance(instance.ID, userID, req)
+	ifMatch := ifMatchVersions(r)
+	upserted, err := h.goalRepo.UpsertDailyInstance(r.Context(), goalID, userID, date, req, ifMatch)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, ErrVersionMismatch) {
+			versionMismatch(w, r, ifMatch)
+			return
+		}
+		apperr.Write(w, r, err)
 		return
 	}
 
+	h.listeners.Notify(r.Context(), Change{
+		Kind:     InstanceUpdated,
+		UserID:   userID,
+		Goal:     upserted.Goal,
+		Instance: upserted.Instance,
+		Previous: upserted.Previous,
+	})
+
+	w.Header().Set("ETag", versionETag(upserted.Instance.Version))
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedInstance)
+	json.NewEncoder(w).Encode(upserted.Instance)
 }
 
 // HandleGetGoalHistory godoc
 // @Summary Get goal history
-// @Description Get daily instances for a goal within a date range
+// @Description Get a page of a goal's daily instances within a date range. Follow the Link header with rel="next" for the next page.
 // @Tags goals
 // @Produce json
 // @Security BearerAuth
 // @Param goalId path string true "Goal ID"
-// @Param startDate query string false "Start date (YYYY-MM-DD format, defaults to 30 days ago)"
+// @Param startDate query string false "Start date (YYYY-MM-DD format, defaults to 30 days before endDate)"
 // @Param endDate query string false "End date (YYYY-MM-DD format, defaults to today)"
+// @Param completed query bool false "Only completed days"
+// @Param sort query string false "Sort field (defaults to date)" Enums(date)
+// @Param order query string false "Sort order (defaults to desc)" Enums(asc, desc)
+// @Param limit query int false "Page size (defaults to 50, at most 200)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {array} DailyGoalInstance
-// @Failure 400 {string} string "Invalid date format"
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 404 {string} string "Goal not found"
-// @Failure 500 {string} string "Internal server error"
-// @Router /api/goals/{goalId}/history [get]
+// @Header 200 {string} ETag "Entity tag of the response"
+// @Header 200 {string} Link "Link to the next page, if there is one"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page, if there is one"
+// @Success 304 "Not Modified"
+// @Failure 400 {object} apperr.Problem "Invalid goal ID, filter or page parameters"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Goal not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /goals/{goalId}/history [get]
 func (h *Handlers) HandleGetGoalHistory(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
-	goalID := r.URL.Path[len("/api/goals/"):]
-	goalID = goalID[:len(goalID)-len("/history")]
-	if goalID == "" {
-		http.Error(w, "Goal ID is required", http.StatusBadRequest)
+	goalID := r.PathValue("goalId")
+	if _, err := uuid.Parse(goalID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid goal ID")
 		return
 	}
 
-	_, err := h.goalRepo.GetGoalByID(goalID, userID)
+	_, err := h.goalRepo.GetGoalByID(r.Context(), goalID, userID)
 	if err != nil {
-		if err.Error() == "goal not found" {
-			http.Error(w, "Goal not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
-	startDateStr := r.URL.Query().Get("startDate")
-	endDateStr := r.URL.Query().Get("endDate")
-
-	endDate := time.Now()
-	if endDateStr != "" {
-		parsed, err := time.Parse("2006-01-02", endDateStr)
-		if err != nil {
-			http.Error(w, "Invalid end date format, use YYYY-MM-DD", http.StatusBadRequest)
-			return
-		}
-		endDate = parsed
-	}
-
-	startDate := endDate.AddDate(0, 0, -30)
-	if startDateStr != "" {
-		parsed, err := time.Parse("2006-01-02", startDateStr)
-		if err != nil {
-			http.Error(w, "Invalid start date format, use YYYY-MM-DD", http.StatusBadRequest)
-			return
-		}
-		startDate = parsed
+	var fields apperr.Fields
+	filter := parseInstanceFilter(r, h.clock(), &fields)
+	page := pagination.Parse(r, &fields, InstanceSorts, "date", "desc")
+	if err := fields.Err(); err != nil {
+		apperr.Write(w, r, err)
+		return
 	}
 
-	instances, err := h.goalRepo.GetDailyInstancesByGoal(goalID, userID, startDate, endDate)
+	instances, next, err := h.goalRepo.GetDailyInstancesByGoal(r.Context(), goalID, userID, filter, page)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(instances)
-}
\ No newline at end of file
+	pagination.WriteNext(w, r, next)
+	writeJSONWithETag(w, r, instances)
+}
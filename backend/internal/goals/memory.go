@@ -0,0 +1,407 @@
+package goals
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/pagination"
+)
+
+// MemoryStore is a GoalStore that keeps goals in memory, for tests and for
+// running the server without a database. It is safe for concurrent use.
+//
+// It mirrors the PostgreSQL schema: timestamps have microsecond precision,
+// every update bumps the row's version as the bump_version trigger does,
+// and each goal has at most one instance per date. Titles sort by byte
+// value, which matches the database collation only for ASCII.
+type MemoryStore struct {
+	mu        sync.Mutex
+	goals     map[string]*Goal
+	instances map[string]*DailyGoalInstance
+	clock     func() time.Time
+}
+
+func NewMemoryStore(clock func() time.Time) *MemoryStore {
+	return &MemoryStore{
+		goals:     make(map[string]*Goal),
+		instances: make(map[string]*DailyGoalInstance),
+		clock:     clock,
+	}
+}
+
+func (s *MemoryStore) CreateGoal(ctx context.Context, userID string, req CreateGoalRequest) (*Goal, error) {
+	now := s.now()
+	goal := &Goal{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Title:       req.Title,
+		Description: clonePtr(req.Description),
+		GoalType:    req.GoalType,
+		TargetValue: clonePtr(req.TargetValue),
+		Unit:        clonePtr(req.Unit),
+		Visibility:  req.Visibility,
+		Difficulty:  req.Difficulty,
+		IsActive:    true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Version:     1,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.goals[goal.ID] = goal
+	return cloneGoal(goal), nil
+}
+
+func (s *MemoryStore) GetGoalsByUserID(ctx context.Context, userID string, filter GoalFilter, page pagination.Page) ([]Goal, *pagination.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var goals []Goal
+	for _, goal := range s.goals {
+		switch {
+		case goal.UserID != userID:
+		case (filter.Status == GoalStatusActive || filter.Status == "") && !goal.IsActive:
+		case filter.Status == GoalStatusArchived && goal.IsActive:
+		case filter.GoalType != "" && goal.GoalType != filter.GoalType:
+		case filter.CreatedAfter != nil && goal.CreatedAt.Before(*filter.CreatedAfter):
+		case filter.CreatedBefore != nil && !goal.CreatedAt.Before(*filter.CreatedBefore):
+		default:
+			goals = append(goals, *cloneGoal(goal))
+		}
+	}
+
+	key := func(g Goal) (string, string) {
+		switch page.Sort {
+		case "updated_at":
+			return pagination.Timestamp(g.UpdatedAt), g.ID
+		case "title":
+			return g.Title, g.ID
+		}
+		return pagination.Timestamp(g.CreatedAt), g.ID
+	}
+	compare := func(a, b Goal) int {
+		switch page.Sort {
+		case "updated_at":
+			return cmp.Or(a.UpdatedAt.Compare(b.UpdatedAt), strings.Compare(a.ID, b.ID))
+		case "title":
+			return cmp.Or(strings.Compare(a.Title, b.Title), strings.Compare(a.ID, b.ID))
+		}
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.ID, b.ID))
+	}
+
+	goals = paginate(goals, page, compare, key)
+	goals, next := pagination.Trim(goals, page, key)
+	return goals, next, nil
+}
+
+func (s *MemoryStore) GetGoalByID(ctx context.Context, goalID, userID string) (*Goal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goal, err := s.ownedGoal(goalID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return cloneGoal(goal), nil
+}
+
+func (s *MemoryStore) UpdateGoal(ctx context.Context, goalID, userID string, req UpdateGoalRequest, expectedVersions Versions) (*Goal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goal, err := s.ownedGoal(goalID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !expectedVersions.Allows(goal.Version) {
+		return nil, ErrVersionMismatch
+	}
+
+	if err := req.ValidateFor(goal.GoalType); err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		goal.Title = *req.Title
+	}
+	if req.Description != nil {
+		goal.Description = clonePtr(req.Description)
+	}
+	if req.TargetValue != nil {
+		goal.TargetValue = clonePtr(req.TargetValue)
+	}
+	if req.Unit != nil {
+		goal.Unit = clonePtr(req.Unit)
+	}
+	if req.Visibility != nil {
+		goal.Visibility = *req.Visibility
+	}
+	if req.Difficulty != nil {
+		goal.Difficulty = *req.Difficulty
+	}
+	if req.IsActive != nil {
+		goal.IsActive = *req.IsActive
+	}
+	goal.UpdatedAt = s.now()
+	goal.Version++
+
+	return cloneGoal(goal), nil
+}
+
+func (s *MemoryStore) DeleteGoal(ctx context.Context, goalID, userID string, expectedVersions Versions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goal, err := s.ownedGoal(goalID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !expectedVersions.Allows(goal.Version) {
+		return ErrVersionMismatch
+	}
+
+	goal.IsActive = false
+	goal.UpdatedAt = s.now()
+	goal.Version++
+	return nil
+}
+
+func (s *MemoryStore) UpsertDailyInstance(ctx context.Context, goalID, userID string, date time.Time, req UpdateDailyInstanceRequest, expectedVersions Versions) (*UpsertedInstance, error) {
+	dateOnly := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goal, err := s.ownedGoal(goalID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := s.instanceOn(goalID, dateOnly)
+	if instance == nil {
+		if !expectedVersions.Allows(1) {
+			return nil, ErrVersionMismatch
+		}
+
+		instance = &DailyGoalInstance{
+			ID:          uuid.New().String(),
+			GoalID:      goal.ID,
+			UserID:      goal.UserID,
+			Date:        dateOnly,
+			TargetValue: clonePtr(goal.TargetValue),
+			CreatedAt:   s.now(),
+			Version:     1,
+		}
+		previous := cloneInstance(instance)
+
+		instance.CompletedValue = clonePtr(req.CompletedValue)
+		if req.IsCompleted != nil && *req.IsCompleted {
+			instance.IsCompleted = true
+			completedAt := s.now()
+			instance.CompletedAt = &completedAt
+		}
+		s.instances[instance.ID] = instance
+
+		return &UpsertedInstance{Goal: cloneGoal(goal), Instance: cloneInstance(instance), Previous: previous}, nil
+	}
+
+	if !expectedVersions.Allows(instance.Version) {
+		return nil, ErrVersionMismatch
+	}
+
+	previous := cloneInstance(instance)
+
+	if req.CompletedValue != nil {
+		instance.CompletedValue = clonePtr(req.CompletedValue)
+	}
+	if req.IsCompleted != nil {
+		instance.IsCompleted = *req.IsCompleted
+		switch {
+		case !*req.IsCompleted:
+			instance.CompletedAt = nil
+		case instance.CompletedAt == nil:
+			completedAt := s.now()
+			instance.CompletedAt = &completedAt
+		}
+	}
+	instance.Version++
+
+	return &UpsertedInstance{Goal: cloneGoal(goal), Instance: cloneInstance(instance), Previous: previous}, nil
+}
+
+func (s *MemoryStore) GetGoalsWithTodayInstances(ctx context.Context, userID string) ([]GoalWithTodayInstance, error) {
+	today := s.clock().UTC()
+	dateOnly := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []GoalWithTodayInstance
+	for _, goal := range s.goals {
+		if goal.UserID != userID || !goal.IsActive {
+			continue
+		}
+		result := GoalWithTodayInstance{Goal: *cloneGoal(goal)}
+		if instance := s.instanceOn(goal.ID, dateOnly); instance != nil {
+			result.TodayInstance = cloneInstance(instance)
+		}
+		results = append(results, result)
+	}
+
+	slices.SortFunc(results, func(a, b GoalWithTodayInstance) int {
+		return cmp.Or(b.Goal.CreatedAt.Compare(a.Goal.CreatedAt), strings.Compare(b.Goal.ID, a.Goal.ID))
+	})
+	return results, nil
+}
+
+func (s *MemoryStore) GetDailyInstancesByGoal(ctx context.Context, goalID, userID string, filter InstanceFilter, page pagination.Page) ([]DailyGoalInstance, *pagination.Cursor, error) {
+	startDate := truncateDate(filter.StartDate)
+	endDate := truncateDate(filter.EndDate)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var instances []DailyGoalInstance
+	for _, instance := range s.instances {
+		switch {
+		case instance.GoalID != goalID || instance.UserID != userID:
+		case instance.Date.Before(startDate) || instance.Date.After(endDate):
+		case filter.CompletedOnly && !instance.IsCompleted:
+		default:
+			instances = append(instances, *cloneInstance(instance))
+		}
+	}
+
+	key := func(i DailyGoalInstance) (string, string) {
+		return i.Date.Format("2006-01-02"), i.ID
+	}
+	compare := func(a, b DailyGoalInstance) int {
+		return cmp.Or(a.Date.Compare(b.Date), strings.Compare(a.ID, b.ID))
+	}
+
+	instances = paginate(instances, page, compare, key)
+	instances, next := pagination.Trim(instances, page, key)
+	return instances, next, nil
+}
+
+func (s *MemoryStore) GetCurrentStreak(ctx context.Context, goalID string, date time.Time) (int, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	streak := 0
+	for {
+		instance := s.instanceOn(goalID, day)
+		if instance == nil || !instance.IsCompleted {
+			return streak, nil
+		}
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+}
+
+// ownedGoal returns the stored goal, not a copy, if it belongs to userID.
+func (s *MemoryStore) ownedGoal(goalID, userID string) (*Goal, error) {
+	goal, ok := s.goals[goalID]
+	if !ok || goal.UserID != userID {
+		return nil, apperr.NotFound("goal not found")
+	}
+	return goal, nil
+}
+
+// instanceOn returns the stored instance of a goal for a date, or nil.
+func (s *MemoryStore) instanceOn(goalID string, date time.Time) *DailyGoalInstance {
+	for _, instance := range s.instances {
+		if instance.GoalID == goalID && instance.Date.Equal(date) {
+			return instance
+		}
+	}
+	return nil
+}
+
+// paginate sorts items for page and drops those up to and including its
+// cursor, leaving one more item than the page holds when there are more,
+// as pagination.Query does in SQL. key must return what the cursor holds.
+func paginate[T any](items []T, page pagination.Page, compare func(a, b T) int, key func(T) (string, string)) []T {
+	if page.Desc {
+		slices.SortFunc(items, func(a, b T) int { return compare(b, a) })
+	} else {
+		slices.SortFunc(items, compare)
+	}
+
+	if page.After != nil {
+		// Items are in page order, so the cursor's position is the first
+		// item that sorts after it.
+		start := len(items)
+		for i, item := range items {
+			if afterCursor(item, page, key) {
+				start = i
+				break
+			}
+		}
+		items = items[start:]
+	}
+
+	if len(items) > page.Limit+1 {
+		items = items[:page.Limit+1]
+	}
+	return items
+}
+
+// afterCursor reports whether item comes after the page's cursor in page
+// order. The cursor only holds the formatted key, which sorts like the
+// value it was formatted from: timestamps and dates are fixed-width with
+// trailing zeros trimmed, and IDs are lower-case UUIDs.
+func afterCursor[T any](item T, page pagination.Page, key func(T) (string, string)) bool {
+	value, id := key(item)
+	c := cmp.Or(strings.Compare(value, page.After.Value), strings.Compare(id, page.After.ID))
+	if page.Desc {
+		return c < 0
+	}
+	return c > 0
+}
+
+// now returns the current time at the precision PostgreSQL stores.
+func (s *MemoryStore) now() time.Time {
+	return s.clock().UTC().Truncate(time.Microsecond)
+}
+
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func cloneGoal(goal *Goal) *Goal {
+	clone := *goal
+	clone.Description = clonePtr(goal.Description)
+	clone.TargetValue = clonePtr(goal.TargetValue)
+	clone.Unit = clonePtr(goal.Unit)
+	return &clone
+}
+
+func cloneInstance(instance *DailyGoalInstance) *DailyGoalInstance {
+	clone := *instance
+	clone.TargetValue = clonePtr(instance.TargetValue)
+	clone.CompletedValue = clonePtr(instance.CompletedValue)
+	clone.CompletedAt = clonePtr(instance.CompletedAt)
+	return &clone
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
@@ -10,17 +10,48 @@ const (
 	GoalTypeDuration GoalType = "duration"
 )
 
+// GoalVisibility controls who can see activity generated by a goal.
+type GoalVisibility string
+
+const (
+	VisibilityPrivate   GoalVisibility = "private"
+	VisibilityFollowers GoalVisibility = "followers"
+	VisibilityPublic    GoalVisibility = "public"
+)
+
+// Valid reports whether v is one of the known visibility levels.
+func (v GoalVisibility) Valid() bool {
+	return v == VisibilityPrivate || v == VisibilityFollowers || v == VisibilityPublic
+}
+
+// GoalDifficulty weights the experience awarded for completing a goal.
+type GoalDifficulty string
+
+const (
+	DifficultyEasy   GoalDifficulty = "easy"
+	DifficultyMedium GoalDifficulty = "medium"
+	DifficultyHard   GoalDifficulty = "hard"
+)
+
+// Valid reports whether d is one of the known difficulty levels.
+func (d GoalDifficulty) Valid() bool {
+	return d == DifficultyEasy || d == DifficultyMedium || d == DifficultyHard
+}
+
 type Goal struct {
-	ID           string     `json:"id" db:"id"`
-	UserID       string     `json:"user_id" db:"user_id"`
-	Title        string     `json:"title" db:"title"`
-	Description  *string    `json:"description" db:"description"`
-	GoalType     GoalType   `json:"goal_type" db:"goal_type"`
-	TargetValue  *float64   `json:"target_value" db:"target_value"`
-	Unit         *string    `json:"unit" db:"unit"`
-	IsActive     bool       `json:"is_active" db:"is_active"`
-	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	ID          string         `json:"id" db:"id"`
+	UserID      string         `json:"user_id" db:"user_id"`
+	Title       string         `json:"title" db:"title"`
+	Description *string        `json:"description" db:"description"`
+	GoalType    GoalType       `json:"goal_type" db:"goal_type"`
+	TargetValue *float64       `json:"target_value" db:"target_value"`
+	Unit        *string        `json:"unit" db:"unit"`
+	Visibility  GoalVisibility `json:"visibility" db:"visibility"`
+	Difficulty  GoalDifficulty `json:"difficulty" db:"difficulty"`
+	IsActive    bool           `json:"is_active" db:"is_active"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+	Version     int            `json:"version" db:"version"`
 }
 
 type DailyGoalInstance struct {
@@ -33,22 +64,27 @@ type DailyGoalInstance struct {
 	IsCompleted    bool       `json:"is_completed" db:"is_completed"`
 	CompletedAt    *time.Time `json:"completed_at" db:"completed_at"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	Version        int        `json:"version" db:"version"`
 }
 
 type CreateGoalRequest struct {
-	Title       string   `json:"title"`
-	Description *string  `json:"description"`
-	GoalType    GoalType `json:"goal_type"`
-	TargetValue *float64 `json:"target_value"`
-	Unit        *string  `json:"unit"`
+	Title       string         `json:"title"`
+	Description *string        `json:"description"`
+	GoalType    GoalType       `json:"goal_type"`
+	TargetValue *float64       `json:"target_value"`
+	Unit        *string        `json:"unit"`
+	Visibility  GoalVisibility `json:"visibility"`
+	Difficulty  GoalDifficulty `json:"difficulty"`
 }
 
 type UpdateGoalRequest struct {
-	Title       *string  `json:"title"`
-	Description *string  `json:"description"`
-	TargetValue *float64 `json:"target_value"`
-	Unit        *string  `json:"unit"`
-	IsActive    *bool    `json:"is_active"`
+	Title       *string         `json:"title"`
+	Description *string         `json:"description"`
+	TargetValue *float64        `json:"target_value"`
+	Unit        *string         `json:"unit"`
+	Visibility  *GoalVisibility `json:"visibility"`
+	Difficulty  *GoalDifficulty `json:"difficulty"`
+	IsActive    *bool           `json:"is_active"`
 }
 
 type UpdateDailyInstanceRequest struct {
@@ -59,4 +95,30 @@ type UpdateDailyInstanceRequest struct {
 type GoalWithTodayInstance struct {
 	Goal          Goal               `json:"goal"`
 	TodayInstance *DailyGoalInstance `json:"today_instance"`
-}
\ No newline at end of file
+}
+
+// GoalStatus selects goals by whether they have been archived. Deleting a
+// goal archives it.
+type GoalStatus string
+
+const (
+	GoalStatusActive   GoalStatus = "active"
+	GoalStatusArchived GoalStatus = "archived"
+	GoalStatusAll      GoalStatus = "all"
+)
+
+// GoalFilter narrows a goal list. Zero fields do not filter.
+type GoalFilter struct {
+	GoalType      GoalType
+	Status        GoalStatus
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// InstanceFilter narrows a goal's history to a date range, inclusive, and
+// optionally to completed days.
+type InstanceFilter struct {
+	StartDate     time.Time
+	EndDate       time.Time
+	CompletedOnly bool
+}
@@ -1,22 +1,29 @@
 package goals
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/pagination"
 )
 
 type Repository struct {
-	db *sql.DB
+	db    *sql.DB
+	clock func() time.Time
 }
 
-func NewRepository(db *sql.DB) *Repository {
-	return &Repository{db: db}
+func NewRepository(db *sql.DB, clock func() time.Time) *Repository {
+	return &Repository{db: db, clock: clock}
 }
 
-func (r *Repository) CreateGoal(userID string, req CreateGoalRequest) (*Goal, error) {
+func (r *Repository) CreateGoal(ctx context.Context, userID string, req CreateGoalRequest) (*Goal, error) {
+	now := r.clock()
 	goal := &Goal{
 		ID:          uuid.New().String(),
 		UserID:      userID,
@@ -25,16 +32,19 @@ func (r *Repository) CreateGoal(userID string, req CreateGoalRequest) (*Goal, er
 		GoalType:    req.GoalType,
 		TargetValue: req.TargetValue,
 		Unit:        req.Unit,
+		Visibility:  req.Visibility,
+		Difficulty:  req.Difficulty,
 		IsActive:    true,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Version:     1,
 	}
 
 	query := `
-		INSERT INTO goals (id, user_id, title, description, goal_type, target_value, unit, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO goals (id, user_id, title, description, goal_type, target_value, unit, visibility, difficulty, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
-	_, err := r.db.Exec(query, goal.ID, goal.UserID, goal.Title, goal.Description, goal.GoalType, goal.TargetValue, goal.Unit, goal.IsActive, goal.CreatedAt, goal.UpdatedAt)
+	_, err := r.db.ExecContext(ctx, query, goal.ID, goal.UserID, goal.Title, goal.Description, goal.GoalType, goal.TargetValue, goal.Unit, goal.Visibility, goal.Difficulty, goal.IsActive, goal.CreatedAt, goal.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create goal: %w", err)
 	}
@@ -42,43 +52,80 @@ func (r *Repository) CreateGoal(userID string, req CreateGoalRequest) (*Goal, er
 	return goal, nil
 }
 
-func (r *Repository) GetGoalsByUserID(userID string) ([]Goal, error) {
-	query := `
-		SELECT id, user_id, title, description, goal_type, target_value, unit, is_active, created_at, updated_at
-		FROM goals
-		WHERE user_id = $1 AND is_active = true
-		ORDER BY created_at DESC
-	`
-	rows, err := r.db.Query(query, userID)
+// GoalSorts are the orders goal lists can be requested in.
+var GoalSorts = pagination.Sorts{
+	"created_at": {Column: "created_at", Type: "timestamp"},
+	"updated_at": {Column: "updated_at", Type: "timestamp"},
+	"title":      {Column: "title", Type: "text"},
+}
+
+// GetGoalsByUserID returns a page of the user's goals matching filter and
+// the cursor of the next page, which is nil on the last page.
+func (r *Repository) GetGoalsByUserID(ctx context.Context, userID string, filter GoalFilter, page pagination.Page) ([]Goal, *pagination.Cursor, error) {
+	var q pagination.Query
+	q.Where("user_id = ?", userID)
+	switch filter.Status {
+	case GoalStatusActive, "":
+		q.Where("is_active = true")
+	case GoalStatusArchived:
+		q.Where("is_active = false")
+	}
+	if filter.GoalType != "" {
+		q.Where("goal_type = ?", filter.GoalType)
+	}
+	if filter.CreatedAfter != nil {
+		q.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		q.Where("created_at < ?", *filter.CreatedBefore)
+	}
+
+	query, args := q.Build(`
+		SELECT id, user_id, title, description, goal_type, target_value, unit, visibility, difficulty, is_active, created_at, updated_at, version
+		FROM goals`, page, GoalSorts, "id")
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get goals: %w", err)
+		return nil, nil, fmt.Errorf("failed to get goals: %w", err)
 	}
 	defer rows.Close()
 
 	var goals []Goal
 	for rows.Next() {
 		var goal Goal
-		err := rows.Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.GoalType, &goal.TargetValue, &goal.Unit, &goal.IsActive, &goal.CreatedAt, &goal.UpdatedAt)
+		err := rows.Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.GoalType, &goal.TargetValue, &goal.Unit, &goal.Visibility, &goal.Difficulty, &goal.IsActive, &goal.CreatedAt, &goal.UpdatedAt, &goal.Version)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan goal: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan goal: %w", err)
 		}
 		goals = append(goals, goal)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get goals: %w", err)
+	}
 
-	return goals, nil
+	goals, next := pagination.Trim(goals, page, func(g Goal) (string, string) {
+		switch page.Sort {
+		case "updated_at":
+			return pagination.Timestamp(g.UpdatedAt), g.ID
+		case "title":
+			return g.Title, g.ID
+		}
+		return pagination.Timestamp(g.CreatedAt), g.ID
+	})
+	return goals, next, nil
 }
 
-func (r *Repository) GetGoalByID(goalID, userID string) (*Goal, error) {
+func (r *Repository) GetGoalByID(ctx context.Context, goalID, userID string) (*Goal, error) {
 	query := `
-		SELECT id, user_id, title, description, goal_type, target_value, unit, is_active, created_at, updated_at
+		SELECT id, user_id, title, description, goal_type, target_value, unit, visibility, difficulty, is_active, created_at, updated_at, version
 		FROM goals
 		WHERE id = $1 AND user_id = $2
 	`
 	var goal Goal
-	err := r.db.QueryRow(query, goalID, userID).Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.GoalType, &goal.TargetValue, &goal.Unit, &goal.IsActive, &goal.CreatedAt, &goal.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, query, goalID, userID).Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.GoalType, &goal.TargetValue, &goal.Unit, &goal.Visibility, &goal.Difficulty, &goal.IsActive, &goal.CreatedAt, &goal.UpdatedAt, &goal.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("goal not found")
+			return nil, apperr.NotFound("goal not found")
 		}
 		return nil, fmt.Errorf("failed to get goal: %w", err)
 	}
@@ -86,12 +133,24 @@ func (r *Repository) GetGoalByID(goalID, userID string) (*Goal, error) {
 	return &goal, nil
 }
 
-func (r *Repository) UpdateGoal(goalID, userID string, req UpdateGoalRequest) (*Goal, error) {
-	goal, err := r.GetGoalByID(goalID, userID)
+// UpdateGoal applies req to a goal. If expectedVersions is set the update
+// only happens while the goal is at one of those versions. Either way the write
+// is conditional on the version that was read, so a concurrent update is
+// reported as "version mismatch" instead of being silently overwritten.
+func (r *Repository) UpdateGoal(ctx context.Context, goalID, userID string, req UpdateGoalRequest, expectedVersions Versions) (*Goal, error) {
+	goal, err := r.GetGoalByID(ctx, goalID, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	if !expectedVersions.Allows(goal.Version) {
+		return nil, ErrVersionMismatch
+	}
+
+	if err := req.ValidateFor(goal.GoalType); err != nil {
+		return nil, err
+	}
+
 	if req.Title != nil {
 		goal.Title = *req.Title
 	}
@@ -104,27 +163,39 @@ func (r *Repository) UpdateGoal(goalID, userID string, req UpdateGoalRequest) (*
 	if req.Unit != nil {
 		goal.Unit = req.Unit
 	}
+	if req.Visibility != nil {
+		goal.Visibility = *req.Visibility
+	}
+	if req.Difficulty != nil {
+		goal.Difficulty = *req.Difficulty
+	}
 	if req.IsActive != nil {
 		goal.IsActive = *req.IsActive
 	}
-	goal.UpdatedAt = time.Now()
+	goal.UpdatedAt = r.clock()
 
 	query := `
 		UPDATE goals 
-		SET title = $1, description = $2, target_value = $3, unit = $4, is_active = $5, updated_at = $6
-		WHERE id = $7 AND user_id = $8
+		SET title = $1, description = $2, target_value = $3, unit = $4, visibility = $5, difficulty = $6, is_active = $7, updated_at = $8
+		WHERE id = $9 AND user_id = $10 AND version = $11
+		RETURNING version
 	`
-	_, err = r.db.Exec(query, goal.Title, goal.Description, goal.TargetValue, goal.Unit, goal.IsActive, goal.UpdatedAt, goalID, userID)
+	err = r.db.QueryRowContext(ctx, query, goal.Title, goal.Description, goal.TargetValue, goal.Unit, goal.Visibility, goal.Difficulty, goal.IsActive, goal.UpdatedAt, goalID, userID, goal.Version).Scan(&goal.Version)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVersionMismatch
+		}
 		return nil, fmt.Errorf("failed to update goal: %w", err)
 	}
 
 	return goal, nil
 }
 
-func (r *Repository) DeleteGoal(goalID, userID string) error {
-	query := `UPDATE goals SET is_active = false WHERE id = $1 AND user_id = $2`
-	result, err := r.db.Exec(query, goalID, userID)
+// DeleteGoal soft deletes a goal, only while it is at one of
+// expectedVersions if that is set.
+func (r *Repository) DeleteGoal(ctx context.Context, goalID, userID string, expectedVersions Versions) error {
+	query := `UPDATE goals SET is_active = false WHERE id = $1 AND user_id = $2 AND ($3::int[] IS NULL OR version = ANY($3::int[]))`
+	result, err := r.db.ExecContext(ctx, query, goalID, userID, pq.Array(expectedVersions))
 	if err != nil {
 		return fmt.Errorf("failed to delete goal: %w", err)
 	}
@@ -135,123 +206,179 @@ func (r *Repository) DeleteGoal(goalID, userID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("goal not found")
+		if _, err := r.GetGoalByID(ctx, goalID, userID); err != nil {
+			return err
+		}
+		return ErrVersionMismatch
 	}
 
 	return nil
 }
 
-func (r *Repository) GetOrCreateDailyInstance(goalID, userID string, date time.Time) (*DailyGoalInstance, error) {
-	dateOnly := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
-	
-	query := `
-		SELECT id, goal_id, user_id, date, target_value, completed_value, is_completed, completed_at, created_at
-		FROM daily_goal_instances
-		WHERE goal_id = $1 AND user_id = $2 AND date = $3
-	`
-	var instance DailyGoalInstance
-	err := r.db.QueryRow(query, goalID, userID, dateOnly).Scan(
-		&instance.ID, &instance.GoalID, &instance.UserID, &instance.Date,
-		&instance.TargetValue, &instance.CompletedValue, &instance.IsCompleted,
-		&instance.CompletedAt, &instance.CreatedAt)
-	
-	if err == nil {
-		return &instance, nil
-	}
-	
-	if err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to get daily instance: %w", err)
+// UpsertedInstance is the result of UpsertDailyInstance: the instance after
+// the write, the goal it belongs to, and the instance as it was before.
+// Previous is the empty initial state when the instance was just created.
+type UpsertedInstance struct {
+	Goal     *Goal
+	Instance *DailyGoalInstance
+	Previous *DailyGoalInstance
+}
+
+// UpsertDailyInstance applies req to the goal's instance for date, creating
+// the instance if it does not exist, in a single INSERT ... ON CONFLICT
+// statement. Concurrent requests for the same goal and date serialise on the
+// row instead of racing to insert it. If expectedVersions is set the write
+// only happens while the instance is at one of those versions; an instance
+// that does not exist yet is at version 1.
+func (r *Repository) UpsertDailyInstance(ctx context.Context, goalID, userID string, date time.Time, req UpdateDailyInstanceRequest, expectedVersions Versions) (*UpsertedInstance, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	goal, err := r.GetGoalByID(goalID, userID)
+	upserted, err := r.upsertDailyInstance(ctx, tx, goalID, userID, date, req, false, expectedVersions)
 	if err != nil {
 		return nil, err
 	}
 
-	instance = DailyGoalInstance{
-		ID:             uuid.New().String(),
-		GoalID:         goalID,
-		UserID:         userID,
-		Date:           dateOnly,
-		TargetValue:    goal.TargetValue,
-		CompletedValue: nil,
-		IsCompleted:    false,
-		CompletedAt:    nil,
-		CreatedAt:      time.Now(),
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit daily instance: %w", err)
 	}
 
-	insertQuery := `
-		INSERT INTO daily_goal_instances (id, goal_id, user_id, date, target_value, completed_value, is_completed, completed_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	`
-	_, err = r.db.Exec(insertQuery, instance.ID, instance.GoalID, instance.UserID, instance.Date, 
-		instance.TargetValue, instance.CompletedValue, instance.IsCompleted, instance.CompletedAt, instance.CreatedAt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create daily instance: %w", err)
-	}
+	return upserted, nil
+}
 
-	return &instance, nil
+// UpsertDailyInstanceTx is UpsertDailyInstance within tx, for callers that
+// record something else atomically with the write. The caller commits.
+func (r *Repository) UpsertDailyInstanceTx(ctx context.Context, tx *sql.Tx, goalID, userID string, date time.Time, req UpdateDailyInstanceRequest, expectedVersions Versions) (*UpsertedInstance, error) {
+	return r.upsertDailyInstance(ctx, tx, goalID, userID, date, req, false, expectedVersions)
 }
 
-func (r *Repository) UpdateDailyInstance(instanceID, userID string, req UpdateDailyInstanceRequest) (*DailyGoalInstance, error) {
+// AddToDailyInstanceTx adds value to the completed value of the goal's
+// instance for date within tx, creating the instance if it does not exist,
+// with the statement UpsertDailyInstance uses. The addition is made to the
+// row as it is when the write takes its lock, so concurrent additions are
+// all counted. A total too large for the column is a validation error. The
+// caller commits.
+func (r *Repository) AddToDailyInstanceTx(ctx context.Context, tx *sql.Tx, goalID, userID string, date time.Time, value float64) (*UpsertedInstance, error) {
+	upserted, err := r.upsertDailyInstance(ctx, tx, goalID, userID, date, UpdateDailyInstanceRequest{CompletedValue: &value}, true, nil)
+	return upserted, apperr.FromOutOfRange(err, apperr.FieldError{Field: "completed_value", Message: "must not total more than 99999999.99 for the day"})
+}
+
+// upsertDailyInstance writes req to the instance in tx. If add is set,
+// req.CompletedValue is added to the instance's completed value instead of
+// replacing it.
+func (r *Repository) upsertDailyInstance(ctx context.Context, tx *sql.Tx, goalID, userID string, date time.Time, req UpdateDailyInstanceRequest, add bool, expectedVersions Versions) (*UpsertedInstance, error) {
+	dateOnly := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	// previous locks and reads the existing row, if any, from the statement's
+	// snapshot. If another transaction inserts the row after that snapshot,
+	// the upsert waits for it and updates its row, and previous is empty as
+	// though this request had created it.
 	query := `
-		SELECT id, goal_id, user_id, date, target_value, completed_value, is_completed, completed_at, created_at
-		FROM daily_goal_instances
-		WHERE id = $1 AND user_id = $2
+		WITH previous AS (
+			SELECT id, completed_value, is_completed, completed_at, version
+			FROM daily_goal_instances
+			WHERE goal_id = $1 AND user_id = $2 AND date = $3
+			FOR UPDATE
+		), upserted AS (
+			INSERT INTO daily_goal_instances AS i (goal_id, user_id, date, target_value, completed_value, is_completed, completed_at)
+			SELECT g.id, g.user_id, $3, g.target_value, $4::numeric, COALESCE($5::boolean, false),
+			       CASE WHEN $5::boolean THEN $6::timestamp END
+			FROM goals g
+			WHERE g.id = $1 AND g.user_id = $2
+			ON CONFLICT (goal_id, date) DO UPDATE
+			SET completed_value = CASE
+			        WHEN $8::boolean THEN COALESCE(i.completed_value, 0) + $4::numeric
+			        ELSE COALESCE($4::numeric, i.completed_value)
+			    END,
+			    is_completed = COALESCE($5::boolean, i.is_completed),
+			    completed_at = CASE
+			        WHEN $5::boolean IS NULL THEN i.completed_at
+			        WHEN $5::boolean THEN COALESCE(i.completed_at, $6::timestamp)
+			    END
+			WHERE $7::int[] IS NULL OR i.version = ANY($7::int[])
+			RETURNING i.id, i.goal_id, i.user_id, i.date, i.target_value, i.completed_value, i.is_completed,
+			          i.completed_at, i.created_at, i.version, (i.xmax = 0) AS inserted
+		)
+		SELECT u.id, u.goal_id, u.user_id, u.date, u.target_value, u.completed_value, u.is_completed,
+		       u.completed_at, u.created_at, u.version, u.inserted,
+		       p.id, p.completed_value, p.is_completed, p.completed_at, p.version,
+		       g.id, g.user_id, g.title, g.description, g.goal_type, g.target_value, g.unit, g.visibility, g.difficulty,
+		       g.is_active, g.created_at, g.updated_at, g.version
+		FROM upserted u
+		JOIN goals g ON g.id = u.goal_id
+		LEFT JOIN previous p ON true
 	`
 	var instance DailyGoalInstance
-	err := r.db.QueryRow(query, instanceID, userID).Scan(
-		&instance.ID, &instance.GoalID, &instance.UserID, &instance.Date,
-		&instance.TargetValue, &instance.CompletedValue, &instance.IsCompleted,
-		&instance.CompletedAt, &instance.CreatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("daily instance not found")
+	var goal Goal
+	var inserted bool
+	var previousID sql.NullString
+	var previousCompletedValue sql.NullFloat64
+	var previousIsCompleted sql.NullBool
+	var previousCompletedAt sql.NullTime
+	var previousVersion sql.NullInt64
+	err := tx.QueryRowContext(ctx, query, goalID, userID, dateOnly, req.CompletedValue, req.IsCompleted, r.clock(), pq.Array(expectedVersions), add).Scan(
+		&instance.ID, &instance.GoalID, &instance.UserID, &instance.Date, &instance.TargetValue,
+		&instance.CompletedValue, &instance.IsCompleted, &instance.CompletedAt, &instance.CreatedAt,
+		&instance.Version, &inserted,
+		&previousID, &previousCompletedValue, &previousIsCompleted, &previousCompletedAt, &previousVersion,
+		&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.GoalType, &goal.TargetValue, &goal.Unit,
+		&goal.Visibility, &goal.Difficulty, &goal.IsActive, &goal.CreatedAt, &goal.UpdatedAt, &goal.Version)
+	if err == sql.ErrNoRows {
+		// Either the goal is not the user's, or the version check failed.
+		if _, err := r.GetGoalByID(ctx, goalID, userID); err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("failed to get daily instance: %w", err)
+		return nil, ErrVersionMismatch
 	}
-
-	if req.CompletedValue != nil {
-		instance.CompletedValue = req.CompletedValue
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert daily instance: %w", err)
 	}
-	if req.IsCompleted != nil {
-		instance.IsCompleted = *req.IsCompleted
-		if *req.IsCompleted && instance.CompletedAt == nil {
-			now := time.Now()
-			instance.CompletedAt = &now
-		} else if !*req.IsCompleted {
-			instance.CompletedAt = nil
-		}
+
+	if inserted && !expectedVersions.Allows(1) {
+		return nil, ErrVersionMismatch
 	}
 
-	updateQuery := `
-		UPDATE daily_goal_instances 
-		SET completed_value = $1, is_completed = $2, completed_at = $3
-		WHERE id = $4 AND user_id = $5
-	`
-	_, err = r.db.Exec(updateQuery, instance.CompletedValue, instance.IsCompleted, instance.CompletedAt, instanceID, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update daily instance: %w", err)
+	previous := DailyGoalInstance{
+		ID:          instance.ID,
+		GoalID:      instance.GoalID,
+		UserID:      instance.UserID,
+		Date:        instance.Date,
+		TargetValue: instance.TargetValue,
+		CreatedAt:   instance.CreatedAt,
+		Version:     1,
+	}
+	if previousID.Valid {
+		if previousCompletedValue.Valid {
+			previous.CompletedValue = &previousCompletedValue.Float64
+		}
+		previous.IsCompleted = previousIsCompleted.Bool
+		if previousCompletedAt.Valid {
+			previous.CompletedAt = &previousCompletedAt.Time
+		}
+		previous.Version = int(previousVersion.Int64)
 	}
 
-	return &instance, nil
+	return &UpsertedInstance{Goal: &goal, Instance: &instance, Previous: &previous}, nil
 }
 
-func (r *Repository) GetGoalsWithTodayInstances(userID string) ([]GoalWithTodayInstance, error) {
-	today := time.Now().UTC()
+func (r *Repository) GetGoalsWithTodayInstances(ctx context.Context, userID string) ([]GoalWithTodayInstance, error) {
+	today := r.clock().UTC()
 	dateOnly := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
 
 	query := `
 		SELECT 
-			g.id, g.user_id, g.title, g.description, g.goal_type, g.target_value, g.unit, g.is_active, g.created_at, g.updated_at,
-			dgi.id, dgi.goal_id, dgi.user_id, dgi.date, dgi.target_value, dgi.completed_value, dgi.is_completed, dgi.completed_at, dgi.created_at
+			g.id, g.user_id, g.title, g.description, g.goal_type, g.target_value, g.unit, g.visibility, g.difficulty, g.is_active, g.created_at, g.updated_at, g.version,
+			dgi.id, dgi.goal_id, dgi.user_id, dgi.date, dgi.target_value, dgi.completed_value, dgi.is_completed, dgi.completed_at, dgi.created_at, dgi.version
 		FROM goals g
 		LEFT JOIN daily_goal_instances dgi ON g.id = dgi.goal_id AND dgi.date = $2
 		WHERE g.user_id = $1 AND g.is_active = true
 		ORDER BY g.created_at DESC
 	`
 
-	rows, err := r.db.Query(query, userID, dateOnly)
+	rows, err := r.db.QueryContext(ctx, query, userID, dateOnly)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get goals with today instances: %w", err)
 	}
@@ -265,10 +392,11 @@ func (r *Repository) GetGoalsWithTodayInstances(userID string) ([]GoalWithTodayI
 		var instanceDate, instanceCompletedAt sql.NullTime
 		var instanceTargetValue, instanceCompletedValue sql.NullFloat64
 		var instanceIsCompleted sql.NullBool
+		var instanceVersion sql.NullInt64
 
 		err := rows.Scan(
-			&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.GoalType, &goal.TargetValue, &goal.Unit, &goal.IsActive, &goal.CreatedAt, &goal.UpdatedAt,
-			&instanceID, &instanceGoalID, &instanceUserID, &instanceDate, &instanceTargetValue, &instanceCompletedValue, &instanceIsCompleted, &instanceCompletedAt, &instanceCreatedAt,
+			&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.GoalType, &goal.TargetValue, &goal.Unit, &goal.Visibility, &goal.Difficulty, &goal.IsActive, &goal.CreatedAt, &goal.UpdatedAt, &goal.Version,
+			&instanceID, &instanceGoalID, &instanceUserID, &instanceDate, &instanceTargetValue, &instanceCompletedValue, &instanceIsCompleted, &instanceCompletedAt, &instanceCreatedAt, &instanceVersion,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan goal with instance: %w", err)
@@ -295,6 +423,7 @@ func (r *Repository) GetGoalsWithTodayInstances(userID string) ([]GoalWithTodayI
 			}
 			instanceCreatedTime, _ := time.Parse(time.RFC3339, instanceCreatedAt.String)
 			instance.CreatedAt = instanceCreatedTime
+			instance.Version = int(instanceVersion.Int64)
 
 			result.TodayInstance = &instance
 		}
@@ -305,17 +434,29 @@ func (r *Repository) GetGoalsWithTodayInstances(userID string) ([]GoalWithTodayI
 	return results, nil
 }
 
-func (r *Repository) GetDailyInstancesByGoal(goalID, userID string, startDate, endDate time.Time) ([]DailyGoalInstance, error) {
-	query := `
-		SELECT id, goal_id, user_id, date, target_value, completed_value, is_completed, completed_at, created_at
-		FROM daily_goal_instances
-		WHERE goal_id = $1 AND user_id = $2 AND date >= $3 AND date <= $4
-		ORDER BY date DESC
-	`
+// InstanceSorts are the orders a goal's history can be requested in.
+var InstanceSorts = pagination.Sorts{
+	"date": {Column: "date", Type: "date"},
+}
+
+// GetDailyInstancesByGoal returns a page of a goal's daily instances
+// matching filter and the cursor of the next page, which is nil on the last
+// page.
+func (r *Repository) GetDailyInstancesByGoal(ctx context.Context, goalID, userID string, filter InstanceFilter, page pagination.Page) ([]DailyGoalInstance, *pagination.Cursor, error) {
+	var q pagination.Query
+	q.Where("goal_id = ? AND user_id = ?", goalID, userID)
+	q.Where("date >= ? AND date <= ?", filter.StartDate, filter.EndDate)
+	if filter.CompletedOnly {
+		q.Where("is_completed = true")
+	}
 
-	rows, err := r.db.Query(query, goalID, userID, startDate, endDate)
+	query, args := q.Build(`
+		SELECT id, goal_id, user_id, date, target_value, completed_value, is_completed, completed_at, created_at, version
+		FROM daily_goal_instances`, page, InstanceSorts, "id")
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get daily instances: %w", err)
+		return nil, nil, fmt.Errorf("failed to get daily instances: %w", err)
 	}
 	defer rows.Close()
 
@@ -324,12 +465,41 @@ func (r *Repository) GetDailyInstancesByGoal(goalID, userID string, startDate, e
 		var instance DailyGoalInstance
 		err := rows.Scan(&instance.ID, &instance.GoalID, &instance.UserID, &instance.Date,
 			&instance.TargetValue, &instance.CompletedValue, &instance.IsCompleted,
-			&instance.CompletedAt, &instance.CreatedAt)
+			&instance.CompletedAt, &instance.CreatedAt, &instance.Version)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan daily instance: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan daily instance: %w", err)
 		}
 		instances = append(instances, instance)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get daily instances: %w", err)
+	}
+
+	instances, next := pagination.Trim(instances, page, func(i DailyGoalInstance) (string, string) {
+		return i.Date.Format("2006-01-02"), i.ID
+	})
+	return instances, next, nil
+}
 
-	return instances, nil
-}
\ No newline at end of file
+// GetCurrentStreak returns the number of consecutive completed days for a
+// goal ending on date. It is zero when the instance for date is not complete.
+func (r *Repository) GetCurrentStreak(ctx context.Context, goalID string, date time.Time) (int, error) {
+	dateOnly := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	query := `
+		WITH completed AS (
+			SELECT date, date - (ROW_NUMBER() OVER (ORDER BY date))::int AS grp
+			FROM daily_goal_instances
+			WHERE goal_id = $1 AND is_completed = true AND date <= $2
+		)
+		SELECT COUNT(*)
+		FROM completed
+		WHERE grp = (SELECT grp FROM completed WHERE date = $2)
+	`
+	var streak int
+	if err := r.db.QueryRowContext(ctx, query, goalID, dateOnly).Scan(&streak); err != nil {
+		return 0, fmt.Errorf("failed to get streak: %w", err)
+	}
+
+	return streak, nil
+}
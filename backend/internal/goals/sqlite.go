@@ -0,0 +1,462 @@
+package goals
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+	"github.com/JoshPugli/grindhouse-api/internal/pagination"
+)
+
+// SQLiteStore is a GoalStore on SQLite, for self-hosting without a
+// PostgreSQL server. It mirrors the Repository: ids and timestamps are
+// generated here rather than by the database, every update bumps the row's
+// version in place of the bump_version trigger, and an instance is upserted
+// in a transaction, which holds SQLite's write lock from its start. Titles
+// sort by byte value, which matches the PostgreSQL collation only for ASCII.
+type SQLiteStore struct {
+	db    *sql.DB
+	clock func() time.Time
+}
+
+func NewSQLiteStore(db *sql.DB, clock func() time.Time) *SQLiteStore {
+	return &SQLiteStore{db: db, clock: clock}
+}
+
+const (
+	sqliteGoalColumns     = `id, user_id, title, description, goal_type, target_value, unit, visibility, difficulty, is_active, created_at, updated_at, version`
+	sqliteInstanceColumns = `id, goal_id, user_id, date, target_value, completed_value, is_completed, completed_at, created_at, version`
+)
+
+// rowQuerier is a *sql.DB or a *sql.Tx.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// rowScanner is a *sql.Row or *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (s *SQLiteStore) CreateGoal(ctx context.Context, userID string, req CreateGoalRequest) (*Goal, error) {
+	now := s.now()
+	goal := &Goal{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Title:       req.Title,
+		Description: req.Description,
+		GoalType:    req.GoalType,
+		TargetValue: req.TargetValue,
+		Unit:        req.Unit,
+		Visibility:  req.Visibility,
+		Difficulty:  req.Difficulty,
+		IsActive:    true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Version:     1,
+	}
+
+	query := `
+		INSERT INTO goals (id, user_id, title, description, goal_type, target_value, unit, visibility, difficulty, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11)
+	`
+	_, err := s.db.ExecContext(ctx, query, goal.ID, goal.UserID, goal.Title, goal.Description, goal.GoalType, goal.TargetValue, goal.Unit, goal.Visibility, goal.Difficulty, goal.IsActive, dialect.SQLite.Timestamp(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+func (s *SQLiteStore) GetGoalsByUserID(ctx context.Context, userID string, filter GoalFilter, page pagination.Page) ([]Goal, *pagination.Cursor, error) {
+	q := pagination.Query{Dialect: dialect.SQLite}
+	q.Where("user_id = ?", userID)
+	switch filter.Status {
+	case GoalStatusActive, "":
+		q.Where("is_active = true")
+	case GoalStatusArchived:
+		q.Where("is_active = false")
+	}
+	if filter.GoalType != "" {
+		q.Where("goal_type = ?", filter.GoalType)
+	}
+	if filter.CreatedAfter != nil {
+		q.Where("created_at >= ?", dialect.SQLite.Timestamp(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		q.Where("created_at < ?", dialect.SQLite.Timestamp(*filter.CreatedBefore))
+	}
+
+	query, args := q.Build(`SELECT `+sqliteGoalColumns+` FROM goals`, page, GoalSorts, "id")
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get goals: %w", err)
+	}
+	defer rows.Close()
+
+	var goals []Goal
+	for rows.Next() {
+		goal, err := scanSQLiteGoal(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		goals = append(goals, *goal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get goals: %w", err)
+	}
+
+	goals, next := pagination.Trim(goals, page, func(g Goal) (string, string) {
+		switch page.Sort {
+		case "updated_at":
+			return pagination.Timestamp(g.UpdatedAt), g.ID
+		case "title":
+			return g.Title, g.ID
+		}
+		return pagination.Timestamp(g.CreatedAt), g.ID
+	})
+	return goals, next, nil
+}
+
+func (s *SQLiteStore) GetGoalByID(ctx context.Context, goalID, userID string) (*Goal, error) {
+	return s.getGoal(ctx, s.db, goalID, userID)
+}
+
+// UpdateGoal applies req to a goal, conditional on the version that was
+// read as in Repository.UpdateGoal.
+func (s *SQLiteStore) UpdateGoal(ctx context.Context, goalID, userID string, req UpdateGoalRequest, expectedVersions Versions) (*Goal, error) {
+	goal, err := s.GetGoalByID(ctx, goalID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !expectedVersions.Allows(goal.Version) {
+		return nil, ErrVersionMismatch
+	}
+
+	if err := req.ValidateFor(goal.GoalType); err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		goal.Title = *req.Title
+	}
+	if req.Description != nil {
+		goal.Description = req.Description
+	}
+	if req.TargetValue != nil {
+		goal.TargetValue = req.TargetValue
+	}
+	if req.Unit != nil {
+		goal.Unit = req.Unit
+	}
+	if req.Visibility != nil {
+		goal.Visibility = *req.Visibility
+	}
+	if req.Difficulty != nil {
+		goal.Difficulty = *req.Difficulty
+	}
+	if req.IsActive != nil {
+		goal.IsActive = *req.IsActive
+	}
+	goal.UpdatedAt = s.now()
+
+	query := `
+		UPDATE goals
+		SET title = $1, description = $2, target_value = $3, unit = $4, visibility = $5, difficulty = $6, is_active = $7, updated_at = $8,
+		    version = version + 1
+		WHERE id = $9 AND user_id = $10 AND version = $11
+		RETURNING version
+	`
+	err = s.db.QueryRowContext(ctx, query, goal.Title, goal.Description, goal.TargetValue, goal.Unit, goal.Visibility, goal.Difficulty, goal.IsActive, dialect.SQLite.Timestamp(goal.UpdatedAt), goalID, userID, goal.Version).Scan(&goal.Version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVersionMismatch
+		}
+		return nil, fmt.Errorf("failed to update goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+// DeleteGoal soft deletes a goal, only while it is at one of
+// expectedVersions if that is set. Like UpdateGoal, the write is conditional
+// on the version that was read.
+func (s *SQLiteStore) DeleteGoal(ctx context.Context, goalID, userID string, expectedVersions Versions) error {
+	goal, err := s.GetGoalByID(ctx, goalID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !expectedVersions.Allows(goal.Version) {
+		return ErrVersionMismatch
+	}
+
+	query := `UPDATE goals SET is_active = false, updated_at = $1, version = version + 1 WHERE id = $2 AND user_id = $3 AND version = $4`
+	result, err := s.db.ExecContext(ctx, query, dialect.SQLite.Timestamp(s.now()), goalID, userID, goal.Version)
+	if err != nil {
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// UpsertDailyInstance applies req to the goal's instance for date, creating
+// the instance if it does not exist. The transaction takes the write lock
+// when it begins, so the instance cannot change between being read and
+// written. If expectedVersions is set the write only happens while the
+// instance is at one of those versions; an instance that does not exist yet
+// is at version 1.
+func (s *SQLiteStore) UpsertDailyInstance(ctx context.Context, goalID, userID string, date time.Time, req UpdateDailyInstanceRequest, expectedVersions Versions) (*UpsertedInstance, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	goal, err := s.getGoal(ctx, tx, goalID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	query := `SELECT ` + sqliteInstanceColumns + ` FROM daily_goal_instances WHERE goal_id = $1 AND date = $2`
+	instance, err := scanSQLiteInstance(tx.QueryRowContext(ctx, query, goalID, dialect.SQLite.Date(date)))
+	created := err == sql.ErrNoRows
+	switch {
+	case created:
+		if !expectedVersions.Allows(1) {
+			return nil, ErrVersionMismatch
+		}
+		instance = &DailyGoalInstance{
+			ID:          uuid.New().String(),
+			GoalID:      goal.ID,
+			UserID:      goal.UserID,
+			Date:        truncateDate(date),
+			TargetValue: goal.TargetValue,
+			CreatedAt:   now,
+			Version:     1,
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to get daily instance: %w", err)
+	case !expectedVersions.Allows(instance.Version):
+		return nil, ErrVersionMismatch
+	}
+	previous := *instance
+
+	if req.CompletedValue != nil {
+		instance.CompletedValue = req.CompletedValue
+	}
+	if req.IsCompleted != nil {
+		instance.IsCompleted = *req.IsCompleted
+		switch {
+		case !*req.IsCompleted:
+			instance.CompletedAt = nil
+		case instance.CompletedAt == nil:
+			instance.CompletedAt = &now
+		}
+	}
+
+	if created {
+		query = `
+			INSERT INTO daily_goal_instances (id, goal_id, user_id, date, target_value, completed_value, is_completed, completed_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		`
+		_, err = tx.ExecContext(ctx, query, instance.ID, instance.GoalID, instance.UserID, dialect.SQLite.Date(instance.Date),
+			instance.TargetValue, instance.CompletedValue, instance.IsCompleted, sqliteNullableTimestamp(instance.CompletedAt),
+			dialect.SQLite.Timestamp(now))
+	} else {
+		query = `
+			UPDATE daily_goal_instances
+			SET completed_value = $1, is_completed = $2, completed_at = $3, updated_at = $4, version = version + 1
+			WHERE id = $5
+		`
+		_, err = tx.ExecContext(ctx, query, instance.CompletedValue, instance.IsCompleted,
+			sqliteNullableTimestamp(instance.CompletedAt), dialect.SQLite.Timestamp(now), instance.ID)
+		instance.Version++
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert daily instance: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit daily instance: %w", err)
+	}
+
+	return &UpsertedInstance{Goal: goal, Instance: instance, Previous: &previous}, nil
+}
+
+func (s *SQLiteStore) GetGoalsWithTodayInstances(ctx context.Context, userID string) ([]GoalWithTodayInstance, error) {
+	query := `
+		SELECT
+			g.id, g.user_id, g.title, g.description, g.goal_type, g.target_value, g.unit, g.visibility, g.difficulty, g.is_active, g.created_at, g.updated_at, g.version,
+			dgi.id, dgi.goal_id, dgi.user_id, dgi.date, dgi.target_value, dgi.completed_value, dgi.is_completed, dgi.completed_at, dgi.created_at, dgi.version
+		FROM goals g
+		LEFT JOIN daily_goal_instances dgi ON g.id = dgi.goal_id AND dgi.date = $2
+		WHERE g.user_id = $1 AND g.is_active = true
+		ORDER BY g.created_at DESC, g.id DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, dialect.SQLite.Date(s.clock().UTC()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals with today instances: %w", err)
+	}
+	defer rows.Close()
+
+	var results []GoalWithTodayInstance
+	for rows.Next() {
+		var goal Goal
+		var instanceID, instanceGoalID, instanceUserID sql.NullString
+		var instanceDate, instanceCreatedAt sql.NullTime
+		var instanceIsCompleted sql.NullBool
+		var instanceVersion sql.NullInt64
+		var instance DailyGoalInstance
+
+		err := rows.Scan(
+			&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.GoalType, &goal.TargetValue, &goal.Unit, &goal.Visibility, &goal.Difficulty, &goal.IsActive, &goal.CreatedAt, &goal.UpdatedAt, &goal.Version,
+			&instanceID, &instanceGoalID, &instanceUserID, &instanceDate, &instance.TargetValue, &instance.CompletedValue, &instanceIsCompleted, &instance.CompletedAt, &instanceCreatedAt, &instanceVersion,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal with instance: %w", err)
+		}
+
+		result := GoalWithTodayInstance{
+			Goal: goal,
+		}
+
+		if instanceID.Valid {
+			instance.ID = instanceID.String
+			instance.GoalID = instanceGoalID.String
+			instance.UserID = instanceUserID.String
+			instance.Date = instanceDate.Time
+			instance.IsCompleted = instanceIsCompleted.Bool
+			instance.CreatedAt = instanceCreatedAt.Time
+			instance.Version = int(instanceVersion.Int64)
+
+			result.TodayInstance = &instance
+		}
+
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get goals with today instances: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLiteStore) GetDailyInstancesByGoal(ctx context.Context, goalID, userID string, filter InstanceFilter, page pagination.Page) ([]DailyGoalInstance, *pagination.Cursor, error) {
+	q := pagination.Query{Dialect: dialect.SQLite}
+	q.Where("goal_id = ? AND user_id = ?", goalID, userID)
+	q.Where("date >= ? AND date <= ?", dialect.SQLite.Date(filter.StartDate), dialect.SQLite.Date(filter.EndDate))
+	if filter.CompletedOnly {
+		q.Where("is_completed = true")
+	}
+
+	query, args := q.Build(`SELECT `+sqliteInstanceColumns+` FROM daily_goal_instances`, page, InstanceSorts, "id")
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get daily instances: %w", err)
+	}
+	defer rows.Close()
+
+	var instances []DailyGoalInstance
+	for rows.Next() {
+		instance, err := scanSQLiteInstance(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan daily instance: %w", err)
+		}
+		instances = append(instances, *instance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get daily instances: %w", err)
+	}
+
+	instances, next := pagination.Trim(instances, page, func(i DailyGoalInstance) (string, string) {
+		return i.Date.Format(dialect.DateLayout), i.ID
+	})
+	return instances, next, nil
+}
+
+// GetCurrentStreak returns the number of consecutive completed days for a
+// goal ending on date. Consecutive dates have consecutive Julian day
+// numbers, which stand in for PostgreSQL's date arithmetic.
+func (s *SQLiteStore) GetCurrentStreak(ctx context.Context, goalID string, date time.Time) (int, error) {
+	query := `
+		WITH completed AS (
+			SELECT date, CAST(julianday(date) AS INTEGER) - ROW_NUMBER() OVER (ORDER BY date) AS grp
+			FROM daily_goal_instances
+			WHERE goal_id = $1 AND is_completed = true AND date <= $2
+		)
+		SELECT COUNT(*)
+		FROM completed
+		WHERE grp = (SELECT grp FROM completed WHERE date = $2)
+	`
+	var streak int
+	if err := s.db.QueryRowContext(ctx, query, goalID, dialect.SQLite.Date(date)).Scan(&streak); err != nil {
+		return 0, fmt.Errorf("failed to get streak: %w", err)
+	}
+
+	return streak, nil
+}
+
+func (s *SQLiteStore) getGoal(ctx context.Context, q rowQuerier, goalID, userID string) (*Goal, error) {
+	query := `SELECT ` + sqliteGoalColumns + ` FROM goals WHERE id = $1 AND user_id = $2`
+	goal, err := scanSQLiteGoal(q.QueryRowContext(ctx, query, goalID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("goal not found")
+		}
+		return nil, fmt.Errorf("failed to get goal: %w", err)
+	}
+	return goal, nil
+}
+
+func scanSQLiteGoal(row rowScanner) (*Goal, error) {
+	var goal Goal
+	err := row.Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.GoalType, &goal.TargetValue, &goal.Unit,
+		&goal.Visibility, &goal.Difficulty, &goal.IsActive, &goal.CreatedAt, &goal.UpdatedAt, &goal.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+func scanSQLiteInstance(row rowScanner) (*DailyGoalInstance, error) {
+	var instance DailyGoalInstance
+	err := row.Scan(&instance.ID, &instance.GoalID, &instance.UserID, &instance.Date,
+		&instance.TargetValue, &instance.CompletedValue, &instance.IsCompleted,
+		&instance.CompletedAt, &instance.CreatedAt, &instance.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &instance, nil
+}
+
+// now returns the current time as it reads back from SQLite.
+func (s *SQLiteStore) now() time.Time {
+	return s.clock().UTC().Truncate(time.Microsecond)
+}
+
+func sqliteNullableTimestamp(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return dialect.SQLite.Timestamp(*t)
+}
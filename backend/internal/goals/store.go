@@ -0,0 +1,33 @@
+package goals
+
+import (
+	"context"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/pagination"
+)
+
+// GoalStore persists goals and their daily instances. Every method is scoped
+// to the owning user except GetCurrentStreak, whose callers have already
+// checked ownership. Goals are never removed: DeleteGoal archives them.
+//
+// Repository implements it on PostgreSQL, SQLiteStore on SQLite and
+// MemoryStore in memory. All of them must pass the storetest conformance
+// suite.
+type GoalStore interface {
+	CreateGoal(ctx context.Context, userID string, req CreateGoalRequest) (*Goal, error)
+	GetGoalsByUserID(ctx context.Context, userID string, filter GoalFilter, page pagination.Page) ([]Goal, *pagination.Cursor, error)
+	GetGoalByID(ctx context.Context, goalID, userID string) (*Goal, error)
+	UpdateGoal(ctx context.Context, goalID, userID string, req UpdateGoalRequest, expectedVersions Versions) (*Goal, error)
+	DeleteGoal(ctx context.Context, goalID, userID string, expectedVersions Versions) error
+	UpsertDailyInstance(ctx context.Context, goalID, userID string, date time.Time, req UpdateDailyInstanceRequest, expectedVersions Versions) (*UpsertedInstance, error)
+	GetGoalsWithTodayInstances(ctx context.Context, userID string) ([]GoalWithTodayInstance, error)
+	GetDailyInstancesByGoal(ctx context.Context, goalID, userID string, filter InstanceFilter, page pagination.Page) ([]DailyGoalInstance, *pagination.Cursor, error)
+	GetCurrentStreak(ctx context.Context, goalID string, date time.Time) (int, error)
+}
+
+var (
+	_ GoalStore = (*Repository)(nil)
+	_ GoalStore = (*SQLiteStore)(nil)
+	_ GoalStore = (*MemoryStore)(nil)
+)
@@ -0,0 +1,153 @@
+package goals_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/pagination"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+	"github.com/JoshPugli/grindhouse-api/internal/xp"
+)
+
+// TestUpsertDailyInstanceConcurrently checks in the same goal and date from
+// many goroutines at once, as HandleUpdateDailyInstance does, and checks
+// that the check-ins serialise on a single row and that the experience
+// ledger ends up matching the instance.
+func TestUpsertDailyInstanceConcurrently(t *testing.T) {
+	db := storetest.OpenPostgres(t)
+	ctx := context.Background()
+
+	repo := goals.NewRepository(db, time.Now)
+	xpRepo := xp.NewRepository(db)
+	listeners := goals.Listeners{xp.NewService(xpRepo, repo)}
+
+	owner := storetest.NewUser(t, db)
+	target, unit := 10.0, "reps"
+	goal, err := repo.CreateGoal(ctx, owner.ID, goals.CreateGoalRequest{
+		Title:       "push-ups",
+		GoalType:    goals.GoalTypeNumeric,
+		TargetValue: &target,
+		Unit:        &unit,
+		Visibility:  goals.VisibilityPrivate,
+		Difficulty:  goals.DifficultyHard,
+	})
+	if err != nil {
+		t.Fatalf("CreateGoal: %v", err)
+	}
+
+	checkIn := func(date time.Time, value float64, completed bool) (*goals.UpsertedInstance, error) {
+		req := goals.UpdateDailyInstanceRequest{CompletedValue: &value, IsCompleted: &completed}
+		upserted, err := repo.UpsertDailyInstance(ctx, goal.ID, owner.ID, date, req, nil)
+		if err != nil {
+			return nil, err
+		}
+		listeners.Notify(ctx, goals.Change{
+			Kind:     goals.InstanceUpdated,
+			UserID:   owner.ID,
+			Goal:     upserted.Goal,
+			Instance: upserted.Instance,
+			Previous: upserted.Previous,
+		})
+		return upserted, nil
+	}
+
+	// Completing the day before makes the streak on the contested day 2.
+	date := time.Date(2020, time.June, 15, 0, 0, 0, 0, time.UTC)
+	if _, err := checkIn(date.AddDate(0, 0, -1), 10, true); err != nil {
+		t.Fatalf("check-in on the day before: %v", err)
+	}
+
+	const workers = 50
+	results := make([]*goals.UpsertedInstance, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every third check-in un-completes the day.
+			results[i], errs[i] = checkIn(date, float64(i), i%3 != 0)
+		}()
+	}
+	wg.Wait()
+
+	byVersion := make(map[int]*goals.UpsertedInstance)
+	created := 0
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("check-in %d: %v", i, err)
+		}
+		instance := results[i].Instance
+		if other, ok := byVersion[instance.Version]; ok {
+			t.Fatalf("check-ins %s and %s both wrote version %d", other.Instance.ID, instance.ID, instance.Version)
+		}
+		byVersion[instance.Version] = results[i]
+		// The instance a check-in creates is previously at version 1.
+		wantPrevious := max(instance.Version-1, 1)
+		if results[i].Previous.Version != wantPrevious {
+			t.Errorf("check-in at version %d saw previous version %d, want %d", instance.Version, results[i].Previous.Version, wantPrevious)
+		}
+		if instance.Version == 1 {
+			created++
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d check-ins created the instance, want 1", created)
+	}
+
+	var rows int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM daily_goal_instances WHERE goal_id = $1 AND date = $2`, goal.ID, date).Scan(&rows); err != nil {
+		t.Fatalf("failed to count instances: %v", err)
+	}
+	if rows != 1 {
+		t.Fatalf("%d instances for the date, want 1", rows)
+	}
+
+	last, ok := byVersion[workers]
+	if !ok {
+		t.Fatalf("no check-in wrote version %d", workers)
+	}
+	history, _, err := repo.GetDailyInstancesByGoal(ctx, goal.ID, owner.ID, goals.InstanceFilter{StartDate: date, EndDate: date}, pagination.Page{Limit: 10, Sort: "date"})
+	if err != nil {
+		t.Fatalf("GetDailyInstancesByGoal: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("history has %d instances, want 1", len(history))
+	}
+	final := history[0]
+	if final.Version != workers || final.IsCompleted != last.Instance.IsCompleted ||
+		final.CompletedValue == nil || *final.CompletedValue != *last.Instance.CompletedValue {
+		t.Errorf("stored instance is %+v, want the last check-in's %+v", final, last.Instance)
+	}
+
+	wantStreak, wantAward := 0, 0
+	if final.IsCompleted {
+		wantStreak, wantAward = 2, xp.AwardFor(goals.DifficultyHard, 2)
+	}
+	streak, err := repo.GetCurrentStreak(ctx, goal.ID, date)
+	if err != nil {
+		t.Fatalf("GetCurrentStreak: %v", err)
+	}
+	if streak != wantStreak {
+		t.Errorf("streak is %d, want %d", streak, wantStreak)
+	}
+
+	var outstanding int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(SUM(amount), 0) FROM xp_ledger WHERE instance_id = $1`, final.ID).Scan(&outstanding); err != nil {
+		t.Fatalf("failed to sum ledger: %v", err)
+	}
+	if outstanding != wantAward {
+		t.Errorf("outstanding award for the date is %d, want %d", outstanding, wantAward)
+	}
+
+	total, err := xpRepo.GetTotal(ctx, owner.ID)
+	if err != nil {
+		t.Fatalf("GetTotal: %v", err)
+	}
+	if want := xp.AwardFor(goals.DifficultyHard, 1) + wantAward; total != want {
+		t.Errorf("total experience is %d, want %d", total, want)
+	}
+}
@@ -0,0 +1,137 @@
+package goals
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
+
+// Limits of the goals and daily_goal_instances columns.
+const (
+	maxTitleLength = 255
+	maxUnitLength  = 50
+	// maxValue is the largest DECIMAL(10,2).
+	maxValue = 99999999.99
+)
+
+// Validate checks every field of the request and reports all problems at
+// once. Empty visibility and difficulty are allowed and mean the defaults.
+func (req CreateGoalRequest) Validate() error {
+	var fields apperr.Fields
+
+	validateTitle(&fields, req.Title)
+
+	switch req.GoalType {
+	case "":
+		fields.Add("goal_type", "is required")
+	case GoalTypeBoolean, GoalTypeNumeric, GoalTypeDuration:
+		validateTarget(&fields, req.GoalType, req.TargetValue, req.Unit, true)
+	default:
+		fields.Add("goal_type", "must be one of boolean, numeric, duration")
+	}
+
+	if req.Visibility != "" && !req.Visibility.Valid() {
+		fields.Add("visibility", "must be one of private, followers, public")
+	}
+	if req.Difficulty != "" && !req.Difficulty.Valid() {
+		fields.Add("difficulty", "must be one of easy, medium, hard")
+	}
+
+	return fields.Err()
+}
+
+// Validate checks the fields present in the request. Rules that depend on
+// the goal's type are checked by ValidateFor once the goal has been read.
+func (req UpdateGoalRequest) Validate() error {
+	var fields apperr.Fields
+
+	if req.Title != nil {
+		validateTitle(&fields, *req.Title)
+	}
+	if req.TargetValue != nil {
+		validateValue(&fields, "target_value", *req.TargetValue, false)
+	}
+	if req.Unit != nil {
+		validateUnit(&fields, *req.Unit)
+	}
+	if req.Visibility != nil && !req.Visibility.Valid() {
+		fields.Add("visibility", "must be one of private, followers, public")
+	}
+	if req.Difficulty != nil && !req.Difficulty.Valid() {
+		fields.Add("difficulty", "must be one of easy, medium, hard")
+	}
+
+	return fields.Err()
+}
+
+// ValidateFor checks the request against the type of the goal it updates.
+// A goal's type never changes, so this holds for as long as the goal exists.
+func (req UpdateGoalRequest) ValidateFor(goalType GoalType) error {
+	var fields apperr.Fields
+	validateTarget(&fields, goalType, req.TargetValue, req.Unit, false)
+	return fields.Err()
+}
+
+// Validate checks that a reported value fits the column it is stored in.
+func (req UpdateDailyInstanceRequest) Validate() error {
+	var fields apperr.Fields
+	if req.CompletedValue != nil {
+		validateValue(&fields, "completed_value", *req.CompletedValue, true)
+	}
+	return fields.Err()
+}
+
+func validateTitle(fields *apperr.Fields, title string) {
+	switch {
+	case strings.TrimSpace(title) == "":
+		fields.Add("title", "is required")
+	case utf8.RuneCountInString(title) > maxTitleLength:
+		fields.Add("title", "must be at most 255 characters")
+	}
+}
+
+func validateUnit(fields *apperr.Fields, unit string) {
+	if utf8.RuneCountInString(unit) > maxUnitLength {
+		fields.Add("unit", "must be at most 50 characters")
+	}
+}
+
+// validateValue checks a numeric column. Targets must be positive; reported
+// values may be zero.
+func validateValue(fields *apperr.Fields, field string, value float64, allowZero bool) {
+	switch {
+	case value < 0 || (value == 0 && !allowZero):
+		if allowZero {
+			fields.Add(field, "must not be negative")
+		} else {
+			fields.Add(field, "must be greater than 0")
+		}
+	case value > maxValue:
+		fields.Add(field, "must be at most 99999999.99")
+	}
+}
+
+// validateTarget applies the rules that depend on the goal type: boolean
+// goals are done or not and take no target or unit, while numeric and
+// duration goals need a positive target when they are created.
+func validateTarget(fields *apperr.Fields, goalType GoalType, targetValue *float64, unit *string, creating bool) {
+	if goalType == GoalTypeBoolean {
+		if targetValue != nil {
+			fields.Add("target_value", "must be omitted for boolean goals")
+		}
+		if unit != nil {
+			fields.Add("unit", "must be omitted for boolean goals")
+		}
+		return
+	}
+
+	if targetValue != nil {
+		validateValue(fields, "target_value", *targetValue, false)
+	} else if creating {
+		fields.Add("target_value", "is required for "+string(goalType)+" goals")
+	}
+	if unit != nil {
+		validateUnit(fields, *unit)
+	}
+}
@@ -0,0 +1,191 @@
+// Package idempotency makes retried POST and PUT requests safe by replaying the original response
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/middleware"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+type Middleware struct {
+	store   *Store
+	ttl     time.Duration
+	maxBody int64
+	authn   *auth.Authenticator
+}
+
+// NewMiddleware returns middleware that remembers responses to POST and PUT
+// requests carrying an Idempotency-Key header for ttl. Keys are scoped to
+// the user authn authenticates the request as. Requests with a key and a
+// body larger than maxBody bytes are rejected.
+func NewMiddleware(store *Store, ttl time.Duration, maxBody int64, authn *auth.Authenticator) *Middleware {
+	return &Middleware{
+		store:   store,
+		ttl:     ttl,
+		maxBody: maxBody,
+		authn:   authn,
+	}
+}
+
+// Handler wraps next. The first request with a given key runs normally and
+// its response is stored. A retry with the same key and the same method,
+// path, query and body gets the stored response back with an
+// Idempotent-Replayed header instead of running again. Reusing a key for a
+// different request is rejected with 422, and a retry that arrives while
+// the original is still running gets 409.
+//
+// Keys are scoped to the authenticated user, so clients only need to make
+// them unique per user. Unauthenticated requests are passed through
+// without a key: they have no scope of their own, and sharing one would let
+// a client replay another's response, such as a login token. Responses with
+// a 5xx status are not stored, so the client's retry runs the request
+// again.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderKey)
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPut) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			apperr.WriteProblem(w, r, http.StatusBadRequest, "Idempotency-Key must be at most "+strconv.Itoa(maxKeyLength)+" characters")
+			return
+		}
+
+		userID, err := m.authn.UserIDFromRequest(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		scope := "user:" + userID
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, m.maxBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				apperr.WriteProblem(w, r, http.StatusRequestEntityTooLarge, "Request body must be at most "+strconv.FormatInt(m.maxBody, 10)+" bytes with an Idempotency-Key")
+				return
+			}
+			apperr.WriteProblem(w, r, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := fingerprint(r, body)
+
+		record, claimed, err := m.store.Claim(r.Context(), scope, key, fingerprint, m.ttl)
+		if err != nil {
+			apperr.Write(w, r, err)
+			return
+		}
+
+		if !claimed {
+			switch {
+			case record.Fingerprint != fingerprint:
+				apperr.WriteProblem(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			case record.StatusCode == 0:
+				w.Header().Set("Retry-After", "1")
+				apperr.WriteProblem(w, r, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+			default:
+				replay(w, record)
+			}
+			return
+		}
+
+		// The response is recorded even if the client has gone away, so a
+		// retry with the same key can be answered from the store.
+		ctx := context.WithoutCancel(r.Context())
+
+		rec := &recorder{ResponseWriter: w}
+		defer func() {
+			if p := recover(); p != nil {
+				m.release(ctx, scope, key)
+				panic(p)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= http.StatusInternalServerError {
+			m.release(ctx, scope, key)
+			return
+		}
+
+		if err := m.store.Complete(ctx, scope, key, status, w.Header().Clone(), rec.body.Bytes()); err != nil {
+			middleware.GetLogger(ctx).Error("failed to store idempotent response", "err", err)
+			m.release(ctx, scope, key)
+		}
+	})
+}
+
+func (m *Middleware) release(ctx context.Context, scope, key string) {
+	if err := m.store.Release(ctx, scope, key); err != nil {
+		middleware.GetLogger(ctx).Error("failed to release idempotency key", "err", err)
+	}
+}
+
+func replay(w http.ResponseWriter, record *Record) {
+	for name, values := range record.Header {
+		// The replay keeps this request's own ID so it can be traced.
+		if name == middleware.HeaderRequestID {
+			continue
+		}
+		w.Header()[name] = values
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// fingerprint identifies a request by everything that determines what it
+// does, so a key cannot be replayed against a different request.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+"\n"+r.URL.Path+"\n"+r.URL.RawQuery+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder passes a response through while keeping a copy to store.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,102 @@
+package idempotency_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/idempotency"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+)
+
+const maxBody = 64
+
+var authn = auth.NewAuthenticator("test-secret", time.Hour)
+
+// counter responds with how many requests it has handled.
+type counter struct{ calls int }
+
+func (c *counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.calls++
+	io.Copy(io.Discard, r.Body)
+	w.WriteHeader(http.StatusCreated)
+	io.WriteString(w, strings.Repeat("x", c.calls))
+}
+
+func request(t *testing.T, userID, key, body string) *http.Request {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/goals", strings.NewReader(body))
+	r.Header.Set(idempotency.HeaderKey, key)
+	if userID != "" {
+		token, err := authn.GenerateJWT(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestHandlerWithoutStore(t *testing.T) {
+	// Neither case may reach the store, which is nil.
+	tests := []struct {
+		name       string
+		userID     string
+		body       string
+		wantStatus int
+		wantCalls  int
+	}{
+		{"anonymous request is passed through", "", "{}", http.StatusCreated, 1},
+		{"body over the limit", uuid.New().String(), strings.Repeat("a", maxBody+1), http.StatusRequestEntityTooLarge, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &counter{}
+			handler := idempotency.NewMiddleware(nil, time.Hour, maxBody, authn).Handler(next)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request(t, tt.userID, uuid.New().String(), tt.body))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status is %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if next.calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", next.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHandlerReplays(t *testing.T) {
+	db := storetest.OpenPostgres(t)
+	owner := storetest.NewUser(t, db)
+
+	next := &counter{}
+	handler := idempotency.NewMiddleware(idempotency.NewStore(db), time.Hour, maxBody, authn).Handler(next)
+	key := uuid.New().String()
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, request(t, owner.ID, key, `{"title":"a"}`))
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, request(t, owner.ID, key, `{"title":"a"}`))
+
+	if next.calls != 1 {
+		t.Errorf("handler ran %d times, want once", next.calls)
+	}
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() || retry.Header().Get(idempotency.HeaderReplayed) != "true" {
+		t.Errorf("retry got %d %q, want a replay of %d %q", retry.Code, retry.Body, first.Code, first.Body)
+	}
+
+	reused := httptest.NewRecorder()
+	handler.ServeHTTP(reused, request(t, owner.ID, key, `{"title":"b"}`))
+	if reused.Code != http.StatusUnprocessableEntity {
+		t.Errorf("key reused for another body got %d, want %d", reused.Code, http.StatusUnprocessableEntity)
+	}
+}
@@ -0,0 +1,140 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// lockTimeout is how long a request may hold a key before another request
+// with the same key is allowed to take it over, e.g. after a crash.
+const lockTimeout = 5 * time.Minute
+
+// Record is a stored idempotency key. StatusCode is zero while the original
+// request is still being processed.
+type Record struct {
+	Fingerprint string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Claim reserves key within scope for a request with the given fingerprint.
+// It returns claimed=true if the caller now owns the key and must later call
+// Complete or Release. Otherwise it returns the existing record, which is
+// either finished (replay it) or still in progress.
+//
+// Keys whose TTL has passed, and keys abandoned mid-request for longer than
+// lockTimeout, are reclaimed as if they did not exist.
+func (s *Store) Claim(ctx context.Context, scope, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (scope, key, fingerprint, locked_at, expires_at)
+		VALUES ($1, $2, $3, NOW(), NOW() + $4::float8 * INTERVAL '1 second')
+		ON CONFLICT (scope, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, header = NULL, body = NULL,
+		    locked_at = EXCLUDED.locked_at, expires_at = EXCLUDED.expires_at, created_at = NOW()
+		WHERE idempotency_keys.expires_at < NOW()
+		   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_at < NOW() - $5::float8 * INTERVAL '1 second')
+		RETURNING fingerprint
+	`
+	var claimed string
+	err := s.db.QueryRowContext(ctx, query, scope, key, fingerprint, ttl.Seconds(), lockTimeout.Seconds()).Scan(&claimed)
+	if err == nil {
+		return nil, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	var record Record
+	var statusCode sql.NullInt64
+	var header []byte
+	selectQuery := `
+		SELECT fingerprint, status_code, header, body
+		FROM idempotency_keys
+		WHERE scope = $1 AND key = $2
+	`
+	err = s.db.QueryRowContext(ctx, selectQuery, scope, key).Scan(&record.Fingerprint, &statusCode, &header, &record.Body)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Removed between our insert and select; the client can retry.
+			return &Record{Fingerprint: fingerprint}, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	record.StatusCode = int(statusCode.Int64)
+	if header != nil {
+		if err := json.Unmarshal(header, &record.Header); err != nil {
+			return nil, false, fmt.Errorf("failed to decode stored headers: %w", err)
+		}
+	}
+
+	return &record, false, nil
+}
+
+// Complete stores the response to replay for key.
+func (s *Store) Complete(ctx context.Context, scope, key string, statusCode int, header http.Header, body []byte) error {
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("failed to encode headers: %w", err)
+	}
+
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $3, header = $4, body = $5, locked_at = NULL
+		WHERE scope = $1 AND key = $2
+	`
+	if _, err := s.db.ExecContext(ctx, query, scope, key, statusCode, encoded, body); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// Release forgets key so the request can be retried, used when the original
+// attempt failed in a way that should not be replayed.
+func (s *Store) Release(ctx context.Context, scope, key string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2`, scope, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) RemoveExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove expired idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// RunCleanup removes expired keys every interval until ctx is cancelled,
+// logging failures to logger.
+func (s *Store) RunCleanup(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RemoveExpired(ctx); err != nil {
+				logger.Error("idempotency cleanup failed", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+const (
+	csvFile              = "import.csv"
+	defaultCSVDateFormat = "2006-01-02"
+)
+
+// Validate fills in defaults and checks that the mapping names the columns
+// the parser needs.
+func (m *CSVMapping) Validate() error {
+	if m.GoalColumn == "" {
+		return fmt.Errorf("goal_column is required")
+	}
+	if m.DateColumn == "" {
+		return fmt.Errorf("date_column is required")
+	}
+	if m.DateFormat == "" {
+		m.DateFormat = defaultCSVDateFormat
+	}
+	if m.GoalType == "" {
+		m.GoalType = goals.GoalTypeBoolean
+		if m.ValueColumn != "" {
+			m.GoalType = goals.GoalTypeNumeric
+		}
+	}
+
+	switch m.GoalType {
+	case goals.GoalTypeBoolean:
+		if m.ValueColumn == "" && m.CompletedColumn == "" {
+			// Every listed row counts as a completed day.
+			return nil
+		}
+	case goals.GoalTypeNumeric, goals.GoalTypeDuration:
+		if m.ValueColumn == "" {
+			return fmt.Errorf("value_column is required for %s goals", m.GoalType)
+		}
+	default:
+		return fmt.Errorf("invalid goal_type")
+	}
+	return nil
+}
+
+// ParseCSV parses a generic CSV file with a header row, using mapping to find
+// the goal name, date, value and completion columns. Each distinct goal name
+// becomes one goal.
+func ParseCSV(data []byte, mapping CSVMapping) (*Parsed, error) {
+	if err := mapping.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+
+	columns := headerIndex(records[0])
+	for _, name := range []string{mapping.GoalColumn, mapping.DateColumn, mapping.ValueColumn, mapping.CompletedColumn} {
+		if name == "" {
+			continue
+		}
+		if _, ok := columns[strings.ToLower(name)]; !ok {
+			return nil, fmt.Errorf("column %q not found in header", name)
+		}
+	}
+
+	parsed := &Parsed{}
+	habits := make(map[string]*Habit)
+	order := []string{}
+	seen := make(map[string]map[time.Time]bool)
+
+	for i, record := range records[1:] {
+		row := i + 2
+		rowError := func(column, message string) {
+			parsed.Errors = append(parsed.Errors, RowError{File: csvFile, Row: row, Field: column, Message: message})
+		}
+
+		title := field(record, columns, strings.ToLower(mapping.GoalColumn))
+		if title == "" {
+			rowError(mapping.GoalColumn, "goal name is required")
+			continue
+		}
+
+		date, err := time.Parse(mapping.DateFormat, field(record, columns, strings.ToLower(mapping.DateColumn)))
+		if err != nil {
+			rowError(mapping.DateColumn, "expected date in format "+mapping.DateFormat)
+			continue
+		}
+		date = truncateDay(date)
+
+		entry := Entry{Date: date, Completed: true, Source: Source{File: csvFile, Row: row}}
+
+		if mapping.ValueColumn != "" {
+			valueStr := field(record, columns, strings.ToLower(mapping.ValueColumn))
+			if valueStr != "" {
+				value, err := strconv.ParseFloat(valueStr, 64)
+				if err != nil {
+					rowError(mapping.ValueColumn, "not a number")
+					continue
+				}
+				entry.Value = &value
+				entry.Completed = value > 0
+			} else if mapping.GoalType != goals.GoalTypeBoolean {
+				rowError(mapping.ValueColumn, "value is required")
+				continue
+			}
+		}
+
+		if mapping.CompletedColumn != "" {
+			completed, err := parseCompleted(field(record, columns, strings.ToLower(mapping.CompletedColumn)))
+			if err != nil {
+				rowError(mapping.CompletedColumn, err.Error())
+				continue
+			}
+			entry.Completed = completed
+		}
+
+		if mapping.GoalType == goals.GoalTypeBoolean {
+			entry.Value = nil
+		}
+
+		key := strings.ToLower(title)
+		habit, ok := habits[key]
+		if !ok {
+			habit = &Habit{
+				ExternalID: "title:" + key,
+				Title:      title,
+				GoalType:   mapping.GoalType,
+				Source:     Source{File: csvFile, Row: row},
+			}
+			habits[key] = habit
+			seen[key] = make(map[time.Time]bool)
+			order = append(order, key)
+		}
+
+		if seen[key][date] {
+			rowError(mapping.DateColumn, "duplicate date for this goal")
+			continue
+		}
+		seen[key][date] = true
+
+		if entry.Completed || entry.Value != nil {
+			habit.Entries = append(habit.Entries, entry)
+		}
+	}
+
+	for _, key := range order {
+		habit := habits[key]
+		sortEntries(habit.Entries)
+		parsed.Habits = append(parsed.Habits, *habit)
+	}
+
+	return parsed, nil
+}
+
+func parseCompleted(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "true", "yes", "y", "x", "done", "completed":
+		return true, nil
+	case "", "0", "false", "no", "n":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected a boolean")
+}
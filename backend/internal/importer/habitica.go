@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+const habiticaFile = "habitica.json"
+
+// habiticaExport is the subset of Habitica's "Export Data > User Data (JSON)"
+// file that carries habits and their history.
+type habiticaExport struct {
+	Tasks struct {
+		Habits []habiticaTask `json:"habits"`
+		Dailys []habiticaTask `json:"dailys"`
+	} `json:"tasks"`
+}
+
+type habiticaTask struct {
+	ID       string            `json:"id"`
+	Text     string            `json:"text"`
+	Notes    string            `json:"notes"`
+	Archived bool              `json:"archived"`
+	History  []habiticaHistory `json:"history"`
+}
+
+type habiticaHistory struct {
+	Date      json.RawMessage `json:"date"`
+	Completed *bool           `json:"completed"`
+	ScoredUp  *int            `json:"scoredUp"`
+}
+
+// ParseHabitica parses a Habitica user data export. Dailies become boolean
+// goals completed on the days Habitica recorded them as completed. Habits
+// become numeric goals counting the number of positive scores per day.
+func ParseHabitica(data []byte) (*Parsed, error) {
+	var export habiticaExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid Habitica export: %w", err)
+	}
+
+	parsed := &Parsed{}
+
+	for i, task := range export.Tasks.Dailys {
+		habit, ok := habiticaHabit(parsed, "tasks.dailys", i, task, goals.GoalTypeBoolean)
+		if !ok {
+			continue
+		}
+
+		completed := make(map[time.Time]bool)
+		for j, h := range task.History {
+			date, err := habiticaDate(h.Date)
+			if err != nil {
+				parsed.Errors = append(parsed.Errors, habiticaError(i, j, "dailys", "date", err.Error()))
+				continue
+			}
+			if h.Completed != nil && *h.Completed {
+				completed[date] = true
+			}
+		}
+		for date := range completed {
+			habit.Entries = append(habit.Entries, Entry{Date: date, Completed: true, Source: habit.Source})
+		}
+		sortEntries(habit.Entries)
+
+		parsed.Habits = append(parsed.Habits, habit)
+	}
+
+	for i, task := range export.Tasks.Habits {
+		habit, ok := habiticaHabit(parsed, "tasks.habits", i, task, goals.GoalTypeNumeric)
+		if !ok {
+			continue
+		}
+		unit := "times"
+		habit.Unit = &unit
+
+		counts := make(map[time.Time]float64)
+		for j, h := range task.History {
+			date, err := habiticaDate(h.Date)
+			if err != nil {
+				parsed.Errors = append(parsed.Errors, habiticaError(i, j, "habits", "date", err.Error()))
+				continue
+			}
+			if h.ScoredUp != nil && *h.ScoredUp > 0 {
+				counts[date] += float64(*h.ScoredUp)
+			}
+		}
+		for date, count := range counts {
+			value := count
+			habit.Entries = append(habit.Entries, Entry{Date: date, Value: &value, Completed: true, Source: habit.Source})
+		}
+		sortEntries(habit.Entries)
+
+		parsed.Habits = append(parsed.Habits, habit)
+	}
+
+	return parsed, nil
+}
+
+func habiticaHabit(parsed *Parsed, list string, index int, task habiticaTask, goalType goals.GoalType) (Habit, bool) {
+	title := strings.TrimSpace(task.Text)
+	if title == "" {
+		parsed.Errors = append(parsed.Errors, RowError{File: habiticaFile, Row: index + 1, Field: list + ".text", Message: "text is required"})
+		return Habit{}, false
+	}
+	if task.ID == "" {
+		parsed.Errors = append(parsed.Errors, RowError{File: habiticaFile, Row: index + 1, Field: list + ".id", Message: "id is required"})
+		return Habit{}, false
+	}
+
+	habit := Habit{
+		ExternalID: task.ID,
+		Title:      title,
+		GoalType:   goalType,
+		Archived:   task.Archived,
+		Source:     Source{File: habiticaFile, Row: index + 1},
+	}
+	if notes := strings.TrimSpace(task.Notes); notes != "" {
+		habit.Description = &notes
+	}
+	return habit, true
+}
+
+func habiticaError(task, entry int, list, field, message string) RowError {
+	return RowError{
+		File:    habiticaFile,
+		Row:     task + 1,
+		Field:   fmt.Sprintf("tasks.%s[%d].history[%d].%s", list, task, entry, field),
+		Message: message,
+	}
+}
+
+// habiticaDate accepts both the millisecond timestamps used by current
+// exports and the ISO 8601 strings used by older ones.
+func habiticaDate(raw json.RawMessage) (time.Time, error) {
+	var t time.Time
+
+	var ms float64
+	if err := json.Unmarshal(raw, &ms); err == nil {
+		t = time.UnixMilli(int64(ms)).UTC()
+	} else {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return time.Time{}, fmt.Errorf("expected a timestamp")
+		}
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			t = time.UnixMilli(n).UTC()
+		} else if t, err = time.Parse(time.RFC3339, s); err != nil {
+			return time.Time{}, fmt.Errorf("expected a timestamp")
+		}
+	}
+
+	return truncateDay(t), nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+}
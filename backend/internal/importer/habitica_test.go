@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseHabitica(t *testing.T) {
+	day := func(d, hour int) int64 {
+		return time.Date(2026, time.October, d, hour, 0, 0, 0, time.UTC).UnixMilli()
+	}
+
+	data := fmt.Sprintf(`{
+		"tasks": {
+			"dailys": [
+				{"id": "daily-1", "text": " Stretch ", "notes": "Ten minutes", "history": [
+					{"date": %d, "completed": true},
+					{"date": %d, "completed": true},
+					{"date": "2026-10-02T21:00:00Z", "completed": false},
+					{"date": "%d", "completed": true},
+					{"date": "last week", "completed": true}
+				]},
+				{"id": "daily-2", "text": "", "history": []}
+			],
+			"habits": [
+				{"id": "habit-1", "text": "Drink water", "archived": true, "history": [
+					{"date": %d, "scoredUp": 2, "scoredDown": 0},
+					{"date": %d, "scoredUp": 1, "scoredDown": 1},
+					{"date": %d, "scoredUp": 0, "scoredDown": 3}
+				]},
+				{"text": "No ID", "history": []}
+			]
+		}
+	}`, day(1, 7), day(1, 20), day(3, 9), day(1, 8), day(1, 18), day(2, 8))
+
+	parsed, err := ParseHabitica([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseHabitica: %v", err)
+	}
+
+	wantHabits(t, parsed, []string{
+		"daily-1 Stretch boolean description=Ten minutes: 2026-10-01✓ 2026-10-03✓",
+		"habit-1 Drink water numeric unit=times archived: 2026-10-01=3✓",
+	})
+	wantErrors(t, parsed, []RowError{
+		{File: habiticaFile, Row: 1, Field: "tasks.dailys[0].history[4].date", Message: "expected a timestamp"},
+		{File: habiticaFile, Row: 2, Field: "tasks.dailys.text", Message: "text is required"},
+		{File: habiticaFile, Row: 2, Field: "tasks.habits.id", Message: "id is required"},
+	})
+
+	if _, err := ParseHabitica([]byte("[]")); err == nil {
+		t.Error("ParseHabitica accepted JSON that is not an export")
+	}
+}
@@ -0,0 +1,133 @@
+// Package importer imports goals and history exported from other habit trackers
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+const maxImportBytes = 32 << 20
+
+type Handlers struct {
+	importRepo *Repository
+}
+
+func NewHandlers(importRepo *Repository) *Handlers {
+	return &Handlers{
+		importRepo: importRepo,
+	}
+}
+
+// HandleImport godoc
+// @Summary Import from another habit tracker
+// @Description Import goals and history from a Loop Habit Tracker CSV export (ZIP) or database backup, a Habitica JSON export, or a generic CSV file. The file is sent either as the raw request body or as the "file" field of a multipart form. Habits already imported from the same source are reused and days that already have an instance are skipped, so re-importing a file is safe. Rows that cannot be parsed, and habits and entries that fail the validation of the goals endpoints, are reported per row in errors and do not stop the import. With dry_run=true nothing is saved, and the same errors are reported.
+// @Tags import
+// @Accept octet-stream
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param format query string true "File format" Enums(loop-csv, loop-sqlite, habitica, csv)
+// @Param dry_run query bool false "Preview the import without saving it"
+// @Param goal_column query string false "CSV: column holding the goal name"
+// @Param date_column query string false "CSV: column holding the date"
+// @Param value_column query string false "CSV: column holding the numeric value"
+// @Param completed_column query string false "CSV: column holding whether the day was completed"
+// @Param date_format query string false "CSV: Go time layout of the date column (default 2006-01-02)"
+// @Param goal_type query string false "CSV: goal type for created goals (default numeric if value_column is set, otherwise boolean)"
+// @Param file formData file false "File to import, when sending a multipart form"
+// @Success 200 {object} ImportResult
+// @Failure 400 {object} apperr.Problem "Invalid format, mapping or file"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 413 {object} apperr.Problem "File too large"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /import [post]
+func (h *Handlers) HandleImport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	query := r.URL.Query()
+	format := Format(query.Get("format"))
+	dryRun := query.Get("dry_run") == "true"
+
+	data, err := readUpload(w, r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apperr.WriteProblem(w, r, http.StatusRequestEntityTooLarge, "File too large")
+			return
+		}
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid file: "+err.Error())
+		return
+	}
+	if len(data) == 0 {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "File is empty")
+		return
+	}
+
+	var parsed *Parsed
+	switch format {
+	case FormatLoopCSV:
+		parsed, err = ParseLoopCSV(data)
+	case FormatLoopSQLite:
+		parsed, err = ParseLoopSQLite(r.Context(), data)
+	case FormatHabitica:
+		parsed, err = ParseHabitica(data)
+	case FormatCSV:
+		parsed, err = ParseCSV(data, CSVMapping{
+			GoalColumn:      query.Get("goal_column"),
+			DateColumn:      query.Get("date_column"),
+			ValueColumn:     query.Get("value_column"),
+			CompletedColumn: query.Get("completed_column"),
+			DateFormat:      query.Get("date_format"),
+			GoalType:        goals.GoalType(query.Get("goal_type")),
+		})
+	default:
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid format")
+		return
+	}
+	if err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.importRepo.Import(r.Context(), userID, format, parsed, dryRun)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// readUpload returns the uploaded file from either a multipart "file" field
+// or the raw request body.
+func readUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return io.ReadAll(r.Body)
+	}
+
+	if err := r.ParseMultipartForm(maxImportBytes); err != nil {
+		return nil, err
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("missing file field")
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
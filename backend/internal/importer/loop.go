@@ -0,0 +1,368 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+
+	_ "modernc.org/sqlite"
+)
+
+// Loop Habit Tracker stores checkmark values as integers. Boolean habits use
+// the constants below; numerical habits store the amount multiplied by 1000.
+const (
+	loopYesManual      = 2
+	loopNumericScale   = 1000.0
+	loopTypeNumerical  = 1
+	loopTargetAtMost   = 1
+	loopHabitsFile     = "Habits.csv"
+	loopCheckmarksFile = "Checkmarks.csv"
+	loopDateLayout     = "2006-01-02"
+)
+
+// loopHabit is the habit metadata shared by Loop's CSV and SQLite exports.
+type loopHabit struct {
+	externalID  string
+	name        string
+	description string
+	numerical   bool
+	atMost      bool
+	target      float64
+	unit        string
+	archived    bool
+	source      Source
+}
+
+func (h loopHabit) toHabit() Habit {
+	habit := Habit{
+		ExternalID: h.externalID,
+		Title:      h.name,
+		GoalType:   goals.GoalTypeBoolean,
+		Archived:   h.archived,
+		Source:     h.source,
+	}
+	if h.description != "" {
+		description := h.description
+		habit.Description = &description
+	}
+	if h.numerical {
+		habit.GoalType = goals.GoalTypeNumeric
+		if h.target > 0 {
+			target := h.target
+			habit.TargetValue = &target
+		}
+		if h.unit != "" {
+			unit := h.unit
+			habit.Unit = &unit
+		}
+	}
+	return habit
+}
+
+// entry converts a raw Loop checkmark value into an Entry. It returns false
+// for values that do not represent progress, such as skips and unchecked days.
+func (h loopHabit) entry(date time.Time, raw int64) (Entry, bool) {
+	if !h.numerical {
+		return Entry{Date: date, Completed: true}, raw == loopYesManual
+	}
+
+	if raw <= 0 {
+		return Entry{}, false
+	}
+
+	value := float64(raw) / loopNumericScale
+	completed := value > 0
+	if h.target > 0 {
+		completed = value >= h.target
+		if h.atMost {
+			completed = value <= h.target
+		}
+	}
+	return Entry{Date: date, Value: &value, Completed: completed}, true
+}
+
+// ParseLoopCSV parses the ZIP produced by Loop's "Export as CSV". It reads
+// Habits.csv for habit metadata and each habit's folder Checkmarks.csv for
+// its history.
+func ParseLoopCSV(data []byte) (*Parsed, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("Loop CSV export must be a ZIP archive: %w", err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var habitsFile *zip.File
+	for name, f := range files {
+		if path.Base(name) == loopHabitsFile {
+			habitsFile = f
+			break
+		}
+	}
+	if habitsFile == nil {
+		return nil, fmt.Errorf("archive does not contain %s", loopHabitsFile)
+	}
+	root := path.Dir(habitsFile.Name)
+
+	records, err := readZipCSV(habitsFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", loopHabitsFile)
+	}
+
+	parsed := &Parsed{}
+	columns := headerIndex(records[0])
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("%s has no Name column", loopHabitsFile)
+	}
+
+	for i, record := range records[1:] {
+		row := i + 2
+		loop := loopHabit{
+			name:        strings.TrimSpace(field(record, columns, "name")),
+			description: field(record, columns, "description"),
+			unit:        field(record, columns, "unit"),
+			numerical:   field(record, columns, "type") == strconv.Itoa(loopTypeNumerical),
+			atMost:      field(record, columns, "target type") == strconv.Itoa(loopTargetAtMost),
+			archived:    strings.EqualFold(field(record, columns, "archived?"), "true"),
+			source:      Source{File: loopHabitsFile, Row: row},
+		}
+		loop.externalID = "name:" + loop.name
+
+		if loop.name == "" {
+			parsed.Errors = append(parsed.Errors, RowError{File: loopHabitsFile, Row: row, Field: "Name", Message: "name is required"})
+			continue
+		}
+
+		if targetStr := field(record, columns, "target value"); targetStr != "" {
+			target, err := strconv.ParseFloat(targetStr, 64)
+			if err != nil {
+				parsed.Errors = append(parsed.Errors, RowError{File: loopHabitsFile, Row: row, Field: "Target Value", Message: "not a number"})
+				continue
+			}
+			loop.target = target
+		}
+
+		habit := loop.toHabit()
+
+		// Each habit's files live in a folder named after its position and
+		// name, e.g. "001 Meditate/Checkmarks.csv".
+		position, _ := strconv.Atoi(field(record, columns, "position"))
+		checkmarksName := path.Join(root, fmt.Sprintf("%03d %s", position, loop.name), loopCheckmarksFile)
+		checkmarks, ok := files[checkmarksName]
+		if !ok {
+			parsed.Errors = append(parsed.Errors, RowError{File: loopHabitsFile, Row: row, Message: "missing " + checkmarksName})
+			parsed.Habits = append(parsed.Habits, habit)
+			continue
+		}
+
+		checkRecords, err := readZipCSV(checkmarks)
+		if err != nil {
+			return nil, err
+		}
+
+		for j, checkRecord := range checkRecords {
+			checkRow := j + 1
+			if len(checkRecord) < 2 {
+				parsed.Errors = append(parsed.Errors, RowError{File: checkmarksName, Row: checkRow, Message: "expected Date,Value"})
+				continue
+			}
+			date, err := time.Parse(loopDateLayout, strings.TrimSpace(checkRecord[0]))
+			if err != nil {
+				// Some Loop versions write a Date,Value header row.
+				if j == 0 {
+					continue
+				}
+				parsed.Errors = append(parsed.Errors, RowError{File: checkmarksName, Row: checkRow, Field: "Date", Message: "expected YYYY-MM-DD"})
+				continue
+			}
+			raw, err := strconv.ParseInt(strings.TrimSpace(checkRecord[1]), 10, 64)
+			if err != nil {
+				parsed.Errors = append(parsed.Errors, RowError{File: checkmarksName, Row: checkRow, Field: "Value", Message: "not an integer"})
+				continue
+			}
+			if entry, ok := loop.entry(date, raw); ok {
+				entry.Source = Source{File: checkmarksName, Row: checkRow}
+				habit.Entries = append(habit.Entries, entry)
+			}
+		}
+		sortEntries(habit.Entries)
+
+		parsed.Habits = append(parsed.Habits, habit)
+	}
+
+	return parsed, nil
+}
+
+// ParseLoopSQLite parses a Loop database backup (the .db file produced by
+// "Export full backup").
+func ParseLoopSQLite(ctx context.Context, data []byte) (*Parsed, error) {
+	f, err := os.CreateTemp("", "loop-import-*.db")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+f.Name()+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Loop backup: %w", err)
+	}
+	defer db.Close()
+
+	habits, err := readLoopHabits(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT habit, timestamp, value FROM Repetitions ORDER BY habit, timestamp`)
+	if err != nil {
+		return nil, fmt.Errorf("Loop backup has no readable Repetitions table: %w", err)
+	}
+	defer rows.Close()
+
+	parsed := &Parsed{}
+	entries := make(map[int64][]Entry)
+	row := 0
+	for rows.Next() {
+		row++
+		var habitID, timestamp, value int64
+		if err := rows.Scan(&habitID, &timestamp, &value); err != nil {
+			parsed.Errors = append(parsed.Errors, RowError{File: "Repetitions", Row: row, Message: err.Error()})
+			continue
+		}
+		loop, ok := habits[habitID]
+		if !ok {
+			parsed.Errors = append(parsed.Errors, RowError{File: "Repetitions", Row: row, Field: "habit", Message: "unknown habit"})
+			continue
+		}
+		date := truncateDay(time.UnixMilli(timestamp))
+		if entry, ok := loop.entry(date, value); ok {
+			entry.Source = Source{File: "Repetitions", Row: row}
+			entries[habitID] = append(entries[habitID], entry)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Loop repetitions: %w", err)
+	}
+
+	for id, loop := range habits {
+		habit := loop.toHabit()
+		habit.Entries = entries[id]
+		parsed.Habits = append(parsed.Habits, habit)
+	}
+	sort.Slice(parsed.Habits, func(i, j int) bool {
+		return parsed.Habits[i].Title < parsed.Habits[j].Title
+	})
+
+	return parsed, nil
+}
+
+// readLoopHabits reads the Habits table. Older backups predate numerical
+// habits and habit UUIDs, so it falls back to the columns every version has.
+func readLoopHabits(ctx context.Context, db *sql.DB) (map[int64]loopHabit, error) {
+	habits := make(map[int64]loopHabit)
+	row := 0
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, name, COALESCE(description, ''), archived,
+		       COALESCE(type, 0), COALESCE(target_type, 0), COALESCE(target_value, 0),
+		       COALESCE(unit, ''), COALESCE(uuid, '')
+		FROM Habits`)
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var id, archived, habitType, targetType int64
+			var h loopHabit
+			if err := rows.Scan(&id, &h.name, &h.description, &archived, &habitType, &targetType, &h.target, &h.unit, &h.externalID); err != nil {
+				return nil, fmt.Errorf("failed to read Loop habit: %w", err)
+			}
+			row++
+			h.source = Source{File: "Habits", Row: row}
+			h.archived = archived != 0
+			h.numerical = habitType == loopTypeNumerical
+			h.atMost = targetType == loopTargetAtMost
+			if h.externalID == "" {
+				h.externalID = "id:" + strconv.FormatInt(id, 10)
+			}
+			habits[id] = h
+		}
+		return habits, rows.Err()
+	}
+
+	rows, err = db.QueryContext(ctx, `SELECT id, name, COALESCE(description, ''), archived FROM Habits`)
+	if err != nil {
+		return nil, fmt.Errorf("Loop backup has no readable Habits table: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, archived int64
+		var h loopHabit
+		if err := rows.Scan(&id, &h.name, &h.description, &archived); err != nil {
+			return nil, fmt.Errorf("failed to read Loop habit: %w", err)
+		}
+		row++
+		h.source = Source{File: "Habits", Row: row}
+		h.archived = archived != 0
+		h.externalID = "id:" + strconv.FormatInt(id, 10)
+		habits[id] = h
+	}
+	return habits, rows.Err()
+}
+
+func readZipCSV(f *zip.File) ([][]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	r := csv.NewReader(rc)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return records, nil
+}
+
+// headerIndex maps lower-cased, trimmed header names to column indexes.
+func headerIndex(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	return columns
+}
+
+func field(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
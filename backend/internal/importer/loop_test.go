@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// describe renders parsed habits compactly for comparison, with each entry
+// as its date, value and a check mark if completed.
+func describe(habits []Habit) []string {
+	var out []string
+	for _, h := range habits {
+		s := fmt.Sprintf("%s %s %s", h.ExternalID, h.Title, h.GoalType)
+		if h.Description != nil {
+			s += " description=" + *h.Description
+		}
+		if h.TargetValue != nil {
+			s += " target=" + strconv.FormatFloat(*h.TargetValue, 'f', -1, 64)
+		}
+		if h.Unit != nil {
+			s += " unit=" + *h.Unit
+		}
+		if h.Archived {
+			s += " archived"
+		}
+		s += ":"
+		for _, e := range h.Entries {
+			s += " " + e.Date.Format(time.DateOnly)
+			if e.Value != nil {
+				s += "=" + strconv.FormatFloat(*e.Value, 'f', -1, 64)
+			}
+			if e.Completed {
+				s += "✓"
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func wantHabits(t *testing.T, parsed *Parsed, want []string) {
+	t.Helper()
+	if got := describe(parsed.Habits); !slices.Equal(got, want) {
+		t.Errorf("parsed habits\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func wantErrors(t *testing.T, parsed *Parsed, want []RowError) {
+	t.Helper()
+	if !slices.Equal(parsed.Errors, want) {
+		t.Errorf("parse errors are %+v, want %+v", parsed.Errors, want)
+	}
+}
+
+func TestParseLoopCSV(t *testing.T) {
+	const root = "Loop Habits CSV 2026-10-19"
+	files := map[string]string{
+		root + "/Habits.csv": "Position,Name,Type,Question,Description,NumRepetitions,Interval,Color,Unit,Target Type,Target Value,Archived?\n" +
+			"001,Meditate,0,,Sit still,1,1,#FF0000,,0,0,false\n" +
+			"002,Run,1,,,1,1,#00FF00,km,0,5,false\n" +
+			"003,Junk food,1,,,1,1,#000000,cal,1,500,true\n" +
+			"004,Floss,0,,,1,1,#0000FF,,0,0,false\n" +
+			"005,,0,,,1,1,#0000FF,,0,0,false\n",
+		root + "/001 Meditate/Checkmarks.csv":  "2026-10-03,2\n2026-10-02,0\n2026-10-01,2\n",
+		root + "/002 Run/Checkmarks.csv":       "Date,Value\n2026-10-01,6000\n2026-10-02,3000\n2026-10-03,0\nyesterday,2000\n",
+		root + "/003 Junk food/Checkmarks.csv": "2026-10-01,400000\n2026-10-02,600000\n",
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	parsed, err := ParseLoopCSV(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseLoopCSV: %v", err)
+	}
+
+	wantHabits(t, parsed, []string{
+		"name:Meditate Meditate boolean description=Sit still: 2026-10-01✓ 2026-10-03✓",
+		"name:Run Run numeric target=5 unit=km: 2026-10-01=6✓ 2026-10-02=3",
+		"name:Junk food Junk food numeric target=500 unit=cal archived: 2026-10-01=400✓ 2026-10-02=600",
+		"name:Floss Floss boolean:",
+	})
+	wantErrors(t, parsed, []RowError{
+		{File: root + "/002 Run/Checkmarks.csv", Row: 5, Field: "Date", Message: "expected YYYY-MM-DD"},
+		{File: loopHabitsFile, Row: 5, Message: "missing " + root + "/004 Floss/Checkmarks.csv"},
+		{File: loopHabitsFile, Row: 6, Field: "Name", Message: "name is required"},
+	})
+	if got := parsed.Habits[1].Entries[1].Source; got != (Source{File: root + "/002 Run/Checkmarks.csv", Row: 3}) {
+		t.Errorf("entry source is %+v, want row 3 of Run's checkmarks", got)
+	}
+
+	if _, err := ParseLoopCSV([]byte("not a zip")); err == nil {
+		t.Error("ParseLoopCSV accepted a file that is not a ZIP archive")
+	}
+}
+
+func TestParseLoopSQLite(t *testing.T) {
+	day := func(d int) int64 {
+		return time.Date(2026, time.October, d, 8, 0, 0, 0, time.UTC).UnixMilli()
+	}
+
+	tests := []struct {
+		name   string
+		schema string
+		habits []string
+		want   []string
+	}{
+		{
+			name: "current",
+			schema: `CREATE TABLE Habits (id INTEGER PRIMARY KEY, name TEXT, description TEXT, archived INTEGER,
+				type INTEGER, target_type INTEGER, target_value REAL, unit TEXT, uuid TEXT)`,
+			habits: []string{
+				`INSERT INTO Habits VALUES (1, 'Meditate', 'Sit still', 0, 0, 0, 0, NULL, 'c0ffee')`,
+				`INSERT INTO Habits VALUES (2, 'Run', NULL, 1, 1, 0, 5, 'km', NULL)`,
+			},
+			want: []string{
+				"c0ffee Meditate boolean description=Sit still: 2026-10-01✓",
+				"id:2 Run numeric target=5 unit=km archived: 2026-10-01=6✓ 2026-10-02=3",
+			},
+		},
+		{
+			name:   "without numerical habits",
+			schema: `CREATE TABLE Habits (id INTEGER PRIMARY KEY, name TEXT, description TEXT, archived INTEGER)`,
+			habits: []string{
+				`INSERT INTO Habits VALUES (1, 'Meditate', 'Sit still', 0)`,
+				`INSERT INTO Habits VALUES (2, 'Run', NULL, 1)`,
+			},
+			want: []string{
+				"id:1 Meditate boolean description=Sit still: 2026-10-01✓",
+				"id:2 Run boolean archived:",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "loop.db")
+			db, err := sql.Open("sqlite", path)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			statements := append([]string{tt.schema, `CREATE TABLE Repetitions (id INTEGER PRIMARY KEY, habit INTEGER, timestamp INTEGER, value INTEGER)`}, tt.habits...)
+			statements = append(statements,
+				fmt.Sprintf(`INSERT INTO Repetitions (habit, timestamp, value) VALUES (1, %d, 2), (1, %d, 0)`, day(1), day(2)),
+				fmt.Sprintf(`INSERT INTO Repetitions (habit, timestamp, value) VALUES (2, %d, 6000), (2, %d, 3000)`, day(1), day(2)),
+				fmt.Sprintf(`INSERT INTO Repetitions (habit, timestamp, value) VALUES (9, %d, 2)`, day(3)),
+			)
+			for _, statement := range statements {
+				if _, err := db.Exec(statement); err != nil {
+					t.Fatalf("%s: %v", statement, err)
+				}
+			}
+			db.Close()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile: %v", err)
+			}
+			parsed, err := ParseLoopSQLite(t.Context(), data)
+			if err != nil {
+				t.Fatalf("ParseLoopSQLite: %v", err)
+			}
+
+			wantHabits(t, parsed, tt.want)
+			wantErrors(t, parsed, []RowError{{File: "Repetitions", Row: 5, Field: "habit", Message: "unknown habit"}})
+		})
+	}
+}
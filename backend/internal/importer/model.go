@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+// Format identifies the kind of file being imported.
+type Format string
+
+const (
+	FormatLoopCSV    Format = "loop-csv"
+	FormatLoopSQLite Format = "loop-sqlite"
+	FormatHabitica   Format = "habitica"
+	FormatCSV        Format = "csv"
+)
+
+// Habit is a goal parsed from another tracker, along with its history.
+// ExternalID identifies the habit within its source so re-importing the
+// same file maps onto the same goal.
+type Habit struct {
+	ExternalID  string
+	Title       string
+	Description *string
+	GoalType    goals.GoalType
+	TargetValue *float64
+	Unit        *string
+	Archived    bool
+	Entries     []Entry
+	Source      Source
+}
+
+// Entry is one day of history for a Habit.
+type Entry struct {
+	Date      time.Time
+	Value     *float64
+	Completed bool
+	Source    Source
+}
+
+// Source is where in the imported file a habit or entry was read from, so
+// problems found after parsing are reported against its row.
+type Source struct {
+	File string
+	Row  int
+}
+
+// RowError describes a row that could not be imported. Row is 1-based and
+// counts the header, matching what spreadsheet apps show.
+type RowError struct {
+	File    string `json:"file"`
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Parsed is the output of a parser: the habits it understood and the rows
+// it had to skip.
+type Parsed struct {
+	Habits []Habit
+	Errors []RowError
+}
+
+// CSVMapping tells the generic CSV parser which columns hold what.
+type CSVMapping struct {
+	GoalColumn      string
+	DateColumn      string
+	ValueColumn     string
+	CompletedColumn string
+	DateFormat      string
+	GoalType        goals.GoalType
+}
+
+type HabitSummary struct {
+	Title      string         `json:"title"`
+	GoalType   goals.GoalType `json:"goal_type"`
+	GoalID     *string        `json:"goal_id"`
+	Existing   bool           `json:"existing"`
+	Entries    int            `json:"entries"`
+	NewEntries int            `json:"new_entries"`
+	FirstDate  *string        `json:"first_date"`
+	LastDate   *string        `json:"last_date"`
+}
+
+type ImportResult struct {
+	DryRun         bool           `json:"dry_run"`
+	Format         Format         `json:"format"`
+	Habits         []HabitSummary `json:"habits"`
+	GoalsCreated   int            `json:"goals_created"`
+	GoalsReused    int            `json:"goals_reused"`
+	EntriesAdded   int            `json:"entries_added"`
+	EntriesSkipped int            `json:"entries_skipped"`
+	Errors         []RowError     `json:"errors"`
+}
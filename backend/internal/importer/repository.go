@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const dateLayout = "2006-01-02"
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Import writes parsed habits for a user in a single transaction. Each habit
+// is mapped to a goal through imported_goals, keyed on the source format and
+// the habit's external ID, so importing the same file twice reuses the goals
+// created the first time. Days that already have an instance are left alone.
+// Habits and entries that fail validation are reported in the result's
+// errors and skipped, and the rest are imported.
+//
+// A dry run performs the same work and rolls it back, so the preview reports
+// exactly what a real import would create.
+func (r *Repository) Import(ctx context.Context, userID string, format Format, parsed *Parsed, dryRun bool) (*ImportResult, error) {
+	habits, rowErrors := validate(parsed)
+
+	result := &ImportResult{
+		DryRun: dryRun,
+		Format: format,
+		Habits: []HabitSummary{},
+		Errors: append(append([]RowError{}, parsed.Errors...), rowErrors...),
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialise imports per user so two concurrent uploads of the same file
+	// cannot both create a goal for the same habit.
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('import:' || $1))`, userID); err != nil {
+		return nil, fmt.Errorf("failed to lock imports: %w", err)
+	}
+
+	for _, habit := range habits {
+		summary := HabitSummary{
+			Title:    habit.Title,
+			GoalType: habit.GoalType,
+			Entries:  len(habit.Entries),
+		}
+		if len(habit.Entries) > 0 {
+			first := habit.Entries[0].Date.Format(dateLayout)
+			last := habit.Entries[len(habit.Entries)-1].Date.Format(dateLayout)
+			summary.FirstDate = &first
+			summary.LastDate = &last
+		}
+
+		goalID, existing, err := r.getOrCreateGoal(ctx, tx, userID, format, habit)
+		if err != nil {
+			return nil, err
+		}
+		summary.GoalID = &goalID
+		summary.Existing = existing
+		if existing {
+			result.GoalsReused++
+		} else {
+			result.GoalsCreated++
+		}
+
+		added, err := r.insertEntries(ctx, tx, userID, goalID, habit.Entries)
+		if err != nil {
+			return nil, err
+		}
+		summary.NewEntries = added
+		result.EntriesAdded += added
+		result.EntriesSkipped += len(habit.Entries) - added
+
+		result.Habits = append(result.Habits, summary)
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *Repository) getOrCreateGoal(ctx context.Context, tx *sql.Tx, userID string, format Format, habit Habit) (string, bool, error) {
+	var goalID string
+	query := `
+		SELECT goal_id FROM imported_goals
+		WHERE user_id = $1 AND source = $2 AND external_id = $3
+	`
+	err := tx.QueryRowContext(ctx, query, userID, format, habit.ExternalID).Scan(&goalID)
+	if err == nil {
+		return goalID, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", false, fmt.Errorf("failed to look up imported goal: %w", err)
+	}
+
+	createQuery := `
+		INSERT INTO goals (user_id, title, description, goal_type, target_value, unit, visibility, difficulty, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, 'private', 'medium', $7)
+		RETURNING id
+	`
+	err = tx.QueryRowContext(ctx, createQuery, userID, habit.Title, habit.Description, habit.GoalType,
+		habit.TargetValue, habit.Unit, !habit.Archived).Scan(&goalID)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create goal: %w", err)
+	}
+
+	mappingQuery := `
+		INSERT INTO imported_goals (user_id, source, external_id, goal_id)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.ExecContext(ctx, mappingQuery, userID, format, habit.ExternalID, goalID); err != nil {
+		return "", false, fmt.Errorf("failed to record imported goal: %w", err)
+	}
+
+	return goalID, false, nil
+}
+
+// insertEntries adds one instance per entry in a single statement and
+// returns how many were new.
+func (r *Repository) insertEntries(ctx context.Context, tx *sql.Tx, userID, goalID string, entries []Entry) (int, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	dates := make([]string, len(entries))
+	values := make([]sql.NullFloat64, len(entries))
+	completed := make([]bool, len(entries))
+	for i, entry := range entries {
+		dates[i] = entry.Date.Format(dateLayout)
+		if entry.Value != nil {
+			values[i] = sql.NullFloat64{Float64: *entry.Value, Valid: true}
+		}
+		completed[i] = entry.Completed
+	}
+
+	query := `
+		INSERT INTO daily_goal_instances (goal_id, user_id, date, target_value, completed_value, is_completed, completed_at)
+		SELECT g.id, g.user_id, e.date, g.target_value, e.value, e.completed,
+		       CASE WHEN e.completed THEN e.date::timestamp END
+		FROM goals g,
+		     unnest($3::date[], $4::float8[], $5::bool[]) AS e(date, value, completed)
+		WHERE g.id = $1 AND g.user_id = $2
+		ON CONFLICT (goal_id, date) DO NOTHING
+	`
+	result, err := tx.ExecContext(ctx, query, goalID, userID, pq.Array(dates), pq.Array(values), pq.Array(completed))
+	if err != nil {
+		return 0, fmt.Errorf("failed to import daily instances: %w", err)
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(added), nil
+}
@@ -0,0 +1,46 @@
+package importer_test
+
+import (
+	"testing"
+
+	"github.com/JoshPugli/grindhouse-api/internal/importer"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+)
+
+// TestImportDryRun imports a file as a dry run and for real, which should
+// agree, and then imports it again, which should reuse the goal and add
+// nothing.
+func TestImportDryRun(t *testing.T) {
+	db := storetest.OpenPostgres(t)
+	ctx := t.Context()
+
+	owner := storetest.NewUser(t, db)
+
+	parsed, err := importer.ParseCSV([]byte("goal,date,value\nRun,2026-10-01,5\nRun,2026-10-02,-1\n"), importer.CSVMapping{GoalColumn: "goal", DateColumn: "date", ValueColumn: "value"})
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+
+	repo := importer.NewRepository(db)
+	for _, dryRun := range []bool{true, false} {
+		result, err := repo.Import(ctx, owner.ID, importer.FormatCSV, parsed, dryRun)
+		if err != nil {
+			t.Fatalf("Import (dry run %v): %v", dryRun, err)
+		}
+		if result.GoalsCreated != 1 || result.EntriesAdded != 1 {
+			t.Errorf("dry run %v created %d goals and %d entries, want 1 and 1", dryRun, result.GoalsCreated, result.EntriesAdded)
+		}
+		if len(result.Errors) != 1 || result.Errors[0].Row != 3 || result.Errors[0].Field != "value" {
+			t.Errorf("dry run %v reported %+v, want the negative value on row 3", dryRun, result.Errors)
+		}
+	}
+
+	again, err := repo.Import(ctx, owner.ID, importer.FormatCSV, parsed, false)
+	if err != nil {
+		t.Fatalf("Import again: %v", err)
+	}
+	if again.GoalsCreated != 0 || again.GoalsReused != 1 || again.EntriesAdded != 0 || again.EntriesSkipped != 1 {
+		t.Errorf("re-import created %d goals, reused %d, added %d entries and skipped %d, want 0, 1, 0 and 1",
+			again.GoalsCreated, again.GoalsReused, again.EntriesAdded, again.EntriesSkipped)
+	}
+}
@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+// validate checks parsed habits with the goals API's rules before anything
+// is written: each habit as a goal of its type, leaving out the target other
+// trackers do not require, and each entry's value as a completed value. It
+// returns the habits that passed and a row error for every problem. A habit
+// that fails is skipped with its history; an entry that fails is skipped on
+// its own.
+func validate(parsed *Parsed) ([]Habit, []RowError) {
+	var habits []Habit
+	var rowErrors []RowError
+
+	for _, habit := range parsed.Habits {
+		goal := goals.UpdateGoalRequest{Title: &habit.Title, TargetValue: habit.TargetValue, Unit: habit.Unit}
+		err := goal.Validate()
+		if err == nil {
+			err = goal.ValidateFor(habit.GoalType)
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, fieldErrors(habit.Source, err, "")...)
+			continue
+		}
+
+		entries := make([]Entry, 0, len(habit.Entries))
+		for _, entry := range habit.Entries {
+			if err := (goals.UpdateDailyInstanceRequest{CompletedValue: entry.Value}).Validate(); err != nil {
+				rowErrors = append(rowErrors, fieldErrors(entry.Source, err, "value")...)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		habit.Entries = entries
+
+		habits = append(habits, habit)
+	}
+
+	return habits, rowErrors
+}
+
+// fieldErrors reports each field problem in err as a row error at source. If
+// field is set it replaces the goals API's name for the field.
+func fieldErrors(source Source, err error, field string) []RowError {
+	fields := apperr.FieldsOf(err)
+	rowErrors := make([]RowError, len(fields))
+	for i, f := range fields {
+		name := f.Field
+		if field != "" {
+			name = field
+		}
+		rowErrors[i] = RowError{File: source.File, Row: source.Row, Field: name, Message: f.Message}
+	}
+	return rowErrors
+}
@@ -0,0 +1,45 @@
+package importer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	// Titles are limited in characters, not bytes: 255 two-byte runes fit.
+	longTitle := strings.Repeat("é", 255)
+	csv := "goal,date,value\n" +
+		"Run,2026-10-01,5\n" +
+		"Run,2026-10-02,-1\n" +
+		"Run,2026-10-03,100000000\n" +
+		longTitle + ",2026-10-01,1\n" +
+		longTitle + "é,2026-10-01,1\n"
+
+	parsed, err := ParseCSV([]byte(csv), CSVMapping{GoalColumn: "goal", DateColumn: "date", ValueColumn: "value"})
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+
+	habits, rowErrors := validate(parsed)
+
+	titles := make([]string, len(habits))
+	for i, habit := range habits {
+		titles[i] = habit.Title
+	}
+	if want := []string{"Run", longTitle}; !slices.Equal(titles, want) {
+		t.Errorf("valid habits are %d long, want Run and the 255 character title", len(titles))
+	}
+	if len(habits) > 0 && len(habits[0].Entries) != 1 {
+		t.Errorf("Run kept %d entries, want only the valid one", len(habits[0].Entries))
+	}
+
+	want := []RowError{
+		{File: csvFile, Row: 3, Field: "value", Message: "must not be negative"},
+		{File: csvFile, Row: 4, Field: "value", Message: "must be at most 99999999.99"},
+		{File: csvFile, Row: 6, Field: "title", Message: "must be at most 255 characters"},
+	}
+	if !slices.Equal(rowErrors, want) {
+		t.Errorf("row errors are %+v, want %+v", rowErrors, want)
+	}
+}
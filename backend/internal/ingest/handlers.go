@@ -0,0 +1,253 @@
+// Package ingest lets other apps push numeric progress into goals using per-goal tokens
+package ingest
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/google/uuid"
+)
+
+const (
+	maxValuesPerRequest = 500
+	maxIngestBodyBytes  = 1 << 20
+)
+
+type Handlers struct {
+	ingestRepo *Repository
+	goalRepo   goals.GoalStore
+	listeners  goals.Listeners
+	// clock dates values sent without a timestamp.
+	clock func() time.Time
+}
+
+func NewHandlers(ingestRepo *Repository, goalRepo goals.GoalStore, listeners goals.Listeners, clock func() time.Time) *Handlers {
+	return &Handlers{
+		ingestRepo: ingestRepo,
+		goalRepo:   goalRepo,
+		listeners:  listeners,
+		clock:      clock,
+	}
+}
+
+// HandleCreateToken godoc
+// @Summary Create an ingestion token
+// @Description Create a token that lets another app push values into a numeric or duration goal. The token is only returned in this response.
+// @Tags ingest
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param token body CreateTokenRequest true "Goal and default mode (replace or accumulate, defaults to replace)"
+// @Success 201 {object} Token
+// @Failure 400 {object} apperr.Problem "Invalid JSON, mode or goal type"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Goal not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /ingest-tokens [post]
+func (h *Handlers) HandleCreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = ModeReplace
+	}
+
+	if !req.Mode.Valid() {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid mode")
+		return
+	}
+
+	if _, err := uuid.Parse(req.GoalID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid goal ID")
+		return
+	}
+
+	goal, err := h.goalRepo.GetGoalByID(r.Context(), req.GoalID, userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	if !goal.IsActive {
+		apperr.WriteProblem(w, r, http.StatusNotFound, "Goal not found")
+		return
+	}
+
+	if goal.GoalType == goals.GoalTypeBoolean {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Ingestion is only supported for numeric and duration goals")
+		return
+	}
+
+	token, err := h.ingestRepo.CreateToken(r.Context(), userID, goal.ID, req.Mode)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+// HandleGetTokens godoc
+// @Summary List ingestion tokens
+// @Description List the authenticated user's active ingestion tokens. Only token prefixes are returned.
+// @Tags ingest
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} Token
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /ingest-tokens [get]
+func (h *Handlers) HandleGetTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	tokens, err := h.ingestRepo.GetTokens(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// HandleRevokeToken godoc
+// @Summary Revoke an ingestion token
+// @Description Revoke an ingestion token so it can no longer push values
+// @Tags ingest
+// @Security BearerAuth
+// @Param tokenId path string true "Token ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} apperr.Problem "Invalid token ID"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Token not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /ingest-tokens/{tokenId} [delete]
+func (h *Handlers) HandleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	tokenID := r.PathValue("tokenId")
+	if _, err := uuid.Parse(tokenID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid token ID")
+		return
+	}
+
+	if err := h.ingestRepo.RevokeToken(r.Context(), tokenID, userID); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleIngest godoc
+// @Summary Ingest values
+// @Description Push one or more values into the token's goal. Each value lands on the daily instance for the calendar date of its timestamp (in the timestamp's own offset, defaulting to now). Values with a source_id already seen for the goal are skipped. In replace mode the value overwrites the day's completed value; in accumulate mode it is added to it. In accumulate mode a value needs a source_id or a timestamp, from which one is derived, so that retries are not counted twice. Values that are negative, over 99999999.99, or would take the day's total over it are rejected individually and the rest are still applied.
+// @Tags ingest
+// @Accept json
+// @Produce json
+// @Param token path string true "Ingestion token"
+// @Param values body IngestRequest true "Values to ingest"
+// @Success 200 {object} IngestResponse
+// @Failure 400 {object} apperr.Problem "Invalid JSON or mode"
+// @Failure 404 {object} apperr.Problem "Token not found"
+// @Failure 413 {object} apperr.Problem "Too many values"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /ingest/{token} [post]
+func (h *Handlers) HandleIngest(w http.ResponseWriter, r *http.Request) {
+	owner, err := h.ingestRepo.lookupToken(r.Context(), r.PathValue("token"))
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	var req IngestRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxIngestBodyBytes)).Decode(&req); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if len(req.Values) > maxValuesPerRequest {
+		apperr.WriteProblem(w, r, http.StatusRequestEntityTooLarge, "Too many values")
+		return
+	}
+
+	mode := owner.Mode
+	if req.Mode != nil {
+		if !req.Mode.Valid() {
+			apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid mode")
+			return
+		}
+		mode = *req.Mode
+	}
+
+	goal, err := h.goalRepo.GetGoalByID(r.Context(), owner.GoalID, owner.UserID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	response := IngestResponse{Instances: []goals.DailyGoalInstance{}, Rejected: []Rejection{}}
+	for i, value := range req.Values {
+		if err := value.validate(mode); err != nil {
+			response.Rejected = append(response.Rejected, Rejection{Index: i, Message: apperr.Summary(err)})
+			continue
+		}
+
+		timestamp := h.clock().UTC()
+		if value.Timestamp != nil {
+			timestamp = *value.Timestamp
+		}
+		date := time.Date(timestamp.Year(), timestamp.Month(), timestamp.Day(), 0, 0, 0, 0, time.UTC)
+
+		applied, err := h.ingestRepo.apply(r.Context(), owner, value.Value, date, value.sourceKey(mode), mode)
+		if apperr.KindOf(err) == apperr.KindValidation {
+			response.Rejected = append(response.Rejected, Rejection{Index: i, Message: apperr.Summary(err)})
+			continue
+		}
+		if err != nil {
+			apperr.Write(w, r, err)
+			return
+		}
+
+		if applied == nil {
+			response.Duplicates++
+			continue
+		}
+
+		response.Accepted++
+		response.Instances = append(response.Instances, *applied.Instance)
+		h.listeners.Notify(r.Context(), goals.Change{
+			Kind:     goals.InstanceUpdated,
+			UserID:   owner.UserID,
+			Goal:     goal,
+			Instance: applied.Instance,
+			Previous: applied.Previous,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
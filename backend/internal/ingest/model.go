@@ -0,0 +1,107 @@
+package ingest
+
+import (
+	"errors"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+// maxSourceIDLength is the length of the ingested_values.external_id column.
+const maxSourceIDLength = 255
+
+// Mode controls how an ingested value is combined with the day's existing
+// completed value.
+type Mode string
+
+const (
+	ModeReplace    Mode = "replace"
+	ModeAccumulate Mode = "accumulate"
+)
+
+// Valid reports whether m is a known mode.
+func (m Mode) Valid() bool {
+	return m == ModeReplace || m == ModeAccumulate
+}
+
+// Token authorises pushing values to a single goal. The secret itself is
+// only returned when the token is created; afterwards only its prefix is
+// shown.
+type Token struct {
+	ID          string     `json:"id" db:"id"`
+	GoalID      string     `json:"goal_id" db:"goal_id"`
+	Token       string     `json:"token,omitempty"`
+	TokenPrefix string     `json:"token_prefix" db:"token_prefix"`
+	Mode        Mode       `json:"mode" db:"mode"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at" db:"last_used_at"`
+}
+
+type CreateTokenRequest struct {
+	GoalID string `json:"goal_id"`
+	Mode   Mode   `json:"mode"`
+}
+
+type Value struct {
+	Value     float64    `json:"value"`
+	Timestamp *time.Time `json:"timestamp"`
+	SourceID  string     `json:"source_id"`
+}
+
+// validate checks the value with the rules the goals API applies to a
+// completed value, and that a value ingested in accumulate mode can be told
+// apart from a retry of itself.
+func (v Value) validate(mode Mode) error {
+	var fields apperr.Fields
+
+	var appErr *apperr.Error
+	if err := (goals.UpdateDailyInstanceRequest{CompletedValue: &v.Value}).Validate(); errors.As(err, &appErr) {
+		for _, f := range appErr.Fields {
+			fields.Add("value", f.Message)
+		}
+	}
+
+	if utf8.RuneCountInString(v.SourceID) > maxSourceIDLength {
+		fields.Add("source_id", "must be at most 255 characters")
+	}
+	if mode == ModeAccumulate && v.SourceID == "" && v.Timestamp == nil {
+		fields.Add("source_id", "is required in accumulate mode unless timestamp is set")
+	}
+
+	return fields.Err()
+}
+
+// sourceKey returns what identifies the value among those ingested for the
+// goal: its source_id or, in accumulate mode, a key derived from its
+// timestamp and value, so a retried request is not counted twice. Replace
+// mode needs no key, as applying a value again changes nothing.
+func (v Value) sourceKey(mode Mode) string {
+	if v.SourceID != "" || mode != ModeAccumulate || v.Timestamp == nil {
+		return v.SourceID
+	}
+	return "derived:" + v.Timestamp.UTC().Format(time.RFC3339Nano) + "/" + strconv.FormatFloat(v.Value, 'f', -1, 64)
+}
+
+// IngestRequest carries one or more values. Mode overrides the token's
+// default mode for this request.
+type IngestRequest struct {
+	Mode   *Mode   `json:"mode"`
+	Values []Value `json:"values"`
+}
+
+// Rejection explains why a value was not applied. Index is the value's
+// position in the request.
+type Rejection struct {
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
+
+type IngestResponse struct {
+	Accepted   int                       `json:"accepted"`
+	Duplicates int                       `json:"duplicates"`
+	Instances  []goals.DailyGoalInstance `json:"instances"`
+	Rejected   []Rejection               `json:"rejected"`
+}
@@ -0,0 +1,222 @@
+package ingest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+const (
+	tokenPrefix       = "ing_"
+	tokenPrefixLength = 12
+)
+
+type Repository struct {
+	db       *sql.DB
+	goalRepo *goals.Repository
+}
+
+func NewRepository(db *sql.DB, goalRepo *goals.Repository) *Repository {
+	return &Repository{db: db, goalRepo: goalRepo}
+}
+
+// tokenOwner is what a presented token resolves to.
+type tokenOwner struct {
+	ID     string
+	UserID string
+	GoalID string
+	Mode   Mode
+}
+
+// Applied is the outcome of applying one value to a daily instance.
+type Applied struct {
+	Instance *goals.DailyGoalInstance
+	Previous *goals.DailyGoalInstance
+}
+
+func (r *Repository) CreateToken(ctx context.Context, userID, goalID string, mode Mode) (*Token, error) {
+	secret, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	token := &Token{
+		GoalID:      goalID,
+		Token:       secret,
+		TokenPrefix: secret[:tokenPrefixLength],
+		Mode:        mode,
+	}
+
+	query := `
+		INSERT INTO ingestion_tokens (user_id, goal_id, token_hash, token_prefix, mode)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err = r.db.QueryRowContext(ctx, query, userID, goalID, hashToken(secret), token.TokenPrefix, mode).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ingestion token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *Repository) GetTokens(ctx context.Context, userID string) ([]Token, error) {
+	query := `
+		SELECT id, goal_id, token_prefix, mode, created_at, last_used_at
+		FROM ingestion_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ingestion tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []Token{}
+	for rows.Next() {
+		var token Token
+		if err := rows.Scan(&token.ID, &token.GoalID, &token.TokenPrefix, &token.Mode, &token.CreatedAt, &token.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ingestion token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+func (r *Repository) RevokeToken(ctx context.Context, tokenID, userID string) error {
+	query := `UPDATE ingestion_tokens SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke ingestion token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("token not found")
+	}
+
+	return nil
+}
+
+// lookupToken resolves a presented token to its goal, provided the token has
+// not been revoked and the goal is still active.
+func (r *Repository) lookupToken(ctx context.Context, secret string) (*tokenOwner, error) {
+	query := `
+		UPDATE ingestion_tokens t
+		SET last_used_at = NOW()
+		FROM goals g
+		WHERE t.token_hash = $1 AND t.revoked_at IS NULL AND g.id = t.goal_id AND g.is_active = true
+		RETURNING t.id, t.user_id, t.goal_id, t.mode
+	`
+	var owner tokenOwner
+	err := r.db.QueryRowContext(ctx, query, hashToken(secret)).Scan(&owner.ID, &owner.UserID, &owner.GoalID, &owner.Mode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("token not found")
+		}
+		return nil, fmt.Errorf("failed to get ingestion token: %w", err)
+	}
+
+	return &owner, nil
+}
+
+// apply records value against the goal's instance for date with the goals
+// repository's upsert, creating the instance if needed, and marks the
+// instance completed once its value reaches the target. A value whose
+// sourceID has already been ingested for the goal is skipped and apply
+// returns nil. The dedupe record and the instance update commit together,
+// so a failed request can safely be retried.
+func (r *Repository) apply(ctx context.Context, owner *tokenOwner, value float64, date time.Time, sourceID string, mode Mode) (*Applied, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var externalID *string
+	if sourceID != "" {
+		externalID = &sourceID
+	}
+
+	dedupeQuery := `
+		INSERT INTO ingested_values (token_id, goal_id, external_id, date, value)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (goal_id, external_id) DO NOTHING
+	`
+	result, err := tx.ExecContext(ctx, dedupeQuery, owner.ID, owner.GoalID, externalID, date, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record ingested value: %w", err)
+	}
+	if inserted, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	} else if inserted == 0 {
+		return nil, nil
+	}
+
+	var upserted *goals.UpsertedInstance
+	if mode == ModeAccumulate {
+		upserted, err = r.goalRepo.AddToDailyInstanceTx(ctx, tx, owner.GoalID, owner.UserID, date, value)
+	} else {
+		upserted, err = r.goalRepo.UpsertDailyInstanceTx(ctx, tx, owner.GoalID, owner.UserID, date, goals.UpdateDailyInstanceRequest{CompletedValue: &value}, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The instance stays locked by tx, so its version cannot have moved on.
+	instance := upserted.Instance
+	if completed := reachedTarget(instance); completed != instance.IsCompleted {
+		completion, err := r.goalRepo.UpsertDailyInstanceTx(ctx, tx, owner.GoalID, owner.UserID, date, goals.UpdateDailyInstanceRequest{IsCompleted: &completed}, goals.Versions{instance.Version})
+		if err != nil {
+			return nil, err
+		}
+		instance = completion.Instance
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit ingested value: %w", err)
+	}
+
+	return &Applied{Instance: instance, Previous: upserted.Previous}, nil
+}
+
+// reachedTarget reports whether the instance's value completes it: reaching
+// the target, or any progress for an instance without one.
+func reachedTarget(instance *goals.DailyGoalInstance) bool {
+	if instance.CompletedValue == nil {
+		return false
+	}
+	if instance.TargetValue != nil {
+		return *instance.CompletedValue >= *instance.TargetValue
+	}
+	return *instance.CompletedValue > 0
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate ingestion token: %w", err)
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Tokens are stored hashed so a database leak does not expose working
+// credentials.
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,118 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/pagination"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+)
+
+func TestValueValidate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		value Value
+		mode  Mode
+		want  string
+	}{
+		{"replace without source_id", Value{Value: 3}, ModeReplace, ""},
+		{"accumulate with source_id", Value{Value: 3, SourceID: "a"}, ModeAccumulate, ""},
+		{"accumulate with timestamp", Value{Value: 3, Timestamp: &now}, ModeAccumulate, ""},
+		{"accumulate without either", Value{Value: 3}, ModeAccumulate, "source_id is required in accumulate mode unless timestamp is set"},
+		{"negative", Value{Value: -1}, ModeReplace, "value must not be negative"},
+		{"too large", Value{Value: 1e8}, ModeReplace, "value must be at most 99999999.99"},
+		{"long source_id", Value{Value: 3, SourceID: strings.Repeat("a", 256)}, ModeReplace, "source_id must be at most 255 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if err := tt.value.validate(tt.mode); err != nil {
+				got = apperr.Summary(err)
+			}
+			if got != tt.want {
+				t.Errorf("validate is %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueSourceKey(t *testing.T) {
+	at := time.Date(2026, time.October, 19, 7, 30, 0, 0, time.FixedZone("EDT", -4*60*60))
+	retried := Value{Value: 2.5, Timestamp: &at}
+
+	if got := retried.sourceKey(ModeAccumulate); got == "" || got != retried.sourceKey(ModeAccumulate) {
+		t.Errorf("derived key is %q, want the same non-empty key for a retry", got)
+	}
+	if other := (Value{Value: 3, Timestamp: &at}); other.sourceKey(ModeAccumulate) == retried.sourceKey(ModeAccumulate) {
+		t.Error("different values at the same time share a key")
+	}
+	if got := retried.sourceKey(ModeReplace); got != "" {
+		t.Errorf("replace mode key is %q, want none", got)
+	}
+	if got := (Value{Value: 2.5, Timestamp: &at, SourceID: "steps-1"}).sourceKey(ModeAccumulate); got != "steps-1" {
+		t.Errorf("key is %q, want the source_id", got)
+	}
+}
+
+func TestApplyAccumulate(t *testing.T) {
+	db := storetest.OpenPostgres(t)
+	ctx := t.Context()
+
+	owner := storetest.NewUser(t, db)
+	goalRepo := goals.NewRepository(db, time.Now)
+	target := 10.0
+	goal, err := goalRepo.CreateGoal(ctx, owner.ID, goals.CreateGoalRequest{Title: "Steps", GoalType: goals.GoalTypeNumeric, TargetValue: &target})
+	if err != nil {
+		t.Fatalf("CreateGoal: %v", err)
+	}
+
+	repo := NewRepository(db, goalRepo)
+	token, err := repo.CreateToken(ctx, owner.ID, goal.ID, ModeAccumulate)
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	tokenOwner, err := repo.lookupToken(ctx, token.Token)
+	if err != nil {
+		t.Fatalf("lookupToken: %v", err)
+	}
+	date := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+	first, err := repo.apply(ctx, tokenOwner, 6, date, "a", ModeAccumulate)
+	if err != nil || first.Instance.IsCompleted {
+		t.Fatalf("first value returned %+v, %v, want an incomplete instance", first, err)
+	}
+	if retry, err := repo.apply(ctx, tokenOwner, 6, date, "a", ModeAccumulate); retry != nil || err != nil {
+		t.Fatalf("retried value returned %+v, %v, want it skipped", retry, err)
+	}
+
+	second, err := repo.apply(ctx, tokenOwner, 6, date, "b", ModeAccumulate)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if got := *second.Instance.CompletedValue; got != 12 || !second.Instance.IsCompleted || second.Instance.CompletedAt == nil {
+		t.Errorf("instance is %+v with value %v, want 12 and completed", second.Instance, got)
+	}
+	if second.Previous.IsCompleted || *second.Previous.CompletedValue != 6 {
+		t.Errorf("previous is %+v, want the incomplete 6", second.Previous)
+	}
+
+	// A value that would take the total past the column is rejected on its
+	// own, and the day's total is left as it was.
+	if _, err := repo.apply(ctx, tokenOwner, 99999999, date, "c", ModeAccumulate); apperr.KindOf(err) != apperr.KindValidation {
+		t.Fatalf("overflowing value returned %v, want a validation error", err)
+	}
+	if _, err := repo.apply(ctx, tokenOwner, 99999999, date, "c", ModeAccumulate); apperr.KindOf(err) != apperr.KindValidation {
+		t.Errorf("retried overflowing value returned %v, want it rejected again rather than skipped", err)
+	}
+	instances, _, err := goalRepo.GetDailyInstancesByGoal(ctx, goal.ID, owner.ID, goals.InstanceFilter{StartDate: date, EndDate: date}, pagination.Page{Limit: 10, Sort: "date"})
+	if err != nil {
+		t.Fatalf("GetDailyInstancesByGoal: %v", err)
+	}
+	if len(instances) != 1 || *instances[0].CompletedValue != 12 {
+		t.Errorf("instances are %+v, want the single instance at 12", instances)
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+const loggerKey contextKey = "logger"
+
+// Logger returns middleware that puts logger in the request's context, with
+// the request's ID attached when RequestID has already run, for handlers to
+// retrieve with GetLogger.
+func Logger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := logger
+			if id := GetRequestID(r.Context()); id != "" {
+				l = l.With("request_id", id)
+			}
+			next.ServeHTTP(w, r.WithContext(WithLogger(r.Context(), l)))
+		})
+	}
+}
+
+// WithLogger returns a copy of ctx that carries logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// GetLogger returns the logger carried by ctx, or slog.Default if it carries
+// none.
+func GetLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,104 @@
+// Package migrations versions the database schema. Migrations are numbered
+// pairs of SQL files, NNNN_name.up.sql and NNNN_name.down.sql, embedded in
+// the binary and applied in order. The versions applied to a database are
+// recorded in its schema_migrations table.
+//
+// Each dialect has its own migrations: PostgreSQL's in sql, and SQLite's,
+// which cover only the goal and user stores, in sqlite.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+)
+
+//go:embed sql/*.sql sqlite/*.sql
+var files embed.FS
+
+// dirs are the directories of each dialect's migrations.
+var dirs = map[dialect.Dialect]string{
+	dialect.Postgres: "sql",
+	dialect.SQLite:   "sqlite",
+}
+
+// Migration is one step of the schema's history.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// load reads the embedded migrations of d in version order. Every version
+// must have both an up and a down file, and versions must be unique.
+func load(d dialect.Dialect) ([]Migration, error) {
+	dir, ok := dirs[d]
+	if !ok {
+		return nil, fmt.Errorf("no migrations for database driver %q", d)
+	}
+
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		base, direction, ok := cutDirection(entry.Name())
+		if !ok {
+			return nil, fmt.Errorf("migration %s must end in .up.sql or .down.sql", entry.Name())
+		}
+		versionStr, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionStr)
+		if !ok || err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %s must be named NNNN_name", entry.Name())
+		}
+
+		body, err := files.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func cutDirection(filename string) (base, direction string, ok bool) {
+	if base, ok := strings.CutSuffix(filename, ".up.sql"); ok {
+		return base, "up", true
+	}
+	if base, ok := strings.CutSuffix(filename, ".down.sql"); ok {
+		return base, "down", true
+	}
+	return "", "", false
+}
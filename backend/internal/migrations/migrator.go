@@ -0,0 +1,320 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+)
+
+// lockKey identifies the advisory lock held while migrating, so that
+// instances starting at the same time apply each migration once.
+const lockKey int64 = 0x6772696e64 // "grind"
+
+// baselineVersion is recorded as applied, without running it, on databases
+// created from init.sql before migrations existed. Migration 1 is the first
+// init.sql; migration 2 adds what later versions of init.sql did, skipping
+// whatever the database already has.
+const baselineVersion = 1
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect.Dialect
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+// NewMigrator returns a Migrator for the migrations of d embedded in the
+// binary, which reports recording the baseline to logger.
+func NewMigrator(db *sql.DB, d dialect.Dialect, logger *slog.Logger) (*Migrator, error) {
+	migrations, err := load(d)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: d, migrations: migrations, logger: logger}, nil
+}
+
+// Status is the state of one migration in the database.
+type Status struct {
+	Version int
+	Name    string
+	// AppliedAt is nil while the migration is pending.
+	AppliedAt *time.Time
+	// Unknown is set for versions recorded in the database that this
+	// binary has no migration for, such as those of a newer release.
+	Unknown bool
+}
+
+// Up applies every pending migration in order and returns those it applied.
+// Each migration runs in its own transaction, so a failure leaves the
+// database at the last migration that succeeded.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.checkNames(ctx, conn); err != nil {
+			return err
+		}
+
+		// SQLite databases have only ever been created by migrations.
+		if len(versions) == 0 && m.dialect == dialect.Postgres {
+			if err := m.baseline(ctx, conn, versions); err != nil {
+				return err
+			}
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := versions[mig.Version]; ok {
+				continue
+			}
+			if err := run(ctx, conn, mig, true); err != nil {
+				return err
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the most recently applied migration and returns it, or nil if
+// none is applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		mig, err := m.latest(ctx, conn)
+		if err != nil || mig == nil {
+			return err
+		}
+		if err := run(ctx, conn, *mig, false); err != nil {
+			return err
+		}
+		reverted = mig
+		return nil
+	})
+	return reverted, err
+}
+
+// Redo reverts and reapplies the most recently applied migration and returns
+// it, or nil if none is applied. It is for iterating on a migration in
+// development.
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		mig, err := m.latest(ctx, conn)
+		if err != nil || mig == nil {
+			return err
+		}
+		if err := run(ctx, conn, *mig, false); err != nil {
+			return err
+		}
+		if err := run(ctx, conn, *mig, true); err != nil {
+			return err
+		}
+		redone = mig
+		return nil
+	})
+	return redone, err
+}
+
+// Status lists every migration, applied or pending, in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			status := Status{Version: mig.Version, Name: mig.Name}
+			if appliedAt, ok := versions[mig.Version]; ok {
+				status.AppliedAt = &appliedAt
+				delete(versions, mig.Version)
+			}
+			statuses = append(statuses, status)
+		}
+		for version, appliedAt := range versions {
+			statuses = append(statuses, Status{Version: version, AppliedAt: &appliedAt, Unknown: true})
+		}
+		return nil
+	})
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, err
+}
+
+// withLock runs fn on a single connection holding the migration lock, after
+// making sure schema_migrations exists. The lock is session-level, so every
+// statement has to go through conn. SQLite has no such lock; a SQLite
+// database is served by a single process, which migrates it on start.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if m.dialect == dialect.Postgres {
+		// Waiting for the lock and running a migration can both take
+		// longer than the statement timeout that guards requests.
+		if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+			return fmt.Errorf("failed to disable statement timeout: %w", err)
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), "RESET statement_timeout")
+
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockKey)
+	}
+
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// latest returns the most recently applied migration, or nil if none is.
+func (m *Migrator) latest(ctx context.Context, conn *sql.Conn) (*Migration, error) {
+	var version int
+	err := conn.QueryRowContext(ctx, `SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1`).Scan(&version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest migration: %w", err)
+	}
+
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return &mig, nil
+		}
+	}
+	return nil, fmt.Errorf("migration %d is applied but not known to this build", version)
+}
+
+// checkNames fails if a version recorded in the database was applied under
+// another name, which means this build's migration of that number is a
+// different one and would be skipped.
+func (m *Migrator) checkNames(ctx context.Context, conn *sql.Conn) error {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	names := make(map[int]string, len(m.migrations))
+	for _, mig := range m.migrations {
+		names[mig.Version] = mig.Name
+	}
+	for rows.Next() {
+		var version int
+		var name string
+		if err := rows.Scan(&version, &name); err != nil {
+			return fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		if want, ok := names[version]; ok && name != want && !(version == baselineVersion && name == "baseline") {
+			return fmt.Errorf("migration %d is recorded as %s but is %s in this build; recreate the database or fix schema_migrations by hand", version, name, want)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	return nil
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		versions[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	return versions, nil
+}
+
+// baseline records the initial migration as applied if the schema already
+// exists, which is the case for databases created by the init.sql that
+// migration 1 replaced. Later migrations then run as usual, starting with
+// migration 2, which brings a database created from any later init.sql up
+// to date.
+func (m *Migrator) baseline(ctx context.Context, conn *sql.Conn, versions map[int]time.Time) error {
+	var exists bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('public.users') IS NOT NULL`).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check for existing schema: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	query := `INSERT INTO schema_migrations (version, name) VALUES ($1, 'baseline') RETURNING applied_at`
+	var appliedAt time.Time
+	if err := conn.QueryRowContext(ctx, query, baselineVersion).Scan(&appliedAt); err != nil {
+		return fmt.Errorf("failed to record baseline migration: %w", err)
+	}
+	versions[baselineVersion] = appliedAt
+
+	m.logger.Info("existing schema found, recorded baseline migration as applied", "version", baselineVersion)
+	return nil
+}
+
+// run applies or reverts mig and records it, in one transaction.
+func run(ctx context.Context, conn *sql.Conn, mig Migration, up bool) error {
+	direction, body := "up", mig.Up
+	if !up {
+		direction, body = "down", mig.Down
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", mig.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return fmt.Errorf("failed to run migration %d_%s %s: %w", mig.Version, mig.Name, direction, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", mig.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", mig.Version, err)
+	}
+	return nil
+}
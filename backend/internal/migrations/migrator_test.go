@@ -0,0 +1,185 @@
+package migrations_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/lib/pq"
+
+	"github.com/JoshPugli/grindhouse-api/internal/config"
+	"github.com/JoshPugli/grindhouse-api/internal/database"
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+	"github.com/JoshPugli/grindhouse-api/internal/migrations"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+)
+
+// roundTrip applies every migration, reverts them all and applies them
+// again, checking that each down file undoes its up file.
+func roundTrip(t *testing.T, migrator *migrations.Migrator) {
+	t.Helper()
+	ctx := context.Background()
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for {
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			t.Fatalf("Down: %v", err)
+		}
+		if m == nil {
+			break
+		}
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up after reverting everything: %v", err)
+	}
+	if len(applied) != len(statuses) {
+		t.Errorf("Up applied %d migrations, want all %d", len(applied), len(statuses))
+	}
+}
+
+func TestSQLite(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.Driver = dialect.SQLite
+	cfg.Path = filepath.Join(t.TempDir(), "test.db")
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrations.NewMigrator(db, dialect.SQLite, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	roundTrip(t, migrator)
+}
+
+// laterInitSQL is what init.sql added for the activity feed, after the
+// initial schema and before migrations replaced it.
+const laterInitSQL = `
+CREATE TYPE goal_visibility_enum AS ENUM ('private', 'followers', 'public');
+ALTER TABLE goals ADD COLUMN visibility goal_visibility_enum NOT NULL DEFAULT 'private';
+CREATE TABLE user_follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+`
+
+// TestPostgresBaseline migrates databases created from the first init.sql
+// and from a later one, which migration 1 must be recorded for without
+// running and migration 2 must complete.
+func TestPostgresBaseline(t *testing.T) {
+	for name, initSQL := range map[string]string{
+		"initial init.sql": "",
+		"later init.sql":   laterInitSQL,
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := scratchDatabase(t)
+			ctx := context.Background()
+
+			initial, err := os.ReadFile("sql/0001_initial.up.sql")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := db.ExecContext(ctx, string(initial)+";"+initSQL); err != nil {
+				t.Fatalf("failed to create the init.sql schema: %v", err)
+			}
+
+			migrator, err := migrations.NewMigrator(db, dialect.Postgres, slog.New(slog.DiscardHandler))
+			if err != nil {
+				t.Fatalf("NewMigrator: %v", err)
+			}
+			applied, err := migrator.Up(ctx)
+			if err != nil {
+				t.Fatalf("Up: %v", err)
+			}
+			if len(applied) == 0 || applied[0].Version != 2 {
+				t.Fatalf("Up applied %v, want everything from migration 2", applied)
+			}
+
+			// Columns and tables from every version of init.sql exist.
+			for _, query := range []string{
+				`SELECT visibility, difficulty, version, change_xid FROM goals`,
+				`SELECT updated_at, version, change_xid FROM daily_goal_instances`,
+				`SELECT 1 FROM user_follows`,
+				`SELECT 1 FROM idempotency_keys`,
+			} {
+				if _, err := db.ExecContext(ctx, query); err != nil {
+					t.Errorf("%s: %v", query, err)
+				}
+			}
+
+			roundTrip(t, migrator)
+		})
+	}
+}
+
+// TestPostgresRenumbered checks that a database whose schema_migrations
+// records a different migration under a version is refused rather than
+// having the migration skipped.
+func TestPostgresRenumbered(t *testing.T) {
+	db := scratchDatabase(t)
+	ctx := context.Background()
+
+	migrator, err := migrations.NewMigrator(db, dialect.Postgres, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE schema_migrations SET name = 'goals_search' WHERE version = 3`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err == nil || !strings.Contains(err.Error(), "recorded as goals_search") {
+		t.Errorf("Up returned %v, want it to refuse the mismatched migration", err)
+	}
+}
+
+// scratchDatabase creates an empty database next to the test database, for
+// tests that need a schema of their own, and drops it when t ends.
+func scratchDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+
+	admin := storetest.OpenPostgres(t)
+	dsn, err := url.Parse(os.Getenv(storetest.PostgresDSNEnv))
+	if err != nil || dsn.Scheme == "" {
+		t.Skipf("%s must be a URL to create a scratch database", storetest.PostgresDSNEnv)
+	}
+
+	name := fmt.Sprintf("grindhouse_migrations_%d", os.Getpid())
+	admin.Exec(`DROP DATABASE IF EXISTS ` + name)
+	if _, err := admin.Exec(`CREATE DATABASE ` + name); err != nil {
+		t.Skipf("failed to create a scratch database: %v", err)
+	}
+
+	dsn.Path = "/" + name
+	db, err := sql.Open("postgres", dsn.String())
+	if err != nil {
+		t.Fatalf("failed to open scratch database: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		if _, err := admin.Exec(`DROP DATABASE IF EXISTS ` + name); err != nil {
+			t.Errorf("failed to drop scratch database: %v", err)
+		}
+	})
+	return db
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS daily_goal_instances, goals, users;
+
+DROP TYPE IF EXISTS goal_type_enum;
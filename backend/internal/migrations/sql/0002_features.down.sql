@@ -0,0 +1,39 @@
+DROP TABLE IF EXISTS
+    idempotency_keys,
+    sync_tombstones,
+    calendar_tokens,
+    imported_goals,
+    export_jobs,
+    ingested_values,
+    ingestion_tokens,
+    webhook_deliveries,
+    webhook_subscriptions,
+    xp_ledger,
+    user_achievements,
+    feed_items,
+    activity_events,
+    user_follows;
+
+DROP TRIGGER IF EXISTS goals_sync_change ON goals;
+DROP TRIGGER IF EXISTS goals_sync_delete ON goals;
+DROP TRIGGER IF EXISTS goals_bump_version ON goals;
+DROP TRIGGER IF EXISTS daily_goal_instances_sync_change ON daily_goal_instances;
+DROP TRIGGER IF EXISTS daily_goal_instances_sync_delete ON daily_goal_instances;
+DROP TRIGGER IF EXISTS daily_goal_instances_bump_version ON daily_goal_instances;
+
+DROP FUNCTION IF EXISTS bump_version(), sync_track_delete(), sync_track_change();
+
+DROP INDEX IF EXISTS idx_goals_user_change, idx_daily_instances_user_change;
+
+ALTER TABLE daily_goal_instances
+    DROP COLUMN IF EXISTS change_xid,
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE goals
+    DROP COLUMN IF EXISTS change_xid,
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS difficulty,
+    DROP COLUMN IF EXISTS visibility;
+
+DROP TYPE IF EXISTS goal_difficulty_enum, goal_visibility_enum;
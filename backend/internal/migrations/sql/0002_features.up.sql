@@ -0,0 +1,243 @@
+-- Everything init.sql gained after the initial schema. A database may have
+-- been created from any version of init.sql, so every statement skips what
+-- already exists.
+
+DO $$
+BEGIN
+    CREATE TYPE goal_visibility_enum AS ENUM ('private', 'followers', 'public');
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+DO $$
+BEGIN
+    CREATE TYPE goal_difficulty_enum AS ENUM ('easy', 'medium', 'hard');
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+ALTER TABLE goals
+    ADD COLUMN IF NOT EXISTS visibility goal_visibility_enum NOT NULL DEFAULT 'private',
+    ADD COLUMN IF NOT EXISTS difficulty goal_difficulty_enum NOT NULL DEFAULT 'medium',
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+ALTER TABLE daily_goal_instances
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE TABLE IF NOT EXISTS user_follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE TABLE IF NOT EXISTS activity_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    goal_id UUID REFERENCES goals(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    dedupe_key VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(actor_id, event_type, dedupe_key)
+);
+
+CREATE TABLE IF NOT EXISTS feed_items (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL REFERENCES activity_events(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_follows_followee ON user_follows(followee_id);
+CREATE INDEX IF NOT EXISTS idx_feed_items_user_created ON feed_items(user_id, created_at DESC, event_id DESC);
+
+CREATE TABLE IF NOT EXISTS user_achievements (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    achievement_key VARCHAR(100) NOT NULL,
+    unlocked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, achievement_key)
+);
+
+CREATE TABLE IF NOT EXISTS xp_ledger (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    goal_id UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    instance_id UUID NOT NULL REFERENCES daily_goal_instances(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_xp_ledger_user_created ON xp_ledger(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_xp_ledger_instance ON xp_ledger(instance_id);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_attempt_at TIMESTAMP,
+    response_status INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_user ON webhook_subscriptions(user_id) WHERE is_active;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS ingestion_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    goal_id UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    token_prefix VARCHAR(16) NOT NULL,
+    mode VARCHAR(20) NOT NULL DEFAULT 'replace' CHECK (mode IN ('replace', 'accumulate')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ingested_values (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    token_id UUID NOT NULL REFERENCES ingestion_tokens(id) ON DELETE CASCADE,
+    goal_id UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    external_id VARCHAR(255),
+    date DATE NOT NULL,
+    value DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(goal_id, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingestion_tokens_user ON ingestion_tokens(user_id) WHERE revoked_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed', 'expired')),
+    file_path TEXT,
+    download_token VARCHAR(64) UNIQUE,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_pending ON export_jobs(created_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS imported_goals (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL,
+    external_id TEXT NOT NULL,
+    goal_id UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, source, external_id)
+);
+
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Offline sync: every write to goals and daily_goal_instances stamps the row
+-- with the writing transaction's ID, and hard deletes leave a tombstone, so
+-- clients can pull everything that changed since a snapshot.
+CREATE TABLE IF NOT EXISTS sync_tombstones (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    entity VARCHAR(20) NOT NULL CHECK (entity IN ('goal', 'instance')),
+    entity_id UUID NOT NULL,
+    change_xid xid8 NOT NULL DEFAULT pg_current_xact_id(),
+    deleted_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX IF NOT EXISTS idx_goals_user_change ON goals(user_id, change_xid);
+CREATE INDEX IF NOT EXISTS idx_daily_instances_user_change ON daily_goal_instances(user_id, change_xid);
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_user_change ON sync_tombstones(user_id, change_xid);
+
+CREATE OR REPLACE FUNCTION sync_track_change() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.change_xid := pg_current_xact_id();
+    NEW.updated_at := NOW() AT TIME ZONE 'UTC';
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Tombstones are skipped when the owning user is being deleted, since there
+-- is no one left to sync them to.
+CREATE OR REPLACE FUNCTION sync_track_delete() RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE id = OLD.user_id) THEN
+        INSERT INTO sync_tombstones (user_id, entity, entity_id)
+        VALUES (OLD.user_id, TG_ARGV[0], OLD.id);
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER goals_sync_change
+    BEFORE INSERT OR UPDATE ON goals
+    FOR EACH ROW EXECUTE FUNCTION sync_track_change();
+
+CREATE OR REPLACE TRIGGER goals_sync_delete
+    AFTER DELETE ON goals
+    FOR EACH ROW EXECUTE FUNCTION sync_track_delete('goal');
+
+CREATE OR REPLACE TRIGGER daily_goal_instances_sync_change
+    BEFORE INSERT OR UPDATE ON daily_goal_instances
+    FOR EACH ROW EXECUTE FUNCTION sync_track_change();
+
+CREATE OR REPLACE TRIGGER daily_goal_instances_sync_delete
+    AFTER DELETE ON daily_goal_instances
+    FOR EACH ROW EXECUTE FUNCTION sync_track_delete('instance');
+
+-- Optimistic concurrency: every update to a goal or daily instance bumps its
+-- version, whichever code path performs it. Clients see it as the ETag.
+CREATE OR REPLACE FUNCTION bump_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER goals_bump_version
+    BEFORE UPDATE ON goals
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+CREATE OR REPLACE TRIGGER daily_goal_instances_bump_version
+    BEFORE UPDATE ON daily_goal_instances
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope VARCHAR(64) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    status_code INTEGER,
+    header JSONB,
+    body BYTEA,
+    locked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
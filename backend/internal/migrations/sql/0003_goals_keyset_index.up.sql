@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_goals_user_created ON goals(user_id, created_at DESC, id DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_goals_search;
+
+ALTER TABLE goals DROP COLUMN IF EXISTS search_vector;
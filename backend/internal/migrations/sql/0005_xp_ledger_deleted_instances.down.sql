@@ -0,0 +1,5 @@
+DELETE FROM xp_ledger l
+WHERE NOT EXISTS (SELECT 1 FROM daily_goal_instances i WHERE i.id = l.instance_id);
+
+ALTER TABLE xp_ledger ADD CONSTRAINT xp_ledger_instance_id_fkey
+    FOREIGN KEY (instance_id) REFERENCES daily_goal_instances(id) ON DELETE CASCADE;
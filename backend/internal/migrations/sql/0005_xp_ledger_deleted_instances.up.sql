@@ -0,0 +1,3 @@
+-- Awards outlive the instance they were for, so that deleting an instance
+-- reverses its award in the ledger instead of erasing the history.
+ALTER TABLE xp_ledger DROP CONSTRAINT IF EXISTS xp_ledger_instance_id_fkey;
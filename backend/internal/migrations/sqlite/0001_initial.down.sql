@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS daily_goal_instances;
+DROP TABLE IF EXISTS goals;
+DROP TABLE IF EXISTS users;
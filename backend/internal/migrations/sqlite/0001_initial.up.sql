@@ -0,0 +1,52 @@
+-- The schema of the goal and user stores on SQLite, translated from the
+-- PostgreSQL migrations:
+--   * ids are TEXT, generated by the stores as uuid_generate_v4 would;
+--   * enums are TEXT with a CHECK on their values;
+--   * DECIMAL(10,2) is REAL;
+--   * timestamps and dates are TEXT in the layouts of the dialect package,
+--     written by the stores, which also bump version on every update in
+--     place of the bump_version trigger.
+
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    email TEXT UNIQUE NOT NULL,
+    first_name TEXT,
+    password TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE goals (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT,
+    goal_type TEXT NOT NULL CHECK (goal_type IN ('boolean', 'numeric', 'duration')),
+    target_value REAL,
+    unit TEXT,
+    visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'followers', 'public')),
+    difficulty TEXT NOT NULL DEFAULT 'medium' CHECK (difficulty IN ('easy', 'medium', 'hard')),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE daily_goal_instances (
+    id TEXT PRIMARY KEY,
+    goal_id TEXT NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    target_value REAL,
+    completed_value REAL,
+    is_completed BOOLEAN NOT NULL DEFAULT FALSE,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    UNIQUE(goal_id, date)
+);
+
+CREATE INDEX idx_goals_user_id_active ON goals(user_id, is_active);
+CREATE INDEX idx_goals_user_created ON goals(user_id, created_at, id);
+CREATE INDEX idx_daily_instances_user_date ON daily_goal_instances(user_id, date);
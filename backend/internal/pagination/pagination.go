@@ -0,0 +1,178 @@
+// Package pagination implements keyset pagination for list endpoints: the
+// limit, cursor, sort and order query parameters, the opaque cursor format,
+// the SQL that resumes after a cursor, and the Link header to the next page.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// HeaderNextCursor carries the cursor of the next page, alongside the Link
+// header, for clients that do not parse links.
+const HeaderNextCursor = "X-Next-Cursor"
+
+// SortField is a column a list can be sorted by. Rows are ordered by the
+// column and then by ID, so the order is total and a page boundary is never
+// ambiguous.
+type SortField struct {
+	// Column is the SQL expression sorted on.
+	Column string
+	// Type is the SQL type a cursor value is cast to before comparing it
+	// with Column.
+	Type string
+}
+
+// Sorts maps the names accepted in the sort parameter to their fields.
+type Sorts map[string]SortField
+
+// Page is a parsed page request.
+type Page struct {
+	Limit int
+	Sort  string
+	Desc  bool
+	After *Cursor
+}
+
+// Cursor is the position of the last row of a page. It records the sort it
+// was issued for, so it cannot be replayed against a different order.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    string `json:"i"`
+}
+
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*Cursor, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, false
+	}
+	if _, err := uuid.Parse(c.ID); err != nil {
+		return nil, false
+	}
+	return &c, true
+}
+
+// Parse reads limit, cursor, sort and order from the request. sort must be a
+// key of sorts and defaults to defaultSort; order is "asc" or "desc" and
+// defaults to defaultOrder. A cursor is only accepted with the sort and
+// order it was issued for. Problems are added to fields, so they are
+// reported together with those of the endpoint's own filters.
+func Parse(r *http.Request, fields *apperr.Fields, sorts Sorts, defaultSort, defaultOrder string) Page {
+	query := r.URL.Query()
+
+	page := Page{Limit: DefaultLimit, Sort: defaultSort, Desc: defaultOrder == "desc"}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			fields.Add("limit", "must be between 1 and "+strconv.Itoa(MaxLimit))
+		} else {
+			page.Limit = limit
+		}
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		if _, ok := sorts[sort]; !ok {
+			fields.Add("sort", "must be one of "+strings.Join(sortNames(sorts), ", "))
+		} else {
+			page.Sort = sort
+		}
+	}
+
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		page.Desc = false
+	case "desc":
+		page.Desc = true
+	default:
+		fields.Add("order", "must be asc or desc")
+	}
+
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		cursor, ok := decodeCursor(cursorStr)
+		switch {
+		case !ok:
+			fields.Add("cursor", "is invalid")
+		case cursor.Sort != page.Sort || cursor.Desc != page.Desc:
+			fields.Add("cursor", "was issued for a different sort or order")
+		default:
+			page.After = cursor
+		}
+	}
+
+	return page
+}
+
+// Trim cuts items, fetched with Query's limit of one extra row, down to the
+// page and returns the cursor of the next page, or nil on the last page. key
+// returns a row's sort value, formatted so that it casts back to the sort
+// field's Type, and its ID.
+func Trim[T any](items []T, page Page, key func(T) (value, id string)) ([]T, *Cursor) {
+	if len(items) <= page.Limit {
+		return items, nil
+	}
+
+	items = items[:page.Limit]
+	value, id := key(items[len(items)-1])
+	return items, &Cursor{Sort: page.Sort, Desc: page.Desc, Value: value, ID: id}
+}
+
+// WriteNext advertises the next page, if there is one, with a Link header
+// to the same URL with the cursor replaced and an X-Next-Cursor header.
+func WriteNext(w http.ResponseWriter, r *http.Request, next *Cursor) {
+	if next == nil {
+		return
+	}
+
+	encoded := next.Encode()
+	query := r.URL.Query()
+	query.Set("cursor", encoded)
+	link := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+
+	w.Header().Set(HeaderNextCursor, encoded)
+	w.Header().Add("Link", "<"+link.String()+`>; rel="next"`)
+}
+
+func sortNames(sorts Sorts) []string {
+	names := make([]string, 0, len(sorts))
+	for name := range sorts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Timestamp formats a TIMESTAMP column value for a cursor. The columns have
+// no time zone, so the wall clock is kept and the zone dropped. The layout
+// is the one SQLite stores timestamps in, so the cursor compares with them.
+func Timestamp(t time.Time) string {
+	return t.Format(dialect.TimestampLayout)
+}
@@ -0,0 +1,173 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+)
+
+var testSorts = Sorts{
+	"created_at": {Column: "created_at", Type: "timestamp"},
+	"title":      {Column: "title", Type: "text"},
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := Cursor{Sort: "created_at", Desc: true, Value: "2026-10-19T07:30:00.123456", ID: uuid.New().String()}
+
+	got, ok := decodeCursor(want.Encode())
+	if !ok || *got != want {
+		t.Errorf("decoded %+v, %v, want %+v", got, ok, want)
+	}
+
+	for _, bad := range []string{"not base64!", "bm90IGpzb24", Cursor{Sort: "title", ID: "not-a-uuid"}.Encode()} {
+		if _, ok := decodeCursor(bad); ok {
+			t.Errorf("decodeCursor(%q) succeeded, want it rejected", bad)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	titleCursor := Cursor{Sort: "title", Value: "a", ID: uuid.New().String()}
+	descCursor := Cursor{Sort: "created_at", Desc: true, Value: "2026-10-19T00:00:00", ID: uuid.New().String()}
+
+	tests := []struct {
+		name       string
+		query      string
+		want       Page
+		wantFields []string
+	}{
+		{"defaults", "", Page{Limit: DefaultLimit, Sort: "created_at", Desc: true}, nil},
+		{"limit, sort and order", "?limit=10&sort=title&order=asc", Page{Limit: 10, Sort: "title"}, nil},
+		{"largest limit", "?limit=200", Page{Limit: MaxLimit, Sort: "created_at", Desc: true}, nil},
+		{"cursor", "?cursor=" + descCursor.Encode(), Page{Limit: DefaultLimit, Sort: "created_at", Desc: true, After: &descCursor}, nil},
+		{"cursor with its sort", "?sort=title&order=asc&cursor=" + titleCursor.Encode(), Page{Limit: DefaultLimit, Sort: "title", After: &titleCursor}, nil},
+		{"zero limit", "?limit=0", Page{Limit: DefaultLimit, Sort: "created_at", Desc: true}, []string{"limit"}},
+		{"limit too large", "?limit=201", Page{Limit: DefaultLimit, Sort: "created_at", Desc: true}, []string{"limit"}},
+		{"limit not a number", "?limit=ten", Page{Limit: DefaultLimit, Sort: "created_at", Desc: true}, []string{"limit"}},
+		{"unknown sort", "?sort=id", Page{Limit: DefaultLimit, Sort: "created_at", Desc: true}, []string{"sort"}},
+		{"unknown order", "?order=up", Page{Limit: DefaultLimit, Sort: "created_at", Desc: true}, []string{"order"}},
+		{"invalid cursor", "?cursor=garbage", Page{Limit: DefaultLimit, Sort: "created_at", Desc: true}, []string{"cursor"}},
+		{"cursor for another sort", "?cursor=" + titleCursor.Encode(), Page{Limit: DefaultLimit, Sort: "created_at", Desc: true}, []string{"cursor"}},
+		{"cursor for another order", "?order=asc&cursor=" + descCursor.Encode(), Page{Limit: DefaultLimit, Sort: "created_at"}, []string{"cursor"}},
+		{"every problem at once", "?limit=-1&sort=id&order=up&cursor=garbage", Page{Limit: DefaultLimit, Sort: "created_at", Desc: true}, []string{"limit", "sort", "order", "cursor"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields apperr.Fields
+			got := Parse(httptest.NewRequest("GET", "/goals"+tt.query, nil), &fields, testSorts, "created_at", "desc")
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse returned %+v, want %+v", got, tt.want)
+			}
+			var names []string
+			for _, f := range fields {
+				names = append(names, f.Field)
+			}
+			if !reflect.DeepEqual(names, tt.wantFields) {
+				t.Errorf("Parse rejected %v, want %v", names, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestQueryBuild(t *testing.T) {
+	after := &Cursor{Sort: "title", Value: "m", ID: uuid.New().String()}
+	tests := []struct {
+		name     string
+		dialect  dialect.Dialect
+		page     Page
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "first page",
+			page:     Page{Limit: 10, Sort: "created_at", Desc: true},
+			wantSQL:  "SELECT * FROM goals WHERE user_id = $1 AND goal_type = $2 AND is_active = true ORDER BY created_at DESC, id DESC LIMIT 11",
+			wantArgs: []any{"u", "numeric"},
+		},
+		{
+			name:     "after a cursor",
+			page:     Page{Limit: 10, Sort: "title", After: after},
+			wantSQL:  "SELECT * FROM goals WHERE user_id = $1 AND goal_type = $2 AND is_active = true AND (title, id) > ($3::text, $4::uuid) ORDER BY title ASC, id ASC LIMIT 11",
+			wantArgs: []any{"u", "numeric", "m", after.ID},
+		},
+		{
+			name:     "after a cursor descending",
+			page:     Page{Limit: 5, Sort: "created_at", Desc: true, After: &Cursor{Value: "2026-10-19T00:00:00", ID: after.ID}},
+			wantSQL:  "SELECT * FROM goals WHERE user_id = $1 AND goal_type = $2 AND is_active = true AND (created_at, id) < ($3::timestamp, $4::uuid) ORDER BY created_at DESC, id DESC LIMIT 6",
+			wantArgs: []any{"u", "numeric", "2026-10-19T00:00:00", after.ID},
+		},
+		{
+			name:     "SQLite",
+			dialect:  dialect.SQLite,
+			page:     Page{Limit: 10, Sort: "title", After: after},
+			wantSQL:  "SELECT * FROM goals WHERE user_id = $1 AND goal_type = $2 AND is_active = true AND (title, id) > ($3, $4) ORDER BY title ASC, id ASC LIMIT 11",
+			wantArgs: []any{"u", "numeric", "m", after.ID},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := Query{Dialect: tt.dialect}
+			q.Where("user_id = ?", "u")
+			q.Where("goal_type = ?", "numeric")
+			q.Where("is_active = true")
+
+			sql, args := q.Build("SELECT * FROM goals", tt.page, testSorts, "id")
+			if sql != tt.wantSQL {
+				t.Errorf("Build returned\n%s\nwant\n%s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Build returned args %v, want %v", args, tt.wantArgs)
+			}
+
+			// Building must not change the query, so it can be built again.
+			if again, _ := q.Build("SELECT * FROM goals", tt.page, testSorts, "id"); again != sql {
+				t.Errorf("second Build returned\n%s\nwant\n%s", again, sql)
+			}
+		})
+	}
+}
+
+func TestTrim(t *testing.T) {
+	items := []string{"a", "b", "c"}
+	key := func(s string) (string, string) { return s, "id-" + s }
+
+	got, next := Trim(items, Page{Limit: 3, Sort: "title"}, key)
+	if len(got) != 3 || next != nil {
+		t.Errorf("Trim of a full last page returned %v, %+v, want every item and no cursor", got, next)
+	}
+
+	got, next = Trim(items, Page{Limit: 2, Sort: "title", Desc: true}, key)
+	want := &Cursor{Sort: "title", Desc: true, Value: "b", ID: "id-b"}
+	if !reflect.DeepEqual(got, items[:2]) || next == nil || *next != *want {
+		t.Errorf("Trim returned %v, %+v, want %v and %+v", got, next, items[:2], want)
+	}
+}
+
+func TestWriteNext(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/goals?status=all&cursor=old&limit=2", nil)
+
+	w := httptest.NewRecorder()
+	WriteNext(w, r, nil)
+	if len(w.Header()) != 0 {
+		t.Errorf("WriteNext without a next page set %v, want no headers", w.Header())
+	}
+
+	next := &Cursor{Sort: "created_at", Desc: true, Value: Timestamp(time.Date(2026, time.October, 19, 7, 30, 0, 0, time.UTC)), ID: uuid.New().String()}
+	w = httptest.NewRecorder()
+	WriteNext(w, r, next)
+
+	if got := w.Header().Get(HeaderNextCursor); got != next.Encode() {
+		t.Errorf("%s is %q, want %q", HeaderNextCursor, got, next.Encode())
+	}
+	wantLink := `</api/v1/goals?cursor=` + next.Encode() + `&limit=2&status=all>; rel="next"`
+	if got := w.Header().Get("Link"); got != wantLink {
+		t.Errorf("Link is %q, want %q", got, wantLink)
+	}
+}
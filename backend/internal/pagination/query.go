@@ -0,0 +1,79 @@
+package pagination
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+)
+
+// Query builds a paginated SELECT from a base statement, the filters a list
+// endpoint accepts, and a Page. Conditions use ? placeholders, which are
+// numbered when the statement is built, so filters can be added in any order
+// without tracking argument positions.
+//
+// Dialect is the database the statement is for; the zero value is
+// PostgreSQL.
+type Query struct {
+	Dialect dialect.Dialect
+
+	conds []string
+	args  []any
+}
+
+// Where adds a condition, ANDed with the others, with one ? per argument.
+func (q *Query) Where(cond string, args ...any) {
+	q.conds = append(q.conds, cond)
+	q.args = append(q.args, args...)
+}
+
+// Build returns the statement and its arguments. base is the SELECT ... FROM
+// part. Rows are ordered by the page's sort field and then idColumn, start
+// after the page's cursor, and are limited to one more than the page size so
+// Trim can tell whether there is a next page.
+func (q Query) Build(base string, page Page, sorts Sorts, idColumn string) (string, []any) {
+	field := sorts[page.Sort]
+	conds := q.conds
+	args := q.args
+
+	if page.After != nil {
+		op := ">"
+		if page.Desc {
+			op = "<"
+		}
+		conds = append(conds[:len(conds):len(conds)],
+			"("+field.Column+", "+idColumn+") "+op+" ("+q.Dialect.Cast("?", field.Type)+", "+q.Dialect.Cast("?", "uuid")+")")
+		args = append(args[:len(args):len(args)], page.After.Value, page.After.ID)
+	}
+
+	dir := " ASC"
+	if page.Desc {
+		dir = " DESC"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(base)
+	if len(conds) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(conds, " AND "))
+	}
+	sb.WriteString(" ORDER BY " + field.Column + dir + ", " + idColumn + dir)
+	sb.WriteString(" LIMIT " + strconv.Itoa(page.Limit+1))
+
+	return numberPlaceholders(sb.String()), args
+}
+
+// numberPlaceholders rewrites each ? as $1, $2, ... in order.
+func numberPlaceholders(query string) string {
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
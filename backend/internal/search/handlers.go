@@ -0,0 +1,105 @@
+// Package search finds a user's goals by the words in their titles and
+// descriptions, using PostgreSQL full-text search
+package search
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+const (
+	defaultLimit   = 20
+	maxLimit       = 50
+	maxQueryLength = 256
+)
+
+type Handlers struct {
+	repo *Repository
+}
+
+func NewHandlers(repo *Repository) *Handlers {
+	return &Handlers{
+		repo: repo,
+	}
+}
+
+// HandleSearch godoc
+// @Summary Search goals
+// @Description Full-text search over the authenticated user's goal titles and descriptions, most relevant first. Matched words in the highlights are wrapped in <mark> and </mark>.
+// @Tags search
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Search terms; supports \"quoted phrases\", OR and -excluded words"
+// @Param status query string false "Goals to search (defaults to all)" Enums(active, archived, all)
+// @Param limit query int false "Maximum number of results (default 20, max 50)"
+// @Success 200 {object} Response
+// @Failure 400 {object} apperr.Problem "Invalid search parameters"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /search [get]
+func (h *Handlers) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	q, err := parseQuery(r)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	results, err := h.repo.SearchGoals(r.Context(), userID, q)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{Query: q.Text, Results: results})
+}
+
+// parseQuery reads the search parameters and reports all problems at once.
+func parseQuery(r *http.Request) (Query, error) {
+	params := r.URL.Query()
+	var fields apperr.Fields
+	q := Query{
+		Text:   strings.TrimSpace(params.Get("q")),
+		Status: goals.GoalStatusAll,
+		Limit:  defaultLimit,
+	}
+
+	switch {
+	case q.Text == "":
+		fields.Add("q", "is required")
+	case utf8.RuneCountInString(q.Text) > maxQueryLength:
+		fields.Add("q", "must be at most 256 characters")
+	}
+
+	switch status := goals.GoalStatus(params.Get("status")); status {
+	case "":
+	case goals.GoalStatusActive, goals.GoalStatusArchived, goals.GoalStatusAll:
+		q.Status = status
+	default:
+		fields.Add("status", "must be one of active, archived, all")
+	}
+
+	if limitStr := params.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxLimit {
+			fields.Add("limit", "must be between 1 and 50")
+		} else {
+			q.Limit = limit
+		}
+	}
+
+	return q, fields.Err()
+}
@@ -0,0 +1,36 @@
+package search
+
+import "github.com/JoshPugli/grindhouse-api/internal/goals"
+
+// Highlight markers wrapped around matched words in highlighted fragments.
+// Titles and descriptions are user text, so clients rendering highlights as
+// HTML must escape everything else.
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+// Query is a parsed search request.
+type Query struct {
+	// Text is the search in websearch syntax: words, "quoted phrases",
+	// OR, and -excluded words.
+	Text   string
+	Status goals.GoalStatus
+	Limit  int
+}
+
+type Result struct {
+	Goal goals.Goal `json:"goal"`
+	// Rank is the relevance of the match; results are ordered by it.
+	Rank float64 `json:"rank"`
+	// TitleHighlight is the title with matched words marked.
+	TitleHighlight string `json:"title_highlight"`
+	// DescriptionHighlight holds the fragments of the description around
+	// matched words, or null if the goal has no description.
+	DescriptionHighlight *string `json:"description_highlight"`
+}
+
+type Response struct {
+	Query   string   `json:"query"`
+	Results []Result `json:"results"`
+}
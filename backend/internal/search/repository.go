@@ -0,0 +1,77 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+// headlineOptions configure ts_headline: descriptions are cut to a couple of
+// short fragments, while titles are short enough to return whole.
+const (
+	titleHeadlineOptions       = "StartSel=" + HighlightStart + ", StopSel=" + HighlightStop + ", HighlightAll=true"
+	descriptionHeadlineOptions = "StartSel=" + HighlightStart + ", StopSel=" + HighlightStop + ", MaxFragments=2, MaxWords=20, MinWords=5"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// SearchGoals returns the user's goals whose title or description match q,
+// most relevant first. Title matches rank above description matches.
+func (r *Repository) SearchGoals(ctx context.Context, userID string, q Query) ([]Result, error) {
+	var isActive sql.NullBool
+	switch q.Status {
+	case goals.GoalStatusActive:
+		isActive = sql.NullBool{Bool: true, Valid: true}
+	case goals.GoalStatusArchived:
+		isActive = sql.NullBool{Bool: false, Valid: true}
+	}
+
+	// Matches are ranked and limited before highlighting, since ts_headline
+	// reparses the text and is by far the most expensive part.
+	query := `
+		WITH matches AS (
+			SELECT g.id, g.user_id, g.title, g.description, g.goal_type, g.target_value, g.unit, g.visibility, g.difficulty, g.is_active, g.created_at, g.updated_at, g.version,
+				ts_rank_cd(g.search_vector, tsq) AS rank, tsq
+			FROM goals g, websearch_to_tsquery('english', $2) tsq
+			WHERE g.user_id = $1 AND g.search_vector @@ tsq AND ($3::boolean IS NULL OR g.is_active = $3)
+			ORDER BY rank DESC, g.created_at DESC, g.id
+			LIMIT $4
+		)
+		SELECT id, user_id, title, description, goal_type, target_value, unit, visibility, difficulty, is_active, created_at, updated_at, version,
+			rank,
+			ts_headline('english', title, tsq, $5),
+			CASE WHEN description IS NOT NULL THEN ts_headline('english', description, tsq, $6) END
+		FROM matches
+		ORDER BY rank DESC, created_at DESC, id
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, q.Text, isActive, q.Limit, titleHeadlineOptions, descriptionHeadlineOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search goals: %w", err)
+	}
+	defer rows.Close()
+
+	results := []Result{}
+	for rows.Next() {
+		var result Result
+		goal := &result.Goal
+		err := rows.Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.GoalType, &goal.TargetValue, &goal.Unit, &goal.Visibility, &goal.Difficulty, &goal.IsActive, &goal.CreatedAt, &goal.UpdatedAt, &goal.Version,
+			&result.Rank, &result.TitleHighlight, &result.DescriptionHighlight)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search goals: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,599 @@
+// Package storetest is a conformance suite for implementations of
+// goals.GoalStore and user.UserStore. Every implementation must pass it, so
+// that handlers behave the same whichever store they run on. Each store's
+// package runs its part of the suite against all of its implementations:
+//
+//	func TestMemoryStore(t *testing.T) {
+//		storetest.RunGoals(t, func(t *testing.T) storetest.Stores {
+//			return storetest.Stores{Goals: goals.NewMemoryStore(time.Now), Users: user.NewMemoryStore()}
+//		})
+//	}
+//
+// The suite creates its own users with unique emails, so the stores may be
+// shared between tests and may already hold data.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/pagination"
+	"github.com/JoshPugli/grindhouse-api/internal/user"
+)
+
+// Stores are the stores under test. Goals may reference only users created
+// in Users.
+type Stores struct {
+	Goals goals.GoalStore
+	Users user.UserStore
+}
+
+// Run runs the whole suite. newStores is called once per test.
+func Run(t *testing.T, newStores func(t *testing.T) Stores) {
+	RunUsers(t, newStores)
+	RunGoals(t, newStores)
+}
+
+// RunUsers runs the tests of the user store.
+func RunUsers(t *testing.T, newStores func(t *testing.T) Stores) {
+	t.Run("Users", func(t *testing.T) { runUsers(t, newStores) })
+}
+
+// RunGoals runs the tests of the goal store, which create their users in
+// the user store.
+func RunGoals(t *testing.T, newStores func(t *testing.T) Stores) {
+	t.Run("Goals", func(t *testing.T) { runGoals(t, newStores) })
+	t.Run("DailyInstances", func(t *testing.T) { runInstances(t, newStores) })
+}
+
+func runUsers(t *testing.T, newStores func(t *testing.T) Stores) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		s := newStores(t)
+		email := uniqueEmail()
+
+		created, err := s.Users.CreateUser(ctx, email, "Ada", "password1")
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if created.ID == "" || created.Email != email || created.FirstName != "Ada" || created.Password != "" {
+			t.Errorf("CreateUser returned %+v", created)
+		}
+
+		byID, err := s.Users.GetUserByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if byID.Email != email || byID.Password != "" {
+			t.Errorf("GetUserByID returned %+v, want email %s and no password", byID, email)
+		}
+
+		byEmail, err := s.Users.GetUserByEmail(ctx, email)
+		if err != nil {
+			t.Fatalf("GetUserByEmail: %v", err)
+		}
+		if byEmail.ID != created.ID || byEmail.Password == "" || byEmail.Password == "password1" {
+			t.Errorf("GetUserByEmail returned %+v, want the password hash", byEmail)
+		}
+	})
+
+	t.Run("EmailIsUnique", func(t *testing.T) {
+		s := newStores(t)
+		email := uniqueEmail()
+
+		mustCreateUser(t, s, email)
+		_, err := s.Users.CreateUser(ctx, email, "Other", "password2")
+		wantKind(t, "CreateUser with a taken email", err, apperr.KindConflict)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		s := newStores(t)
+
+		_, err := s.Users.GetUserByID(ctx, uuid.New().String())
+		wantKind(t, "GetUserByID", err, apperr.KindNotFound)
+		_, err = s.Users.GetUserByEmail(ctx, uniqueEmail())
+		wantKind(t, "GetUserByEmail", err, apperr.KindNotFound)
+	})
+
+	t.Run("ValidatePassword", func(t *testing.T) {
+		s := newStores(t)
+		email := uniqueEmail()
+		created := mustCreateUser(t, s, email)
+
+		got, err := s.Users.ValidatePassword(ctx, email, "password1")
+		if err != nil {
+			t.Fatalf("ValidatePassword: %v", err)
+		}
+		if got.ID != created.ID {
+			t.Errorf("ValidatePassword returned user %s, want %s", got.ID, created.ID)
+		}
+
+		_, err = s.Users.ValidatePassword(ctx, email, "wrong1234")
+		wantKind(t, "ValidatePassword with a wrong password", err, apperr.KindUnauthorized)
+		_, err = s.Users.ValidatePassword(ctx, uniqueEmail(), "password1")
+		wantKind(t, "ValidatePassword with an unknown email", err, apperr.KindUnauthorized)
+	})
+}
+
+func runGoals(t *testing.T, newStores func(t *testing.T) Stores) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		s := newStores(t)
+		owner := mustCreateUser(t, s, uniqueEmail())
+
+		description := "twenty pages"
+		created, err := s.Goals.CreateGoal(ctx, owner.ID, goals.CreateGoalRequest{
+			Title:       "read",
+			Description: &description,
+			GoalType:    goals.GoalTypeNumeric,
+			TargetValue: ptr(20.0),
+			Unit:        ptr("pages"),
+			Visibility:  goals.VisibilityPublic,
+			Difficulty:  goals.DifficultyHard,
+		})
+		if err != nil {
+			t.Fatalf("CreateGoal: %v", err)
+		}
+		if created.ID == "" || created.UserID != owner.ID || !created.IsActive || created.Version != 1 {
+			t.Errorf("CreateGoal returned %+v", created)
+		}
+
+		got, err := s.Goals.GetGoalByID(ctx, created.ID, owner.ID)
+		if err != nil {
+			t.Fatalf("GetGoalByID: %v", err)
+		}
+		if got.Title != "read" || got.Description == nil || *got.Description != description ||
+			got.GoalType != goals.GoalTypeNumeric || got.TargetValue == nil || *got.TargetValue != 20 ||
+			got.Unit == nil || *got.Unit != "pages" || got.Visibility != goals.VisibilityPublic ||
+			got.Difficulty != goals.DifficultyHard || got.Version != 1 {
+			t.Errorf("GetGoalByID returned %+v", got)
+		}
+	})
+
+	t.Run("ScopedToOwner", func(t *testing.T) {
+		s := newStores(t)
+		owner := mustCreateUser(t, s, uniqueEmail())
+		other := mustCreateUser(t, s, uniqueEmail())
+		goal := mustCreateGoal(t, s, owner.ID, "private")
+
+		_, err := s.Goals.GetGoalByID(ctx, goal.ID, other.ID)
+		wantKind(t, "GetGoalByID by another user", err, apperr.KindNotFound)
+		_, err = s.Goals.UpdateGoal(ctx, goal.ID, other.ID, goals.UpdateGoalRequest{Title: ptr("taken")}, nil)
+		wantKind(t, "UpdateGoal by another user", err, apperr.KindNotFound)
+		err = s.Goals.DeleteGoal(ctx, goal.ID, other.ID, nil)
+		wantKind(t, "DeleteGoal by another user", err, apperr.KindNotFound)
+		_, err = s.Goals.UpsertDailyInstance(ctx, goal.ID, other.ID, day(0), goals.UpdateDailyInstanceRequest{IsCompleted: ptr(true)}, nil)
+		wantKind(t, "UpsertDailyInstance by another user", err, apperr.KindNotFound)
+
+		list, _, err := s.Goals.GetGoalsByUserID(ctx, other.ID, goals.GoalFilter{Status: goals.GoalStatusAll}, firstPage("created_at", true, 10))
+		if err != nil {
+			t.Fatalf("GetGoalsByUserID: %v", err)
+		}
+		if len(list) != 0 {
+			t.Errorf("another user's list has %d goals, want 0", len(list))
+		}
+
+		today, err := s.Goals.GetGoalsWithTodayInstances(ctx, other.ID)
+		if err != nil {
+			t.Fatalf("GetGoalsWithTodayInstances: %v", err)
+		}
+		if len(today) != 0 {
+			t.Errorf("another user's today list has %d goals, want 0", len(today))
+		}
+
+		got, err := s.Goals.GetGoalByID(ctx, goal.ID, owner.ID)
+		if err != nil {
+			t.Fatalf("GetGoalByID: %v", err)
+		}
+		if got.Title != "private" || got.Version != 1 || !got.IsActive {
+			t.Errorf("goal changed by another user: %+v", got)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		s := newStores(t)
+		owner := mustCreateUser(t, s, uniqueEmail())
+		goal := mustCreateGoal(t, s, owner.ID, "run")
+
+		updated, err := s.Goals.UpdateGoal(ctx, goal.ID, owner.ID, goals.UpdateGoalRequest{Title: ptr("run far"), TargetValue: ptr(5.0)}, goals.Versions{1})
+		if err != nil {
+			t.Fatalf("UpdateGoal: %v", err)
+		}
+		if updated.Title != "run far" || updated.TargetValue == nil || *updated.TargetValue != 5 || updated.Version != 2 {
+			t.Errorf("UpdateGoal returned %+v", updated)
+		}
+
+		_, err = s.Goals.UpdateGoal(ctx, goal.ID, owner.ID, goals.UpdateGoalRequest{Title: ptr("stale")}, goals.Versions{1})
+		if !errors.Is(err, goals.ErrVersionMismatch) {
+			t.Errorf("UpdateGoal at a stale version: got %v, want ErrVersionMismatch", err)
+		}
+		_, err = s.Goals.UpdateGoal(ctx, goal.ID, owner.ID, goals.UpdateGoalRequest{Title: ptr("stale")}, goals.Versions{})
+		if !errors.Is(err, goals.ErrVersionMismatch) {
+			t.Errorf("UpdateGoal at no version: got %v, want ErrVersionMismatch", err)
+		}
+		updated, err = s.Goals.UpdateGoal(ctx, goal.ID, owner.ID, goals.UpdateGoalRequest{Title: ptr("run further")}, goals.Versions{1, 2})
+		if err != nil || updated.Version != 3 {
+			t.Errorf("UpdateGoal at one of several versions returned %+v, %v, want version 3", updated, err)
+		}
+
+		_, err = s.Goals.UpdateGoal(ctx, goal.ID, owner.ID, goals.UpdateGoalRequest{Unit: ptr("")}, nil)
+		if err != nil {
+			t.Errorf("UpdateGoal without a version: %v", err)
+		}
+
+		boolean := mustCreateBooleanGoal(t, s, owner.ID, "meditate")
+		_, err = s.Goals.UpdateGoal(ctx, boolean.ID, owner.ID, goals.UpdateGoalRequest{TargetValue: ptr(3.0)}, nil)
+		wantKind(t, "UpdateGoal setting a boolean goal's target", err, apperr.KindValidation)
+	})
+
+	t.Run("SoftDelete", func(t *testing.T) {
+		s := newStores(t)
+		owner := mustCreateUser(t, s, uniqueEmail())
+		kept := mustCreateGoal(t, s, owner.ID, "kept")
+		archived := mustCreateGoal(t, s, owner.ID, "archived")
+
+		err := s.Goals.DeleteGoal(ctx, archived.ID, owner.ID, goals.Versions{2, 3})
+		if !errors.Is(err, goals.ErrVersionMismatch) {
+			t.Errorf("DeleteGoal at a wrong version: got %v, want ErrVersionMismatch", err)
+		}
+		if err := s.Goals.DeleteGoal(ctx, archived.ID, owner.ID, goals.Versions{2, 1}); err != nil {
+			t.Fatalf("DeleteGoal: %v", err)
+		}
+		err = s.Goals.DeleteGoal(ctx, uuid.New().String(), owner.ID, nil)
+		wantKind(t, "DeleteGoal of a missing goal", err, apperr.KindNotFound)
+
+		got, err := s.Goals.GetGoalByID(ctx, archived.ID, owner.ID)
+		if err != nil {
+			t.Fatalf("GetGoalByID of an archived goal: %v", err)
+		}
+		if got.IsActive || got.Version != 2 {
+			t.Errorf("archived goal is %+v, want inactive at version 2", got)
+		}
+		// Archiving is an update like any other, so it moves updated_at for
+		// sorting and sync.
+		if !got.UpdatedAt.After(archived.UpdatedAt) {
+			t.Errorf("archived goal's updated_at is %v, want after %v", got.UpdatedAt, archived.UpdatedAt)
+		}
+
+		for status, want := range map[goals.GoalStatus][]string{
+			goals.GoalStatusActive:   {kept.ID},
+			goals.GoalStatusArchived: {archived.ID},
+			goals.GoalStatusAll:      {archived.ID, kept.ID},
+		} {
+			list, _, err := s.Goals.GetGoalsByUserID(ctx, owner.ID, goals.GoalFilter{Status: status}, firstPage("title", false, 10))
+			if err != nil {
+				t.Fatalf("GetGoalsByUserID(%s): %v", status, err)
+			}
+			wantIDs(t, "GetGoalsByUserID("+string(status)+")", goalIDs(list), want)
+		}
+
+		today, err := s.Goals.GetGoalsWithTodayInstances(ctx, owner.ID)
+		if err != nil {
+			t.Fatalf("GetGoalsWithTodayInstances: %v", err)
+		}
+		if len(today) != 1 || today[0].Goal.ID != kept.ID {
+			t.Errorf("GetGoalsWithTodayInstances returned %d goals, want only the active one", len(today))
+		}
+	})
+
+	t.Run("ListFiltersAndPages", func(t *testing.T) {
+		s := newStores(t)
+		owner := mustCreateUser(t, s, uniqueEmail())
+		a := mustCreateGoal(t, s, owner.ID, "alpha")
+		b := mustCreateBooleanGoal(t, s, owner.ID, "bravo")
+		c := mustCreateGoal(t, s, owner.ID, "charlie")
+
+		list, _, err := s.Goals.GetGoalsByUserID(ctx, owner.ID, goals.GoalFilter{GoalType: goals.GoalTypeBoolean}, firstPage("title", false, 10))
+		if err != nil {
+			t.Fatalf("GetGoalsByUserID: %v", err)
+		}
+		wantIDs(t, "boolean goals", goalIDs(list), []string{b.ID})
+
+		page := firstPage("title", false, 2)
+		first, next, err := s.Goals.GetGoalsByUserID(ctx, owner.ID, goals.GoalFilter{}, page)
+		if err != nil {
+			t.Fatalf("GetGoalsByUserID: %v", err)
+		}
+		wantIDs(t, "first page", goalIDs(first), []string{a.ID, b.ID})
+		if next == nil {
+			t.Fatal("first page has no next cursor")
+		}
+
+		page.After = next
+		second, next, err := s.Goals.GetGoalsByUserID(ctx, owner.ID, goals.GoalFilter{}, page)
+		if err != nil {
+			t.Fatalf("GetGoalsByUserID: %v", err)
+		}
+		wantIDs(t, "second page", goalIDs(second), []string{c.ID})
+		if next != nil {
+			t.Errorf("last page has a next cursor %+v", next)
+		}
+
+		desc, _, err := s.Goals.GetGoalsByUserID(ctx, owner.ID, goals.GoalFilter{}, firstPage("title", true, 10))
+		if err != nil {
+			t.Fatalf("GetGoalsByUserID: %v", err)
+		}
+		wantIDs(t, "titles descending", goalIDs(desc), []string{c.ID, b.ID, a.ID})
+
+		byCreated, next, err := s.Goals.GetGoalsByUserID(ctx, owner.ID, goals.GoalFilter{}, firstPage("created_at", false, 1))
+		if err != nil {
+			t.Fatalf("GetGoalsByUserID: %v", err)
+		}
+		if len(byCreated) != 1 || next == nil {
+			t.Fatalf("created_at page returned %d goals and cursor %v", len(byCreated), next)
+		}
+		rest, _, err := s.Goals.GetGoalsByUserID(ctx, owner.ID, goals.GoalFilter{}, pagination.Page{Limit: 10, Sort: "created_at", After: next})
+		if err != nil {
+			t.Fatalf("GetGoalsByUserID: %v", err)
+		}
+		if len(rest) != 2 || slices.Contains(goalIDs(rest), byCreated[0].ID) {
+			t.Errorf("pages by created_at overlap or miss goals: %v then %v", goalIDs(byCreated), goalIDs(rest))
+		}
+	})
+}
+
+func runInstances(t *testing.T, newStores func(t *testing.T) Stores) {
+	ctx := context.Background()
+
+	t.Run("Upsert", func(t *testing.T) {
+		s := newStores(t)
+		owner := mustCreateUser(t, s, uniqueEmail())
+		goal := mustCreateGoal(t, s, owner.ID, "read")
+
+		_, err := s.Goals.UpsertDailyInstance(ctx, goal.ID, owner.ID, day(0), goals.UpdateDailyInstanceRequest{CompletedValue: ptr(3.0)}, goals.Versions{2})
+		if !errors.Is(err, goals.ErrVersionMismatch) {
+			t.Errorf("creating an instance at version 2: got %v, want ErrVersionMismatch", err)
+		}
+
+		created, err := s.Goals.UpsertDailyInstance(ctx, goal.ID, owner.ID, day(0), goals.UpdateDailyInstanceRequest{CompletedValue: ptr(3.0)}, nil)
+		if err != nil {
+			t.Fatalf("UpsertDailyInstance: %v", err)
+		}
+		inst := created.Instance
+		if inst.Version != 1 || inst.CompletedValue == nil || *inst.CompletedValue != 3 || inst.IsCompleted ||
+			inst.TargetValue == nil || *inst.TargetValue != 10 || !inst.Date.Equal(day(0)) {
+			t.Errorf("created instance is %+v", inst)
+		}
+		if created.Previous.Version != 1 || created.Previous.CompletedValue != nil || created.Previous.IsCompleted {
+			t.Errorf("previous of a created instance is %+v, want the empty initial state", created.Previous)
+		}
+		if created.Goal.ID != goal.ID {
+			t.Errorf("upsert returned goal %s, want %s", created.Goal.ID, goal.ID)
+		}
+
+		completed, err := s.Goals.UpsertDailyInstance(ctx, goal.ID, owner.ID, day(0), goals.UpdateDailyInstanceRequest{IsCompleted: ptr(true)}, goals.Versions{1})
+		if err != nil {
+			t.Fatalf("UpsertDailyInstance: %v", err)
+		}
+		inst = completed.Instance
+		if inst.ID != created.Instance.ID || inst.Version != 2 || !inst.IsCompleted || inst.CompletedAt == nil ||
+			inst.CompletedValue == nil || *inst.CompletedValue != 3 {
+			t.Errorf("updated instance is %+v", inst)
+		}
+		if completed.Previous.Version != 1 || completed.Previous.IsCompleted {
+			t.Errorf("previous of an updated instance is %+v", completed.Previous)
+		}
+
+		_, err = s.Goals.UpsertDailyInstance(ctx, goal.ID, owner.ID, day(0), goals.UpdateDailyInstanceRequest{IsCompleted: ptr(false)}, goals.Versions{1})
+		if !errors.Is(err, goals.ErrVersionMismatch) {
+			t.Errorf("updating at a stale version: got %v, want ErrVersionMismatch", err)
+		}
+
+		reopened, err := s.Goals.UpsertDailyInstance(ctx, goal.ID, owner.ID, day(0), goals.UpdateDailyInstanceRequest{IsCompleted: ptr(false)}, nil)
+		if err != nil {
+			t.Fatalf("UpsertDailyInstance: %v", err)
+		}
+		if reopened.Instance.IsCompleted || reopened.Instance.CompletedAt != nil || reopened.Instance.Version != 3 {
+			t.Errorf("reopened instance is %+v", reopened.Instance)
+		}
+
+		_, err = s.Goals.UpsertDailyInstance(ctx, uuid.New().String(), owner.ID, day(0), goals.UpdateDailyInstanceRequest{IsCompleted: ptr(true)}, nil)
+		wantKind(t, "UpsertDailyInstance of a missing goal", err, apperr.KindNotFound)
+	})
+
+	t.Run("HistoryAndStreak", func(t *testing.T) {
+		s := newStores(t)
+		owner := mustCreateUser(t, s, uniqueEmail())
+		goal := mustCreateGoal(t, s, owner.ID, "read")
+
+		// Completed on days -4, -2, -1 and 0; reported but not completed
+		// on day -3.
+		for _, offset := range []int{-4, -2, -1, 0} {
+			mustUpsert(t, s, goal.ID, owner.ID, day(offset), goals.UpdateDailyInstanceRequest{IsCompleted: ptr(true)})
+		}
+		mustUpsert(t, s, goal.ID, owner.ID, day(-3), goals.UpdateDailyInstanceRequest{CompletedValue: ptr(1.0)})
+
+		for offset, want := range map[int]int{0: 3, -1: 2, -3: 0, -4: 1, -5: 0} {
+			streak, err := s.Goals.GetCurrentStreak(ctx, goal.ID, day(offset))
+			if err != nil {
+				t.Fatalf("GetCurrentStreak: %v", err)
+			}
+			if streak != want {
+				t.Errorf("streak on day %d is %d, want %d", offset, streak, want)
+			}
+		}
+
+		filter := goals.InstanceFilter{StartDate: day(-3), EndDate: day(0)}
+		history, _, err := s.Goals.GetDailyInstancesByGoal(ctx, goal.ID, owner.ID, filter, firstPage("date", true, 10))
+		if err != nil {
+			t.Fatalf("GetDailyInstancesByGoal: %v", err)
+		}
+		wantDates(t, "history", history, []int{0, -1, -2, -3})
+
+		filter.CompletedOnly = true
+		page := firstPage("date", false, 2)
+		first, next, err := s.Goals.GetDailyInstancesByGoal(ctx, goal.ID, owner.ID, filter, page)
+		if err != nil {
+			t.Fatalf("GetDailyInstancesByGoal: %v", err)
+		}
+		wantDates(t, "first completed page", first, []int{-2, -1})
+		if next == nil {
+			t.Fatal("first completed page has no next cursor")
+		}
+		page.After = next
+		second, next, err := s.Goals.GetDailyInstancesByGoal(ctx, goal.ID, owner.ID, filter, page)
+		if err != nil {
+			t.Fatalf("GetDailyInstancesByGoal: %v", err)
+		}
+		wantDates(t, "second completed page", second, []int{0})
+		if next != nil {
+			t.Errorf("last page has a next cursor %+v", next)
+		}
+
+		other := mustCreateUser(t, s, uniqueEmail())
+		hidden, _, err := s.Goals.GetDailyInstancesByGoal(ctx, goal.ID, other.ID, goals.InstanceFilter{StartDate: day(-3), EndDate: day(0)}, firstPage("date", true, 10))
+		if err != nil {
+			t.Fatalf("GetDailyInstancesByGoal: %v", err)
+		}
+		if len(hidden) != 0 {
+			t.Errorf("another user sees %d instances, want 0", len(hidden))
+		}
+	})
+
+	t.Run("Today", func(t *testing.T) {
+		s := newStores(t)
+		owner := mustCreateUser(t, s, uniqueEmail())
+		done := mustCreateGoal(t, s, owner.ID, "done")
+		pending := mustCreateGoal(t, s, owner.ID, "pending")
+
+		now := time.Now().UTC()
+		mustUpsert(t, s, done.ID, owner.ID, now, goals.UpdateDailyInstanceRequest{IsCompleted: ptr(true)})
+		mustUpsert(t, s, pending.ID, owner.ID, now.AddDate(0, 0, -1), goals.UpdateDailyInstanceRequest{IsCompleted: ptr(true)})
+
+		today, err := s.Goals.GetGoalsWithTodayInstances(ctx, owner.ID)
+		if err != nil {
+			t.Fatalf("GetGoalsWithTodayInstances: %v", err)
+		}
+		if len(today) != 2 {
+			t.Fatalf("GetGoalsWithTodayInstances returned %d goals, want 2", len(today))
+		}
+		for _, g := range today {
+			switch g.Goal.ID {
+			case done.ID:
+				if g.TodayInstance == nil || !g.TodayInstance.IsCompleted {
+					t.Errorf("completed goal's today instance is %+v", g.TodayInstance)
+				}
+			case pending.ID:
+				if g.TodayInstance != nil {
+					t.Errorf("goal completed only yesterday has today instance %+v", g.TodayInstance)
+				}
+			}
+		}
+	})
+}
+
+func mustCreateUser(t *testing.T, s Stores, email string) *user.User {
+	t.Helper()
+	return createUser(t, s.Users, email)
+}
+
+func createUser(t testing.TB, users user.UserStore, email string) *user.User {
+	t.Helper()
+	u, err := users.CreateUser(context.Background(), email, "Test", "password1")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return u
+}
+
+func mustCreateGoal(t *testing.T, s Stores, userID, title string) *goals.Goal {
+	t.Helper()
+	goal, err := s.Goals.CreateGoal(context.Background(), userID, goals.CreateGoalRequest{
+		Title:       title,
+		GoalType:    goals.GoalTypeNumeric,
+		TargetValue: ptr(10.0),
+		Visibility:  goals.VisibilityPrivate,
+		Difficulty:  goals.DifficultyMedium,
+	})
+	if err != nil {
+		t.Fatalf("CreateGoal: %v", err)
+	}
+	return goal
+}
+
+func mustCreateBooleanGoal(t *testing.T, s Stores, userID, title string) *goals.Goal {
+	t.Helper()
+	goal, err := s.Goals.CreateGoal(context.Background(), userID, goals.CreateGoalRequest{
+		Title:      title,
+		GoalType:   goals.GoalTypeBoolean,
+		Visibility: goals.VisibilityPrivate,
+		Difficulty: goals.DifficultyEasy,
+	})
+	if err != nil {
+		t.Fatalf("CreateGoal: %v", err)
+	}
+	return goal
+}
+
+func mustUpsert(t *testing.T, s Stores, goalID, userID string, date time.Time, req goals.UpdateDailyInstanceRequest) {
+	t.Helper()
+	if _, err := s.Goals.UpsertDailyInstance(context.Background(), goalID, userID, date, req, nil); err != nil {
+		t.Fatalf("UpsertDailyInstance: %v", err)
+	}
+}
+
+func wantKind(t *testing.T, what string, err error, kind apperr.Kind) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("%s succeeded, want a %v error", what, kind)
+		return
+	}
+	if got := apperr.KindOf(err); got != kind {
+		t.Errorf("%s: got %v (%v), want a %v error", what, got, err, kind)
+	}
+}
+
+func wantIDs(t *testing.T, what string, got, want []string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("%s: got %v, want %v", what, got, want)
+	}
+}
+
+func wantDates(t *testing.T, what string, instances []goals.DailyGoalInstance, offsets []int) {
+	t.Helper()
+	got := make([]string, len(instances))
+	for i, instance := range instances {
+		got[i] = instance.Date.Format(time.DateOnly)
+	}
+	want := make([]string, len(offsets))
+	for i, offset := range offsets {
+		want[i] = day(offset).Format(time.DateOnly)
+	}
+	wantIDs(t, what, got, want)
+}
+
+func goalIDs(list []goals.Goal) []string {
+	ids := make([]string, len(list))
+	for i, goal := range list {
+		ids[i] = goal.ID
+	}
+	return ids
+}
+
+func firstPage(sort string, desc bool, limit int) pagination.Page {
+	return pagination.Page{Limit: limit, Sort: sort, Desc: desc}
+}
+
+// day returns a fixed date offset by n days, far from today so that it does
+// not collide with instances other tests create for today.
+func day(n int) time.Time {
+	return time.Date(2020, time.June, 15, 0, 0, 0, 0, time.UTC).AddDate(0, 0, n)
+}
+
+func uniqueEmail() string {
+	return "storetest-" + uuid.New().String() + "@example.com"
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -5,16 +5,27 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
 	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/JoshPugli/grindhouse-api/internal/xp"
 )
 
+// meRecentAwards is how many recent experience awards HandleMe includes.
+const meRecentAwards = 5
+
 type Handlers struct {
-	userRepo *Repository
+	userRepo  UserStore
+	xpService *xp.Service
+	authn     *auth.Authenticator
 }
 
-func NewHandlers(userRepo *Repository) *Handlers {
+// NewHandlers returns the user handlers. xpService may be nil on stores
+// that keep no experience, and HandleMe then leaves it out.
+func NewHandlers(userRepo UserStore, xpService *xp.Service, authn *auth.Authenticator) *Handlers {
 	return &Handlers{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		xpService: xpService,
+		authn:     authn,
 	}
 }
 
@@ -42,31 +53,31 @@ type AuthResponse struct {
 // @Produce json
 // @Param login body LoginRequest true "Login credentials"
 // @Success 200 {object} AuthResponse
-// @Failure 400 {string} string "Invalid JSON"
-// @Failure 401 {string} string "Invalid credentials"
-// @Failure 500 {string} string "Failed to generate token"
-// @Router /api/auth/login [post]
+// @Failure 400 {object} apperr.Problem "Invalid JSON or missing credentials"
+// @Failure 401 {object} apperr.Problem "Invalid credentials"
+// @Failure 500 {object} apperr.Problem "Failed to generate token"
+// @Router /auth/login [post]
 func (h *Handlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
-	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := req.Validate(); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	user, err := h.userRepo.ValidatePassword(req.Email, req.Password)
+	user, err := h.userRepo.ValidatePassword(r.Context(), req.Email, req.Password)
 	if err != nil {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		apperr.Write(w, r, err)
 		return
 	}
 
-	token, err := auth.GenerateJWT(user.ID)
+	token, err := h.authn.GenerateJWT(user.ID)
 	if err != nil {
-		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		apperr.WriteProblem(w, r, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
@@ -91,36 +102,31 @@ func (h *Handlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param register body RegisterRequest true "User registration data"
 // @Success 201 {object} AuthResponse
-// @Failure 400 {string} string "Invalid JSON or missing required fields"
-// @Failure 409 {string} string "User already exists"
-// @Failure 500 {string} string "Failed to generate token"
-// @Router /api/auth/register [post]
+// @Failure 400 {object} apperr.Problem "Invalid JSON or validation error"
+// @Failure 409 {object} apperr.Problem "User already exists"
+// @Failure 500 {object} apperr.Problem "Failed to generate token"
+// @Router /auth/register [post]
 func (h *Handlers) HandleRegister(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
-	if req.Email == "" || req.Password == ""  {
-		http.Error(w, "Email, username, and password are required", http.StatusBadRequest)
+	if err := req.Validate(); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	user, err := h.userRepo.CreateUser(req.Email, req.FirstName, req.Password)
+	user, err := h.userRepo.CreateUser(r.Context(), req.Email, req.FirstName, req.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusConflict)
+		apperr.Write(w, r, err)
 		return
 	}
 
-	token, err := auth.GenerateJWT(user.ID)
+	token, err := h.authn.GenerateJWT(user.ID)
 	if err != nil {
-		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		apperr.WriteProblem(w, r, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
@@ -140,29 +146,25 @@ func (h *Handlers) HandleRegister(w http.ResponseWriter, r *http.Request) {
 
 // HandleMe godoc
 // @Summary Get current user
-// @Description Get the currently authenticated user's information
+// @Description Get the currently authenticated user's information, including their experience summary. Servers that store data in SQLite keep no experience and leave it out.
 // @Tags auth
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{}
-// @Failure 401 {string} string "User not found in context"
-// @Failure 404 {string} string "User not found"
-// @Router /api/auth/me [get]
+// @Failure 401 {object} apperr.Problem "User not found in context"
+// @Failure 404 {object} apperr.Problem "User not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /auth/me [get]
 func (h *Handlers) HandleMe(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
-	user, err := h.userRepo.GetUserByID(userID)
+	user, err := h.userRepo.GetUserByID(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -172,6 +174,15 @@ func (h *Handlers) HandleMe(w http.ResponseWriter, r *http.Request) {
 		"first_name": user.FirstName,
 	}
 
+	if h.xpService != nil {
+		summary, err := h.xpService.Summary(r.Context(), userID, meRecentAwards)
+		if err != nil {
+			apperr.Write(w, r, err)
+			return
+		}
+		userResponse["xp"] = summary
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(userResponse)
-}
\ No newline at end of file
+}
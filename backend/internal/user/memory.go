@@ -0,0 +1,84 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
+
+// MemoryStore is a UserStore that keeps users in memory, for tests and for
+// running the server without a database. It is safe for concurrent use.
+type MemoryStore struct {
+	mu      sync.Mutex
+	byID    map[string]User
+	byEmail map[string]string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		byID:    make(map[string]User),
+		byEmail: make(map[string]string),
+	}
+}
+
+func (s *MemoryStore) CreateUser(ctx context.Context, email, firstName, password string) (*User, error) {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, taken := s.byEmail[email]; taken {
+		return nil, fmt.Errorf("failed to create user: %w", apperr.Conflict("email is already registered"))
+	}
+
+	user := User{
+		ID:        uuid.New().String(),
+		Email:     email,
+		FirstName: firstName,
+		Password:  hashedPassword,
+	}
+	s.byID[user.ID] = user
+	s.byEmail[email] = user.ID
+
+	return &User{
+		ID:        user.ID,
+		Email:     email,
+		FirstName: firstName,
+	}, nil
+}
+
+func (s *MemoryStore) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.byEmail[email]
+	if !ok {
+		return nil, apperr.NotFound("user not found")
+	}
+	user := s.byID[id]
+	return &user, nil
+}
+
+func (s *MemoryStore) GetUserByID(ctx context.Context, id string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.byID[id]
+	if !ok {
+		return nil, apperr.NotFound("user not found")
+	}
+	user.Password = ""
+	return &user, nil
+}
+
+func (s *MemoryStore) ValidatePassword(ctx context.Context, email, password string) (*User, error) {
+	user, err := s.GetUserByEmail(ctx, email)
+	return checkPassword(user, err, password)
+}
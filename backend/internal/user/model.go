@@ -5,4 +5,4 @@ type User struct {
 	Email     string `json:"email" db:"email"`
 	Password  string `json:"-" db:"password"` // Never expose in JSON
 	FirstName string `json:"first_name" db:"first_name"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,35 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
+
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+// checkPassword implements ValidatePassword for every store, given the
+// result of looking the user up by email.
+func checkPassword(user *User, lookupErr error, password string) (*User, error) {
+	if errors.Is(lookupErr, apperr.ErrNotFound) {
+		return nil, apperr.Unauthorized("invalid credentials")
+	}
+	if lookupErr != nil {
+		return nil, lookupErr
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, apperr.Unauthorized("invalid credentials")
+	}
+
+	return user, nil
+}
@@ -1,10 +1,11 @@
 package user
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
 )
 
 type Repository struct {
@@ -15,10 +16,10 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) CreateUser(email, firstName, password string) (*User, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (r *Repository) CreateUser(ctx context.Context, email, firstName, password string) (*User, error) {
+	hashedPassword, err := hashPassword(password)
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return nil, err
 	}
 
 	query := `
@@ -27,9 +28,9 @@ func (r *Repository) CreateUser(email, firstName, password string) (*User, error
 		RETURNING id`
 
 	var id string
-	err = r.db.QueryRow(query, email, firstName, string(hashedPassword)).Scan(&id)
+	err = r.db.QueryRowContext(ctx, query, email, firstName, hashedPassword).Scan(&id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, fmt.Errorf("failed to create user: %w", apperr.FromUniqueViolation(err, "email is already registered"))
 	}
 
 	return &User{
@@ -39,16 +40,16 @@ func (r *Repository) CreateUser(email, firstName, password string) (*User, error
 	}, nil
 }
 
-func (r *Repository) GetUserByEmail(email string) (*User, error) {
+func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	query := `SELECT id, email, first_name, password FROM users WHERE email = $1`
 
 	user := &User{}
-	err := r.db.QueryRow(query, email).Scan(
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID, &user.Email, &user.FirstName, &user.Password,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperr.NotFound("user not found")
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -56,16 +57,16 @@ func (r *Repository) GetUserByEmail(email string) (*User, error) {
 	return user, nil
 }
 
-func (r *Repository) GetUserByID(id string) (*User, error) {
-	query := `SELECT id, email, first_name, last_name FROM users WHERE id = $1`
+func (r *Repository) GetUserByID(ctx context.Context, id string) (*User, error) {
+	query := `SELECT id, email, first_name FROM users WHERE id = $1`
 
 	user := &User{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.Email, &user.FirstName,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperr.NotFound("user not found")
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -73,16 +74,7 @@ func (r *Repository) GetUserByID(id string) (*User, error) {
 	return user, nil
 }
 
-func (r *Repository) ValidatePassword(email, password string) (*User, error) {
-	user, err := r.GetUserByEmail(email)
-	if err != nil {
-		return nil, err
-	}
-
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
-	if err != nil {
-		return nil, fmt.Errorf("invalid password")
-	}
-
-	return user, nil
-}
\ No newline at end of file
+func (r *Repository) ValidatePassword(ctx context.Context, email, password string) (*User, error) {
+	user, err := r.GetUserByEmail(ctx, email)
+	return checkPassword(user, err, password)
+}
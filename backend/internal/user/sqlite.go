@@ -0,0 +1,50 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/dialect"
+)
+
+// SQLiteStore is a UserStore on SQLite. Lookups run the Repository's
+// queries unchanged; only creating a user differs, because SQLite generates
+// neither the id nor the timestamps.
+type SQLiteStore struct {
+	Repository
+	clock func() time.Time
+}
+
+func NewSQLiteStore(db *sql.DB, clock func() time.Time) *SQLiteStore {
+	return &SQLiteStore{Repository: Repository{db: db}, clock: clock}
+}
+
+func (s *SQLiteStore) CreateUser(ctx context.Context, email, firstName, password string) (*User, error) {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
+	now := dialect.SQLite.Timestamp(s.clock())
+
+	query := `
+		INSERT INTO users (id, email, first_name, password, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)`
+
+	_, err = s.db.ExecContext(ctx, query, id, email, firstName, hashedPassword, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", apperr.FromUniqueViolation(err, "email is already registered"))
+	}
+
+	return &User{
+		ID:        id,
+		Email:     email,
+		FirstName: firstName,
+	}, nil
+}
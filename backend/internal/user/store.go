@@ -0,0 +1,26 @@
+package user
+
+import "context"
+
+// UserStore persists users. Emails are unique, compared exactly;
+// registering a taken email is a conflict.
+//
+// Repository implements it on PostgreSQL, SQLiteStore on SQLite and
+// MemoryStore in memory. All of them must pass the storetest conformance
+// suite.
+type UserStore interface {
+	CreateUser(ctx context.Context, email, firstName, password string) (*User, error)
+	// GetUserByEmail returns the user with their password hash.
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	// GetUserByID returns the user without their password hash.
+	GetUserByID(ctx context.Context, id string) (*User, error)
+	// ValidatePassword returns the user if password is theirs. An unknown
+	// email and a wrong password are the same Unauthorized error.
+	ValidatePassword(ctx context.Context, email, password string) (*User, error)
+}
+
+var (
+	_ UserStore = (*Repository)(nil)
+	_ UserStore = (*SQLiteStore)(nil)
+	_ UserStore = (*MemoryStore)(nil)
+)
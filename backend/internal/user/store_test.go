@@ -0,0 +1,30 @@
+package user_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+	"github.com/JoshPugli/grindhouse-api/internal/user"
+)
+
+func TestMemoryStore(t *testing.T) {
+	storetest.RunUsers(t, func(t *testing.T) storetest.Stores {
+		return storetest.Stores{Goals: goals.NewMemoryStore(time.Now), Users: user.NewMemoryStore()}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	db := storetest.OpenPostgres(t)
+	storetest.RunUsers(t, func(t *testing.T) storetest.Stores {
+		return storetest.Stores{Goals: goals.NewRepository(db, time.Now), Users: user.NewRepository(db)}
+	})
+}
+
+func TestSQLiteStore(t *testing.T) {
+	db := storetest.OpenSQLite(t)
+	storetest.RunUsers(t, func(t *testing.T) storetest.Stores {
+		return storetest.Stores{Goals: goals.NewSQLiteStore(db, time.Now), Users: user.NewSQLiteStore(db, time.Now)}
+	})
+}
@@ -0,0 +1,73 @@
+package user
+
+import (
+	"net/mail"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
+
+// Limits of the users columns and of bcrypt, which ignores everything after
+// the 72nd byte of a password.
+const (
+	maxEmailLength     = 255
+	maxFirstNameLength = 100
+	minPasswordLength  = 8
+	maxPasswordBytes   = 72
+)
+
+// Validate checks the registration details and reports all problems at once.
+func (req RegisterRequest) Validate() error {
+	var fields apperr.Fields
+
+	validateEmail(&fields, req.Email)
+
+	if utf8.RuneCountInString(req.FirstName) > maxFirstNameLength {
+		fields.Add("first_name", "must be at most 100 characters")
+	}
+
+	switch {
+	case req.Password == "":
+		fields.Add("password", "is required")
+	case utf8.RuneCountInString(req.Password) < minPasswordLength:
+		fields.Add("password", "must be at least 8 characters")
+	case len(req.Password) > maxPasswordBytes:
+		fields.Add("password", "must be at most 72 bytes")
+	case !strings.ContainsFunc(req.Password, unicode.IsLetter) || !strings.ContainsFunc(req.Password, unicode.IsDigit):
+		fields.Add("password", "must contain at least one letter and one digit")
+	}
+
+	return fields.Err()
+}
+
+// Validate only checks that credentials were given; whether they are right
+// is for ValidatePassword to decide.
+func (req LoginRequest) Validate() error {
+	var fields apperr.Fields
+	if req.Email == "" {
+		fields.Add("email", "is required")
+	}
+	if req.Password == "" {
+		fields.Add("password", "is required")
+	}
+	return fields.Err()
+}
+
+func validateEmail(fields *apperr.Fields, email string) {
+	if email == "" {
+		fields.Add("email", "is required")
+		return
+	}
+	if len(email) > maxEmailLength {
+		fields.Add("email", "must be at most 255 characters")
+		return
+	}
+
+	// Only a bare address is accepted, not "Name <address>".
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(email[strings.LastIndex(email, "@"):], ".") {
+		fields.Add("email", "must be a valid email address")
+	}
+}
@@ -0,0 +1,92 @@
+package webhooks
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// nonPublic lists ranges that are not public but that netip's predicates
+// do not cover.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+}
+
+// NewClient returns the client deliveries are sent with. It gives up after
+// timeout and refuses to connect to anything but public addresses, so a
+// subscription cannot reach the server's loopback interface, its private
+// network or a link-local metadata service such as 169.254.169.254. Ranges
+// in allowed are accepted anyway.
+//
+// The check is made on the address each connection is dialled to, after
+// DNS resolution and for every redirect, so a hostname that resolves or
+// redirects to a refused address is caught as well. Proxies configured in
+// the environment are not used, as they would hide the destination.
+func NewClient(timeout time.Duration, allowed []netip.Prefix) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return checkDestination(address, allowed)
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// ParseNetworks parses CIDR ranges, such as the configured allowed
+// networks, for NewClient.
+func ParseNetworks(networks []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, len(networks))
+	for i, network := range networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", network, err)
+		}
+		prefixes[i] = prefix
+	}
+	return prefixes, nil
+}
+
+// checkDestination returns an error unless address, an IP and port about to
+// be dialled, is public or in allowed.
+func checkDestination(address string, allowed []netip.Prefix) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid destination %q: %w", address, err)
+	}
+	addr := addrPort.Addr().Unmap()
+
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	if !isPublic(addr) {
+		return fmt.Errorf("destination %s is not a public address", addr)
+	}
+	return nil
+}
+
+func isPublic(addr netip.Addr) bool {
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublic {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature-256"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	// MaxAttempts is how many times a delivery is tried before it is marked
+	// as failed.
+	MaxAttempts = 8
+
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+
+	// claimBatchSize is how many deliveries ProcessDue sends at most.
+	claimBatchSize = 20
+	// claimMargin is added to the send timeout to lease a claimed delivery,
+	// leaving time to record the attempt before another instance may claim
+	// it again.
+	claimMargin = 30 * time.Second
+	// defaultSendTimeout bounds a send when the client has no timeout.
+	defaultSendTimeout = 10 * time.Second
+)
+
+// Dispatcher drains the delivery queue, POSTing signed payloads to
+// subscribers and rescheduling failures with exponential backoff.
+type Dispatcher struct {
+	webhookRepo  *Repository
+	client       *http.Client
+	pollInterval time.Duration
+	// clock dates the signature of each send.
+	clock  func() time.Time
+	logger *slog.Logger
+}
+
+func NewDispatcher(webhookRepo *Repository, client *http.Client, pollInterval time.Duration, clock func() time.Time, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		webhookRepo:  webhookRepo,
+		client:       client,
+		pollInterval: pollInterval,
+		clock:        clock,
+		logger:       logger,
+	}
+}
+
+// Run processes due deliveries every poll interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.ProcessDue(ctx); err != nil {
+			d.logger.Error("failed to process webhook deliveries", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue sends up to claimBatchSize due deliveries and returns how
+// many were attempted. Each delivery is claimed just before it is sent and
+// leased for no longer than the send can take, so another instance cannot
+// claim it while it is in flight, and an error leaves no other deliveries
+// leased.
+func (d *Dispatcher) ProcessDue(ctx context.Context) (int, error) {
+	sendTimeout := d.client.Timeout
+	if sendTimeout <= 0 {
+		sendTimeout = defaultSendTimeout
+	}
+
+	for attempted := 0; attempted < claimBatchSize; attempted++ {
+		due, err := d.webhookRepo.claimDue(ctx, 1, sendTimeout+claimMargin)
+		if err != nil {
+			return attempted, err
+		}
+		if len(due) == 0 {
+			return attempted, nil
+		}
+		delivery := due[0]
+
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		responseStatus, sendErr := d.send(sendCtx, delivery)
+		cancel()
+
+		var retryAfter *time.Duration
+		if sendErr != nil && delivery.Attempts+1 < MaxAttempts {
+			backoff := Backoff(delivery.Attempts + 1)
+			retryAfter = &backoff
+		}
+
+		if err := d.webhookRepo.recordAttempt(ctx, delivery.ID, responseStatus, sendErr, retryAfter); err != nil {
+			return attempted + 1, err
+		}
+	}
+
+	return claimBatchSize, nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery dueDelivery) (*int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "grindhouse-webhooks/1")
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	timestamp := d.clock().Unix()
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	status := resp.StatusCode
+	if status < 200 || status >= 300 {
+		return &status, fmt.Errorf("receiver responded with %d", status)
+	}
+
+	return &status, nil
+}
+
+// Sign returns the signature header value for body sent at timestamp, in
+// Unix seconds: "sha256=" followed by the hex HMAC-SHA256, keyed with the
+// subscription secret, of the timestamp header value, a dot and the body.
+// Signing the timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid Sign result for body and the
+// timestamp header value, and the timestamp is within tolerance of now.
+// Receivers written in Go can use it to authenticate deliveries.
+func Verify(secret, timestamp string, body []byte, signature string, tolerance time.Duration) bool {
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := time.Since(time.Unix(sent, 0)); age > tolerance || age < -tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, sent, body)), []byte(signature))
+}
+
+// Backoff returns the delay before retrying after the given number of
+// failed attempts: 30s, 1m, 2m, ... capped at six hours.
+func Backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,189 @@
+package webhooks
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+)
+
+const testSecret = "whsec_test"
+
+// loopback lets tests deliver to an httptest server, which NewClient
+// refuses by default.
+var loopback = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+// receiver is a subscriber endpoint that records what it was sent.
+type receiver struct {
+	*httptest.Server
+	requests chan *http.Request
+	bodies   chan []byte
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	t.Helper()
+
+	rc := &receiver{requests: make(chan *http.Request, 10), bodies: make(chan []byte, 10)}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rc.requests <- r
+		rc.bodies <- body
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func TestCheckDestination(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed []netip.Prefix
+		want    bool
+	}{
+		{"93.184.216.34:443", nil, true},
+		{"[2606:4700::1111]:443", nil, true},
+		{"127.0.0.1:80", nil, false},
+		{"[::1]:80", nil, false},
+		{"[::ffff:127.0.0.1]:80", nil, false},
+		{"10.0.0.5:80", nil, false},
+		{"172.16.0.1:80", nil, false},
+		{"192.168.1.1:80", nil, false},
+		{"169.254.169.254:80", nil, false},
+		{"[fe80::1]:80", nil, false},
+		{"[fd00::1]:80", nil, false},
+		{"100.64.0.1:80", nil, false},
+		{"0.0.0.0:80", nil, false},
+		{"10.0.0.5:80", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, true},
+		{"169.254.169.254:80", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := checkDestination(tt.address, tt.allowed)
+			if got := err == nil; got != tt.want {
+				t.Errorf("checkDestination allowed %v (%v), want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendRefusesLoopback(t *testing.T) {
+	rc := newReceiver(t, http.StatusOK)
+
+	// The redirect target is loopback too, but outside the allowed range.
+	redirect := httptest.NewServer(http.RedirectHandler("http://127.0.0.2:1/hook", http.StatusTemporaryRedirect))
+	t.Cleanup(redirect.Close)
+
+	tests := []struct {
+		name    string
+		url     string
+		allowed []netip.Prefix
+	}{
+		{"loopback", rc.URL, nil},
+		{"hostname resolving to loopback", strings.Replace(rc.URL, "127.0.0.1", "localhost", 1), nil},
+		{"redirect to a refused address", redirect.URL, []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDispatcher(nil, NewClient(time.Second, tt.allowed), time.Second, time.Now, slog.New(slog.DiscardHandler))
+			delivery := dueDelivery{Delivery: Delivery{ID: uuid.New().String(), EventType: EventGoalCreated, Payload: []byte(`{}`)}, URL: tt.url, Secret: testSecret}
+
+			if _, err := d.send(t.Context(), delivery); err == nil || !strings.Contains(err.Error(), "not a public address") {
+				t.Errorf("send returned %v, want the destination refused", err)
+			}
+		})
+	}
+	if len(rc.requests) != 0 {
+		t.Errorf("receiver got %d requests, want none", len(rc.requests))
+	}
+}
+
+func TestSendSigns(t *testing.T) {
+	rc := newReceiver(t, http.StatusNoContent)
+	d := NewDispatcher(nil, NewClient(time.Second, loopback), time.Second, time.Now, slog.New(slog.DiscardHandler))
+	payload := []byte(`{"event":"goal.created"}`)
+	delivery := dueDelivery{Delivery: Delivery{ID: uuid.New().String(), EventType: EventGoalCreated, Payload: payload}, URL: rc.URL, Secret: testSecret}
+
+	status, err := d.send(t.Context(), delivery)
+	if err != nil || status == nil || *status != http.StatusNoContent {
+		t.Fatalf("send returned %v, %v, want 204", status, err)
+	}
+
+	r, body := <-rc.requests, <-rc.bodies
+	timestamp, signature := r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader)
+	if !Verify(testSecret, timestamp, body, signature, time.Minute) {
+		t.Errorf("signature %q does not verify for timestamp %q", signature, timestamp)
+	}
+	if r.Header.Get(DeliveryHeader) != delivery.ID || r.Header.Get(EventHeader) != string(EventGoalCreated) {
+		t.Errorf("headers are %v, want the delivery ID and event", r.Header)
+	}
+
+	// The signature covers the timestamp, so neither can be swapped.
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp %q is not Unix seconds", timestamp)
+	}
+	if Verify(testSecret, strconv.FormatInt(sent+1, 10), body, signature, time.Minute) {
+		t.Error("signature verifies with another timestamp")
+	}
+	old := time.Now().Add(-time.Hour).Unix()
+	if Verify(testSecret, strconv.FormatInt(old, 10), body, Sign(testSecret, old, body), time.Minute) {
+		t.Error("an hour old delivery verifies")
+	}
+}
+
+// TestProcessDue delivers a queued event to a local receiver end to end.
+func TestProcessDue(t *testing.T) {
+	db := storetest.OpenPostgres(t)
+	ctx := t.Context()
+
+	owner := storetest.NewUser(t, db)
+
+	rc := newReceiver(t, http.StatusOK)
+	repo := NewRepository(db)
+	subscription, err := repo.CreateSubscription(ctx, owner.ID, rc.URL, nil)
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	if err := repo.Enqueue(ctx, owner.ID, EventGoalCreated, []byte(`{"event":"goal.created"}`)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	d := NewDispatcher(repo, NewClient(time.Second, loopback), time.Second, time.Now, slog.New(slog.DiscardHandler))
+	// Other tests may have left deliveries queued, so keep draining until
+	// ours has been sent.
+	for range 5 {
+		if _, err := d.ProcessDue(ctx); err != nil {
+			t.Fatalf("ProcessDue: %v", err)
+		}
+		if len(rc.requests) > 0 {
+			break
+		}
+	}
+
+	select {
+	case r := <-rc.requests:
+		body := <-rc.bodies
+		if !Verify(subscription.Secret, r.Header.Get(TimestampHeader), body, r.Header.Get(SignatureHeader), time.Minute) {
+			t.Error("delivery signature does not verify with the subscription secret")
+		}
+	default:
+		t.Fatal("receiver got no delivery")
+	}
+
+	deliveries, err := repo.GetDeliveries(ctx, subscription.ID, owner.ID, 10)
+	if err != nil {
+		t.Fatalf("GetDeliveries: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != StatusSucceeded {
+		t.Errorf("deliveries are %+v, want one succeeded", deliveries)
+	}
+}
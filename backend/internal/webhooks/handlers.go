@@ -0,0 +1,222 @@
+// Package webhooks delivers signed goal and check-in events to user-registered URLs
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+type Handlers struct {
+	webhookRepo *Repository
+}
+
+func NewHandlers(webhookRepo *Repository) *Handlers {
+	return &Handlers{
+		webhookRepo: webhookRepo,
+	}
+}
+
+// HandleCreateWebhook godoc
+// @Summary Register a webhook
+// @Description Register a URL to receive signed goal and check-in events. The signing secret is only returned in this response. Each delivery carries an X-Webhook-Timestamp header with the Unix time it was sent and an X-Webhook-Signature-256 header of "sha256=" followed by the hex HMAC-SHA256, keyed with the secret, of the timestamp, a dot and the body. Receivers should reject deliveries whose timestamp is more than a few minutes old. Deliveries are only sent to public addresses; a URL that resolves to a loopback, private or link-local address fails.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param webhook body CreateSubscriptionRequest true "Webhook subscription"
+// @Success 201 {object} Subscription
+// @Failure 400 {object} apperr.Problem "Invalid JSON, URL or event types"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /webhooks [post]
+func (h *Handlers) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req CreateSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	if req.EventTypes == nil {
+		req.EventTypes = []EventType{}
+	}
+
+	subscription, err := h.webhookRepo.CreateSubscription(r.Context(), userID, req.URL, req.EventTypes)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// HandleGetWebhooks godoc
+// @Summary List webhooks
+// @Description List the authenticated user's active webhook subscriptions
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} Subscription
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /webhooks [get]
+func (h *Handlers) HandleGetWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	subscriptions, err := h.webhookRepo.GetSubscriptions(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscriptions)
+}
+
+// HandleDeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Deactivate a webhook subscription. Pending deliveries are abandoned; the delivery log is kept.
+// @Tags webhooks
+// @Security BearerAuth
+// @Param webhookId path string true "Webhook ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} apperr.Problem "Invalid webhook ID"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Webhook not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /webhooks/{webhookId} [delete]
+func (h *Handlers) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	webhookID := r.PathValue("webhookId")
+	if _, err := uuid.Parse(webhookID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	if err := h.webhookRepo.DeleteSubscription(r.Context(), webhookID, userID); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetDeliveries godoc
+// @Summary Get webhook delivery log
+// @Description List the most recent deliveries for a webhook with their status, attempt count and last response
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param webhookId path string true "Webhook ID"
+// @Param limit query int false "Number of deliveries (default 50, max 200)"
+// @Success 200 {array} Delivery
+// @Failure 400 {object} apperr.Problem "Invalid webhook ID or limit"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Webhook not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /webhooks/{webhookId}/deliveries [get]
+func (h *Handlers) HandleGetDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	webhookID := r.PathValue("webhookId")
+	if _, err := uuid.Parse(webhookID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxDeliveryLimit {
+			apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.webhookRepo.GetDeliveries(r.Context(), webhookID, userID, limit)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// HandleRedeliver godoc
+// @Summary Redeliver a webhook event
+// @Description Queue a new delivery with the same payload as an earlier one
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param webhookId path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 202 {object} Delivery
+// @Failure 400 {object} apperr.Problem "Invalid webhook or delivery ID"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 404 {object} apperr.Problem "Delivery not found"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /webhooks/{webhookId}/deliveries/{deliveryId}/redeliver [post]
+func (h *Handlers) HandleRedeliver(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	webhookID, deliveryID := r.PathValue("webhookId"), r.PathValue("deliveryId")
+	if _, err := uuid.Parse(webhookID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+	if _, err := uuid.Parse(deliveryID); err != nil {
+		apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.webhookRepo.Redeliver(r.Context(), deliveryID, webhookID, userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
@@ -0,0 +1,76 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventGoalCreated        EventType = "goal.created"
+	EventGoalUpdated        EventType = "goal.updated"
+	EventGoalDeleted        EventType = "goal.deleted"
+	EventCheckinUpdated     EventType = "checkin.updated"
+	EventCheckinCompleted   EventType = "checkin.completed"
+	EventCheckinUncompleted EventType = "checkin.uncompleted"
+	EventCheckinDeleted     EventType = "checkin.deleted"
+)
+
+// EventTypes lists every event a subscription can filter on.
+var EventTypes = []EventType{
+	EventGoalCreated,
+	EventGoalUpdated,
+	EventGoalDeleted,
+	EventCheckinUpdated,
+	EventCheckinCompleted,
+	EventCheckinUncompleted,
+	EventCheckinDeleted,
+}
+
+type DeliveryStatus string
+
+const (
+	StatusPending   DeliveryStatus = "pending"
+	StatusSucceeded DeliveryStatus = "succeeded"
+	StatusFailed    DeliveryStatus = "failed"
+)
+
+// Subscription is a user-registered endpoint. An empty EventTypes list
+// subscribes to every event.
+type Subscription struct {
+	ID         string      `json:"id" db:"id"`
+	UserID     string      `json:"user_id" db:"user_id"`
+	URL        string      `json:"url" db:"url"`
+	Secret     string      `json:"secret,omitempty" db:"secret"`
+	EventTypes []EventType `json:"event_types" db:"event_types"`
+	IsActive   bool        `json:"is_active" db:"is_active"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+}
+
+type Delivery struct {
+	ID             string          `json:"id" db:"id"`
+	SubscriptionID string          `json:"subscription_id" db:"subscription_id"`
+	EventType      EventType       `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object" db:"payload"`
+	Status         DeliveryStatus  `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at" db:"last_attempt_at"`
+	ResponseStatus *int            `json:"response_status" db:"response_status"`
+	LastError      *string         `json:"last_error" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at" db:"delivered_at"`
+}
+
+// Envelope is the JSON body posted to subscribers.
+type Envelope struct {
+	Event     EventType `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+type CreateSubscriptionRequest struct {
+	URL        string      `json:"url"`
+	EventTypes []EventType `json:"event_types"`
+}
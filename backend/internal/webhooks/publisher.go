@@ -0,0 +1,74 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+// Publisher turns goal changes into queued webhook deliveries.
+type Publisher struct {
+	webhookRepo *Repository
+	clock       func() time.Time
+}
+
+func NewPublisher(webhookRepo *Repository, clock func() time.Time) *Publisher {
+	return &Publisher{
+		webhookRepo: webhookRepo,
+		clock:       clock,
+	}
+}
+
+// GoalChanged implements goals.Listener.
+func (p *Publisher) GoalChanged(ctx context.Context, change goals.Change) error {
+	data := map[string]any{"goal": change.Goal}
+	if change.Instance != nil {
+		data["checkin"] = change.Instance
+	}
+
+	for _, eventType := range eventTypesFor(change) {
+		body, err := json.Marshal(Envelope{
+			Event:     eventType,
+			CreatedAt: p.clock().UTC(),
+			Data:      data,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook payload: %w", err)
+		}
+
+		if err := p.webhookRepo.Enqueue(ctx, change.UserID, eventType, body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func eventTypesFor(change goals.Change) []EventType {
+	switch change.Kind {
+	case goals.GoalCreated:
+		return []EventType{EventGoalCreated}
+	case goals.GoalUpdated:
+		return []EventType{EventGoalUpdated}
+	case goals.GoalDeleted:
+		return []EventType{EventGoalDeleted}
+	case goals.InstanceUpdated:
+		eventTypes := []EventType{EventCheckinUpdated}
+		wasCompleted := change.Previous != nil && change.Previous.IsCompleted
+		if change.Instance.IsCompleted && !wasCompleted {
+			eventTypes = append(eventTypes, EventCheckinCompleted)
+		} else if !change.Instance.IsCompleted && wasCompleted {
+			eventTypes = append(eventTypes, EventCheckinUncompleted)
+		}
+		return eventTypes
+	case goals.InstanceDeleted:
+		if change.Instance.IsCompleted {
+			return []EventType{EventCheckinDeleted, EventCheckinUncompleted}
+		}
+		return []EventType{EventCheckinDeleted}
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// dueDelivery is a claimed delivery together with where and how to send it.
+type dueDelivery struct {
+	Delivery
+	URL    string
+	Secret string
+}
+
+func (r *Repository) CreateSubscription(ctx context.Context, userID, url string, eventTypes []EventType) (*Subscription, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := &Subscription{
+		UserID:     userID,
+		URL:        url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		IsActive:   true,
+	}
+
+	query := `
+		INSERT INTO webhook_subscriptions (user_id, url, secret, event_types)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	err = r.db.QueryRowContext(ctx, query, userID, url, secret, pq.Array(eventTypeStrings(eventTypes))).Scan(&subscription.ID, &subscription.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// GetSubscriptions returns a user's active subscriptions without their secrets.
+func (r *Repository) GetSubscriptions(ctx context.Context, userID string) ([]Subscription, error) {
+	query := `
+		SELECT id, user_id, url, event_types, is_active, created_at
+		FROM webhook_subscriptions
+		WHERE user_id = $1 AND is_active = true
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []Subscription{}
+	for rows.Next() {
+		var subscription Subscription
+		var eventTypes pq.StringArray
+		err := rows.Scan(&subscription.ID, &subscription.UserID, &subscription.URL, &eventTypes, &subscription.IsActive, &subscription.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subscription.EventTypes = toEventTypes(eventTypes)
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription deactivates a subscription. Its delivery log is kept and
+// pending deliveries are abandoned by the dispatcher.
+func (r *Repository) DeleteSubscription(ctx context.Context, subscriptionID, userID string) error {
+	query := `UPDATE webhook_subscriptions SET is_active = false WHERE id = $1 AND user_id = $2 AND is_active = true`
+	result, err := r.db.ExecContext(ctx, query, subscriptionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("webhook not found")
+	}
+
+	return nil
+}
+
+// Enqueue queues a delivery of payload to every active subscription of the
+// user that is interested in eventType.
+func (r *Repository) Enqueue(ctx context.Context, userID string, eventType EventType, payload []byte) error {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_type, payload)
+		SELECT id, $2, $3
+		FROM webhook_subscriptions
+		WHERE user_id = $1 AND is_active = true
+		  AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, eventType, payload); err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the most recent deliveries for one of the user's
+// subscriptions, including deactivated ones.
+func (r *Repository) GetDeliveries(ctx context.Context, subscriptionID, userID string, limit int) ([]Delivery, error) {
+	if err := r.checkOwnership(ctx, subscriptionID, userID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, subscription_id, event_type, payload, status, attempts, next_attempt_at,
+		       last_attempt_at, response_status, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver queues a fresh copy of an earlier delivery. The original entry is
+// left untouched so the log keeps its history.
+func (r *Repository) Redeliver(ctx context.Context, deliveryID, subscriptionID, userID string) (*Delivery, error) {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_type, payload)
+		SELECT d.subscription_id, d.event_type, d.payload
+		FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		WHERE d.id = $1 AND d.subscription_id = $2 AND s.user_id = $3 AND s.is_active = true
+		RETURNING id, subscription_id, event_type, payload, status, attempts, next_attempt_at,
+		          last_attempt_at, response_status, last_error, created_at, delivered_at
+	`
+	delivery, err := scanDelivery(r.db.QueryRowContext(ctx, query, deliveryID, subscriptionID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("delivery not found")
+		}
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// claimDue leases up to limit pending deliveries whose next attempt is due by
+// pushing their next attempt back by lease. SKIP LOCKED lets several
+// dispatchers share the queue; a dispatcher that dies mid-send simply lets
+// the lease expire and the delivery is retried.
+func (r *Repository) claimDue(ctx context.Context, limit int, lease time.Duration) ([]dueDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND s.is_active = true
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + $2::float8 * INTERVAL '1 second'
+		FROM due, webhook_subscriptions s
+		WHERE d.id = due.id AND s.id = d.subscription_id
+		RETURNING d.id, d.subscription_id, d.event_type, d.payload, d.attempts, s.url, s.secret
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var due []dueDelivery
+	for rows.Next() {
+		var d dueDelivery
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventType, &d.Payload, &d.Attempts, &d.URL, &d.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		due = append(due, d)
+	}
+
+	return due, rows.Err()
+}
+
+// recordAttempt stores the outcome of an attempt. A failed attempt is
+// retried after retryAfter; a nil retryAfter marks it as permanently failed.
+func (r *Repository) recordAttempt(ctx context.Context, deliveryID string, responseStatus *int, attemptErr error, retryAfter *time.Duration) error {
+	status := StatusSucceeded
+	var lastError *string
+	var retrySeconds *float64
+	if attemptErr != nil {
+		msg := attemptErr.Error()
+		lastError = &msg
+		status = StatusFailed
+		if retryAfter != nil {
+			status = StatusPending
+			seconds := retryAfter.Seconds()
+			retrySeconds = &seconds
+		}
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2,
+		    attempts = attempts + 1,
+		    last_attempt_at = NOW(),
+		    next_attempt_at = NOW() + $3::float8 * INTERVAL '1 second',
+		    response_status = $4,
+		    last_error = $5,
+		    delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() ELSE NULL END
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, deliveryID, status, retrySeconds, responseStatus, lastError); err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) checkOwnership(ctx context.Context, subscriptionID, userID string) error {
+	query := `SELECT 1 FROM webhook_subscriptions WHERE id = $1 AND user_id = $2`
+	var exists int
+	if err := r.db.QueryRowContext(ctx, query, subscriptionID, userID).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("webhook not found")
+		}
+		return fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDelivery(row rowScanner) (*Delivery, error) {
+	var d Delivery
+	var payload []byte
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastAttemptAt, &d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+	}
+	d.Payload = json.RawMessage(payload)
+
+	return &d, nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func eventTypeStrings(eventTypes []EventType) []string {
+	strs := make([]string, len(eventTypes))
+	for i, t := range eventTypes {
+		strs[i] = string(t)
+	}
+	return strs
+}
+
+func toEventTypes(strs []string) []EventType {
+	eventTypes := make([]EventType, len(strs))
+	for i, s := range strs {
+		eventTypes[i] = EventType(s)
+	}
+	return eventTypes
+}
@@ -0,0 +1,38 @@
+package webhooks
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
+
+// Validate checks the URL and every event type and reports all problems at
+// once. No event types means every event.
+func (req CreateSubscriptionRequest) Validate() error {
+	var fields apperr.Fields
+
+	if req.URL == "" {
+		fields.Add("url", "is required")
+	} else if target, err := url.Parse(req.URL); err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		fields.Add("url", "must be an absolute http or https URL")
+	}
+
+	for i, eventType := range req.EventTypes {
+		if !slices.Contains(EventTypes, eventType) {
+			fields.Add("event_types["+strconv.Itoa(i)+"]", "must be one of "+eventTypeNames())
+		}
+	}
+
+	return fields.Err()
+}
+
+func eventTypeNames() string {
+	names := make([]string, len(EventTypes))
+	for i, eventType := range EventTypes {
+		names[i] = string(eventType)
+	}
+	return strings.Join(names, ", ")
+}
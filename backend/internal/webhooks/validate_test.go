@@ -0,0 +1,37 @@
+package webhooks
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+)
+
+func TestCreateSubscriptionRequestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  CreateSubscriptionRequest
+		want []string
+	}{
+		{"every event", CreateSubscriptionRequest{URL: "https://example.com/hook"}, nil},
+		{"some events", CreateSubscriptionRequest{URL: "http://example.com:8080/hook", EventTypes: []EventType{EventGoalCreated, EventCheckinCompleted}}, nil},
+		{"no URL", CreateSubscriptionRequest{}, []string{"url"}},
+		{"relative URL", CreateSubscriptionRequest{URL: "/hook"}, []string{"url"}},
+		{"other scheme", CreateSubscriptionRequest{URL: "ftp://example.com/hook"}, []string{"url"}},
+		{"unknown events", CreateSubscriptionRequest{URL: "https://example.com/hook", EventTypes: []EventType{EventGoalCreated, "goal.exploded", ""}}, []string{"event_types[1]", "event_types[2]"}},
+		{"every problem at once", CreateSubscriptionRequest{URL: "example.com", EventTypes: []EventType{"goal.exploded"}}, []string{"url", "event_types[0]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			if err := tt.req.Validate(); err != nil {
+				for _, f := range apperr.FieldsOf(err) {
+					got = append(got, f.Field)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Validate rejected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Package xp awards experience points for completed goals and derives levels
+package xp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/JoshPugli/grindhouse-api/internal/apperr"
+	"github.com/JoshPugli/grindhouse-api/internal/auth"
+)
+
+const (
+	defaultRecentAwards = 20
+	maxRecentAwards     = 100
+)
+
+type Handlers struct {
+	xpService *Service
+}
+
+func NewHandlers(xpService *Service) *Handlers {
+	return &Handlers{
+		xpService: xpService,
+	}
+}
+
+// HandleGetXP godoc
+// @Summary Get experience summary
+// @Description Get the authenticated user's total experience, level and most recent ledger entries
+// @Tags xp
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of recent awards (default 20, max 100)"
+// @Success 200 {object} Summary
+// @Failure 400 {object} apperr.Problem "Invalid limit"
+// @Failure 401 {object} apperr.Problem "Unauthorized"
+// @Failure 500 {object} apperr.Problem "Internal server error"
+// @Router /xp [get]
+func (h *Handlers) HandleGetXP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.WriteProblem(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	limit := defaultRecentAwards
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxRecentAwards {
+			apperr.WriteProblem(w, r, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	summary, err := h.xpService.Summary(r.Context(), userID, limit)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
@@ -0,0 +1,56 @@
+package xp
+
+import (
+	"math"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+const (
+	// BaseAward is the experience for completing a medium goal with no streak.
+	BaseAward = 10
+
+	// streakBonusPerDay is the multiplier added for each consecutive day
+	// beyond the first, up to maxStreakMultiplier.
+	streakBonusPerDay   = 0.1
+	maxStreakMultiplier = 2.0
+
+	// levelStep scales the level curve: reaching level n takes
+	// levelStep * (n-1)^2 experience in total.
+	levelStep = 100
+)
+
+var difficultyWeights = map[goals.GoalDifficulty]float64{
+	goals.DifficultyEasy:   0.5,
+	goals.DifficultyMedium: 1,
+	goals.DifficultyHard:   2,
+}
+
+// AwardFor returns the experience for completing a goal of the given
+// difficulty as part of a streak of the given length.
+func AwardFor(difficulty goals.GoalDifficulty, streak int) int {
+	weight, ok := difficultyWeights[difficulty]
+	if !ok {
+		weight = 1
+	}
+
+	multiplier := 1.0
+	if streak > 1 {
+		multiplier = min(1+float64(streak-1)*streakBonusPerDay, maxStreakMultiplier)
+	}
+
+	return int(math.Round(BaseAward * weight * multiplier))
+}
+
+// LevelFor returns the level reached with total experience.
+func LevelFor(total int) int {
+	if total <= 0 {
+		return 1
+	}
+	return int(math.Sqrt(float64(total)/levelStep)) + 1
+}
+
+// XPForLevel returns the total experience needed to reach level.
+func XPForLevel(level int) int {
+	return levelStep * (level - 1) * (level - 1)
+}
@@ -0,0 +1,73 @@
+package xp_test
+
+import (
+	"testing"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/xp"
+)
+
+func TestAwardFor(t *testing.T) {
+	tests := []struct {
+		difficulty goals.GoalDifficulty
+		streak     int
+		want       int
+	}{
+		{goals.DifficultyMedium, 0, xp.BaseAward},
+		{goals.DifficultyMedium, 1, xp.BaseAward},
+		{goals.DifficultyEasy, 1, 5},
+		{goals.DifficultyHard, 1, 20},
+		{"", 1, xp.BaseAward},
+		{goals.DifficultyMedium, 2, 11},
+		{goals.DifficultyEasy, 2, 6},
+		{goals.DifficultyEasy, 6, 8},
+		{goals.DifficultyHard, 6, 30},
+		{goals.DifficultyMedium, 11, 20},
+		{goals.DifficultyMedium, 12, 20},
+		{goals.DifficultyHard, 365, 40},
+	}
+	for _, tt := range tests {
+		if got := xp.AwardFor(tt.difficulty, tt.streak); got != tt.want {
+			t.Errorf("AwardFor(%q, %d) = %d, want %d", tt.difficulty, tt.streak, got, tt.want)
+		}
+	}
+}
+
+func TestLevelFor(t *testing.T) {
+	tests := []struct {
+		total int
+		want  int
+	}{
+		{-10, 1},
+		{0, 1},
+		{99, 1},
+		{100, 2},
+		{399, 2},
+		{400, 3},
+		{899, 3},
+		{900, 4},
+		{8100, 10},
+	}
+	for _, tt := range tests {
+		if got := xp.LevelFor(tt.total); got != tt.want {
+			t.Errorf("LevelFor(%d) = %d, want %d", tt.total, got, tt.want)
+		}
+	}
+}
+
+// TestXPForLevel checks that XPForLevel is where LevelFor moves up, so the
+// progress shown towards the next level ends exactly at it.
+func TestXPForLevel(t *testing.T) {
+	if got := xp.XPForLevel(1); got != 0 {
+		t.Errorf("XPForLevel(1) = %d, want 0", got)
+	}
+	for level := 2; level <= 50; level++ {
+		threshold := xp.XPForLevel(level)
+		if got := xp.LevelFor(threshold); got != level {
+			t.Errorf("LevelFor(XPForLevel(%d)) = %d, want %d", level, got, level)
+		}
+		if got := xp.LevelFor(threshold - 1); got != level-1 {
+			t.Errorf("LevelFor(XPForLevel(%d) - 1) = %d, want %d", level, got, level-1)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package xp
+
+import "time"
+
+type Reason string
+
+const (
+	ReasonCompletion Reason = "completion"
+	ReasonReversal   Reason = "reversal"
+)
+
+// Award is a single ledger entry. Reversals are recorded as negative awards
+// so the ledger can be summed to get a user's total.
+type Award struct {
+	ID         string    `json:"id" db:"id"`
+	GoalID     string    `json:"goal_id" db:"goal_id"`
+	GoalTitle  string    `json:"goal_title" db:"title"`
+	InstanceID string    `json:"instance_id" db:"instance_id"`
+	Amount     int       `json:"amount" db:"amount"`
+	Reason     Reason    `json:"reason" db:"reason"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+type Summary struct {
+	Total        int     `json:"total"`
+	Level        int     `json:"level"`
+	LevelFloorXP int     `json:"level_floor_xp"`
+	NextLevelXP  int     `json:"next_level_xp"`
+	RecentAwards []Award `json:"recent_awards"`
+}
@@ -0,0 +1,120 @@
+package xp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Reconcile brings the ledger for a daily instance in line with its
+// completion state: a completed instance with no outstanding award is
+// credited the amount returned by amount, and an uncompleted or deleted
+// instance with an outstanding award has it reversed. The instance row is
+// locked for the duration, and its state is read and amount called under
+// the lock, so concurrent check-ins neither double award, nor leave an
+// award against an instance a later check-in uncompleted, nor award for a
+// stale streak. A deleted instance has no row, so its ledger entries are
+// locked instead. It returns the entry written, if any.
+func (r *Repository) Reconcile(ctx context.Context, userID, goalID, instanceID string, amount func(ctx context.Context) (int, error)) (*Award, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	lockQuery := `SELECT is_completed FROM daily_goal_instances WHERE id = $1 AND user_id = $2 FOR UPDATE`
+	var completed bool
+	err = tx.QueryRowContext(ctx, lockQuery, instanceID, userID).Scan(&completed)
+	if err == sql.ErrNoRows {
+		ledgerLockQuery := `SELECT id FROM xp_ledger WHERE instance_id = $1 AND user_id = $2 FOR UPDATE`
+		if _, err := tx.ExecContext(ctx, ledgerLockQuery, instanceID, userID); err != nil {
+			return nil, fmt.Errorf("failed to lock award: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to lock daily instance: %w", err)
+	}
+
+	// Read after the lock so a reversal committed while waiting is seen.
+	var outstanding int
+	sumQuery := `SELECT COALESCE(SUM(amount), 0) FROM xp_ledger WHERE instance_id = $1 AND user_id = $2`
+	if err := tx.QueryRowContext(ctx, sumQuery, instanceID, userID).Scan(&outstanding); err != nil {
+		return nil, fmt.Errorf("failed to get outstanding award: %w", err)
+	}
+
+	award := &Award{GoalID: goalID, InstanceID: instanceID}
+	switch {
+	case completed && outstanding == 0:
+		award.Amount, err = amount(ctx)
+		if err != nil {
+			return nil, err
+		}
+		award.Reason = ReasonCompletion
+	case !completed && outstanding > 0:
+		award.Amount = -outstanding
+		award.Reason = ReasonReversal
+	default:
+		return nil, nil
+	}
+
+	insertQuery := `
+		INSERT INTO xp_ledger (user_id, goal_id, instance_id, amount, reason)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRowContext(ctx, insertQuery, userID, goalID, instanceID, award.Amount, award.Reason).Scan(&award.ID, &award.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record award: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit award: %w", err)
+	}
+
+	return award, nil
+}
+
+func (r *Repository) GetTotal(ctx context.Context, userID string) (int, error) {
+	query := `SELECT COALESCE(SUM(amount), 0) FROM xp_ledger WHERE user_id = $1`
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to get total xp: %w", err)
+	}
+
+	return total, nil
+}
+
+func (r *Repository) GetRecentAwards(ctx context.Context, userID string, limit int) ([]Award, error) {
+	query := `
+		SELECT l.id, l.goal_id, g.title, l.instance_id, l.amount, l.reason, l.created_at
+		FROM xp_ledger l
+		JOIN goals g ON g.id = l.goal_id
+		WHERE l.user_id = $1
+		ORDER BY l.created_at DESC, l.id DESC
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get awards: %w", err)
+	}
+	defer rows.Close()
+
+	awards := []Award{}
+	for rows.Next() {
+		var award Award
+		err := rows.Scan(&award.ID, &award.GoalID, &award.GoalTitle, &award.InstanceID, &award.Amount, &award.Reason, &award.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan award: %w", err)
+		}
+		awards = append(awards, award)
+	}
+
+	return awards, nil
+}
@@ -0,0 +1,63 @@
+package xp_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+	"github.com/JoshPugli/grindhouse-api/internal/storetest"
+	"github.com/JoshPugli/grindhouse-api/internal/xp"
+)
+
+// TestReconcileReversesCredit credits a completed instance, un-completes it
+// and checks that the reversal takes back exactly what was credited, even
+// though the award the instance would earn now is different.
+func TestReconcileReversesCredit(t *testing.T) {
+	db := storetest.OpenPostgres(t)
+	ctx := t.Context()
+
+	owner := storetest.NewUser(t, db)
+	goalRepo := goals.NewRepository(db, time.Now)
+	goal, err := goalRepo.CreateGoal(ctx, owner.ID, goals.CreateGoalRequest{Title: "Read", GoalType: goals.GoalTypeBoolean, Difficulty: goals.DifficultyHard})
+	if err != nil {
+		t.Fatalf("CreateGoal: %v", err)
+	}
+
+	date := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	checkIn := func(completed bool) string {
+		t.Helper()
+		upserted, err := goalRepo.UpsertDailyInstance(ctx, goal.ID, owner.ID, date, goals.UpdateDailyInstanceRequest{IsCompleted: &completed}, nil)
+		if err != nil {
+			t.Fatalf("UpsertDailyInstance: %v", err)
+		}
+		return upserted.Instance.ID
+	}
+	award := func(streak int) func(context.Context) (int, error) {
+		return func(context.Context) (int, error) { return xp.AwardFor(goal.Difficulty, streak), nil }
+	}
+
+	repo := xp.NewRepository(db)
+	instanceID := checkIn(true)
+	credit, err := repo.Reconcile(ctx, owner.ID, goal.ID, instanceID, award(6))
+	if err != nil || credit == nil || credit.Reason != xp.ReasonCompletion || credit.Amount != xp.AwardFor(goal.Difficulty, 6) {
+		t.Fatalf("Reconcile of a completed instance returned %+v, %v, want a credit of %d", credit, err, xp.AwardFor(goal.Difficulty, 6))
+	}
+	if again, err := repo.Reconcile(ctx, owner.ID, goal.ID, instanceID, award(6)); again != nil || err != nil {
+		t.Errorf("Reconcile of a credited instance returned %+v, %v, want nothing written", again, err)
+	}
+
+	checkIn(false)
+	reversal, err := repo.Reconcile(ctx, owner.ID, goal.ID, instanceID, award(1))
+	if err != nil || reversal == nil || reversal.Reason != xp.ReasonReversal || reversal.Amount != -credit.Amount {
+		t.Fatalf("Reconcile of an un-completed instance returned %+v, %v, want a reversal of %d", reversal, err, -credit.Amount)
+	}
+
+	total, err := repo.GetTotal(ctx, owner.ID)
+	if err != nil {
+		t.Fatalf("GetTotal: %v", err)
+	}
+	if total != 0 {
+		t.Errorf("total is %d after the reversal, want 0", total)
+	}
+}
@@ -0,0 +1,66 @@
+package xp
+
+import (
+	"context"
+
+	"github.com/JoshPugli/grindhouse-api/internal/goals"
+)
+
+// Service awards experience as daily instances are completed and reports
+// totals and levels.
+type Service struct {
+	xpRepo   *Repository
+	goalRepo goals.GoalStore
+}
+
+func NewService(xpRepo *Repository, goalRepo goals.GoalStore) *Service {
+	return &Service{
+		xpRepo:   xpRepo,
+		goalRepo: goalRepo,
+	}
+}
+
+// GoalChanged implements goals.Listener. Completing an instance credits an
+// award weighted by goal difficulty and the current streak; un-completing
+// or deleting it writes a matching reversal. The ledger follows the
+// instance as it is when reconciled rather than as the change left it,
+// since a concurrent check-in may have changed it since.
+func (s *Service) GoalChanged(ctx context.Context, change goals.Change) error {
+	if (change.Kind != goals.InstanceUpdated && change.Kind != goals.InstanceDeleted) || change.Instance == nil {
+		return nil
+	}
+
+	instance := change.Instance
+	amount := func(ctx context.Context) (int, error) {
+		streak, err := s.goalRepo.GetCurrentStreak(ctx, instance.GoalID, instance.Date)
+		if err != nil {
+			return 0, err
+		}
+		return AwardFor(change.Goal.Difficulty, streak), nil
+	}
+
+	_, err := s.xpRepo.Reconcile(ctx, change.UserID, instance.GoalID, instance.ID, amount)
+	return err
+}
+
+// Summary returns a user's total experience, level and most recent awards.
+func (s *Service) Summary(ctx context.Context, userID string, recent int) (*Summary, error) {
+	total, err := s.xpRepo.GetTotal(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	awards, err := s.xpRepo.GetRecentAwards(ctx, userID, recent)
+	if err != nil {
+		return nil, err
+	}
+
+	level := LevelFor(total)
+	return &Summary{
+		Total:        total,
+		Level:        level,
+		LevelFloorXP: XPForLevel(level),
+		NextLevelXP:  XPForLevel(level + 1),
+		RecentAwards: awards,
+	}, nil
+}